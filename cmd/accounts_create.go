@@ -34,7 +34,7 @@ var accountsCreateCmd = &cobra.Command{
 	Args:              cobra.MinimumNArgs(1),
 	ValidArgsFunction: listStacks,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 
 		version, err := docker.CheckDockerConfig()
@@ -20,6 +20,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/hyperledger/firefly-cli/internal/docker"
 	"github.com/hyperledger/firefly-cli/internal/log"
@@ -36,7 +40,7 @@ var accountsListCmd = &cobra.Command{
 	Args:              cobra.ExactArgs(1),
 	Aliases:           []string{"ls"},
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 
 		version, err := docker.CheckDockerConfig()
@@ -45,18 +49,67 @@ var accountsListCmd = &cobra.Command{
 		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
 		stackName := args[0]
 		stackManager := stacks.NewStackManager(cmd.Context())
 		if err := stackManager.LoadStack(stackName); err != nil {
 			return err
 		}
-		accounts, err := json.MarshalIndent(stackManager.Stack.State.Accounts, "", "  ")
+		format := outputFormat
+		if !cmd.Flags().Changed("output") {
+			// Accounts have always been listed as JSON by default
+			format = outputJSON
+		}
+		if format != outputTable {
+			return printStructuredOutput(format, stackManager.Stack.State.Accounts)
+		}
+		return printAccountsTable(stackManager.Stack.State.Accounts)
+	},
+}
+
+// printAccountsTable prints one row per account. The fields of an account depend on the
+// blockchain provider, so the columns are taken from the JSON representation of the accounts.
+func printAccountsTable(accounts []interface{}) error {
+	rows := make([]map[string]interface{}, 0, len(accounts))
+	columnSet := make(map[string]bool)
+	for _, account := range accounts {
+		accountBytes, err := json.Marshal(account)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("%s\n", string(accounts))
-		return nil
-	},
+		var row map[string]interface{}
+		if err := json.Unmarshal(accountBytes, &row); err != nil {
+			return err
+		}
+		for column := range row {
+			columnSet[column] = true
+		}
+		rows = append(rows, row)
+	}
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			if value, ok := row[column]; ok && value != nil {
+				values[i] = fmt.Sprint(value)
+			}
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	return w.Flush()
 }
 
 func init() {
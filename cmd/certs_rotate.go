@@ -0,0 +1,84 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+// certsRotateCmd represents the "certs rotate" command
+var certsRotateCmd = &cobra.Command{
+	Use:   "rotate <stack_name>",
+	Short: "Issue new data exchange certificates for every member of a stack",
+	Long: `Issue new data exchange certificates for every member of a stack
+
+Data exchange certificates are valid for one year. This command issues new
+certificates, signed by the stack's CA, and copies them into the data exchange
+volumes. Any data exchange containers that are running are restarted.
+
+Stacks created with older versions of the CLI have self-signed certificates.
+The first rotation creates a CA for the stack.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: listStacks,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var spin *spinner.Spinner
+		if fancyFeatures && !verbose {
+			spin = spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+			logger = log.NewSpinnerLogger(spin)
+		}
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackName := args[0]
+		stackManager := stacks.NewStackManager(ctx)
+		if err := stackManager.LoadStack(stackName); err != nil {
+			return err
+		}
+
+		fmt.Printf("rotating certificates for FireFly stack '%s'... ", stackName)
+		if spin != nil {
+			spin.Start()
+		}
+		err = stackManager.RotateDataExchangeCerts()
+		if spin != nil {
+			spin.Stop()
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("done\n\nCertificates for stack '%s' have been rotated\n\n", stackName)
+		return nil
+	},
+}
+
+func init() {
+	certsCmd.AddCommand(certsRotateCmd)
+}
@@ -0,0 +1,93 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <source_stack_name> <stack_name>",
+	Short: "Create a new stack with the same configuration as an existing one",
+	Long: `Create a new stack with the same configuration as an existing one
+
+This command creates a new stack with the same members, blockchain and
+token providers, extra core and connector config, environment variables
+and versions as the source stack. The new stack gets its own free ports,
+blockchain accounts, keys, swarm key and data exchange certificates.
+None of the data in the source stack is copied - use snapshot and restore
+for that.
+
+Extra connector config is only copied from stacks created by this version
+of the CLI or later.
+`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return listStacks(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var spin *spinner.Spinner
+		if fancyFeatures && !verbose {
+			spin = spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+			logger = log.NewSpinnerLogger(spin)
+		}
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackManager := stacks.NewStackManager(ctx)
+		sourceName := args[0]
+		stackName := args[1]
+		if err := validateStackName(stackName); err != nil {
+			return err
+		}
+
+		fmt.Printf("cloning FireFly stack '%s' as '%s'... ", sourceName, stackName)
+		if spin != nil {
+			spin.Start()
+		}
+		err = stackManager.CloneStack(sourceName, stackName)
+		if spin != nil {
+			spin.Stop()
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n\nStack '%s' created!\nTo start your new stack run:\n\n%s start %s\n\n", stackName, rootCmd.Use, stackName)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+}
@@ -40,7 +40,7 @@ solc --combined-json abi,bin contract.sol > contract.json
 `,
 	Args: cobra.MinimumNArgs(2),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 
 		version, err := docker.CheckDockerConfig()
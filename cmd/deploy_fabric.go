@@ -34,7 +34,7 @@ var deployFabricCmd = &cobra.Command{
 	Long:              `Deploy a packaged chaincode to the Fabric network used by a FireFly stack`,
 	Args:              cobra.ExactArgs(5),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 
 		version, err := docker.CheckDockerConfig()
@@ -0,0 +1,73 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+var devAttachCmd = &cobra.Command{
+	Use:   "attach <stack_name> <service>",
+	Short: "Put a detached service back into a stack",
+	Long: `Put a detached service back into a stack
+
+This command reverses the detach command. The container for the service is
+added back to the docker compose file, every other service is changed to
+use it again, and it is started if the stack is running. Changes made to
+the data of the local build are not copied back into the container.
+`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return listStacks(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackManager := stacks.NewStackManager(ctx)
+		stackName := args[0]
+		serviceName := args[1]
+		if err := stackManager.LoadStack(stackName); err != nil {
+			return err
+		}
+
+		if err := stackManager.AttachService(serviceName); err != nil {
+			return err
+		}
+		fmt.Printf("Service '%s' attached to stack '%s'\n", serviceName, stackName)
+		return nil
+	},
+}
+
+func init() {
+	devCmd.AddCommand(devAttachCmd)
+}
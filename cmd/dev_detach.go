@@ -0,0 +1,112 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+var devDetachCmd = &cobra.Command{
+	Use:   "detach <stack_name> <service>",
+	Short: "Replace a service in a stack with a local build",
+	Long: `Replace a service in a stack with a local build
+
+This command takes a FireFly core, blockchain connector, tokens or data
+exchange service (for example firefly_core_0, evmconnect_0, tokens_0_0 or
+dataexchange_0) out of the docker compose file for the stack. Its config
+files, environment and data are copied to the dev directory of the stack,
+changed to use the ports of the other services on the host, and the
+command to run a local build with them is printed. Every other service is
+changed to use the local build.
+
+The stack must have been started at least once. Use the attach command to
+put the container back.
+`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return listStacks(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackManager := stacks.NewStackManager(ctx)
+		stackName := args[0]
+		serviceName := args[1]
+		if err := stackManager.LoadStack(stackName); err != nil {
+			return err
+		}
+
+		detached, err := stackManager.DetachService(serviceName)
+		if err != nil {
+			return err
+		}
+		printDetachedService(detached)
+		fmt.Printf("\nTo put the container back run:\n\n%s dev attach %s %s\n\n", rootCmd.Use, stackName, serviceName)
+		return nil
+	},
+}
+
+func printDetachedService(detached *stacks.DetachedService) {
+	fmt.Printf("Service '%s' detached. Its files are in %s\n", detached.Service, detached.Dir)
+	if len(detached.Ports) > 0 {
+		fmt.Print("\nThe local build must listen on these ports:\n")
+		containerPorts := make([]int, 0, len(detached.Ports))
+		for containerPort := range detached.Ports {
+			containerPorts = append(containerPorts, containerPort)
+		}
+		sort.Ints(containerPorts)
+		for _, containerPort := range containerPorts {
+			fmt.Printf("  %d (port %d in the container)\n", detached.Ports[containerPort], containerPort)
+		}
+	}
+	if len(detached.Files) > 0 {
+		fmt.Print("\nFiles mounted into the container have been copied to:\n")
+		targets := make([]string, 0, len(detached.Files))
+		for target := range detached.Files {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		for _, target := range targets {
+			fmt.Printf("  %s -> %s\n", target, detached.Files[target])
+		}
+	}
+	if detached.EnvFile != "" {
+		fmt.Printf("\nThe environment of the container has been written to:\n  %s\n", detached.EnvFile)
+	}
+	fmt.Printf("\nTo run your local build:\n\n%s\n", detached.Command)
+}
+
+func init() {
+	devCmd.AddCommand(devDetachCmd)
+}
@@ -0,0 +1,93 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that your environment can run FireFly stacks",
+	Long: `Check that your environment can run FireFly stacks
+
+This checks the Docker and Docker Compose installations, free disk space, the
+permissions of the FireFly home directory, and whether any images used by your
+stacks need emulation on this machine.
+It also looks for ports that are shared between stacks or already in use, and
+for docker volumes left behind by stacks or members that have been removed.
+
+Each check passes, warns or fails, with a hint on how to fix any problems.
+The command exits with a non-zero status if any check fails.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		// A broken docker install is reported by the checks themselves, so the
+		// version is only needed for the checks that look at running stacks
+		if version, err := checkDockerConfig(); err == nil {
+			ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+		}
+
+		checks := stacks.RunDoctorChecks(ctx)
+		var err error
+		if outputFormat != outputTable {
+			err = printStructuredOutput(outputFormat, checks)
+		} else {
+			err = printDoctorChecks(checks)
+		}
+		if err != nil {
+			return err
+		}
+		for _, check := range checks {
+			if check.Result == types.DoctorResultFail {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("one or more checks failed")
+			}
+		}
+		return nil
+	},
+}
+
+func printDoctorChecks(checks []*types.DoctorCheck) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, check := range checks {
+		fmt.Fprintf(w, "[%s]\t%s\t%s\n", strings.ToUpper(check.Result), check.Name, check.Message)
+		if check.Remediation != "" {
+			fmt.Fprintf(w, "\t\t-> %s\n", check.Remediation)
+		}
+	}
+	return w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,102 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+)
+
+const (
+	envFormatShell  = "shell"
+	envFormatDotenv = "dotenv"
+	envFormatJSON   = "json"
+)
+
+var envMember string
+var envFormat string
+
+var envCmd = &cobra.Command{
+	Use:   "env <stack_name>",
+	Short: "Print the endpoints of a stack as environment variables",
+	Long: `Print the endpoints of a stack as environment variables
+
+This command prints the URL of every API a client app needs, along with the
+org names and signing keys of the members. By default the variables of every
+member are printed with an FF_MEMBER_<id>_ prefix, using the member IDs that
+status shows. With --member, only that member is printed, with an FF_ prefix. To set them in your shell run:
+
+eval $(ff env <stack_name>)
+`,
+	ValidArgsFunction: listStacks,
+	Args:              cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+		stackManager := stacks.NewStackManager(ctx)
+		if err := stackManager.LoadStack(args[0]); err != nil {
+			return err
+		}
+
+		env, err := stackManager.GetStackEnvironment(envMember)
+		if err != nil {
+			return err
+		}
+		switch envFormat {
+		case envFormatShell:
+			for _, v := range env {
+				fmt.Printf("export %s=%s\n", v.Name, shellQuote(v.Value))
+			}
+		case envFormatDotenv:
+			for _, v := range env {
+				fmt.Printf("%s=%s\n", v.Name, dotenvQuote(v.Value))
+			}
+		case envFormatJSON:
+			values := make(map[string]string, len(env))
+			for _, v := range env {
+				values[v.Name] = v.Value
+			}
+			return printStructuredOutput(outputJSON, values)
+		default:
+			return fmt.Errorf("invalid format '%s'", envFormat)
+		}
+		return nil
+	},
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func dotenvQuote(value string) string {
+	if strings.ContainsAny(value, " \t\n#\"'$\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func init() {
+	envCmd.Flags().StringVar(&envMember, "member", "", "Only print the variables for the member with this ID")
+	envCmd.Flags().StringVar(&envFormat, "format", envFormatShell, fmt.Sprintf("Output format (%q|%q|%q)", envFormatShell, envFormatDotenv, envFormatJSON))
+	rootCmd.AddCommand(envCmd)
+}
@@ -0,0 +1,84 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+)
+
+var exportDefinitionCmd = &cobra.Command{
+	Use:   "export-definition <stack_name> [filename]",
+	Short: "Write a stack definition file for an existing stack",
+	Long: `Write a stack definition file for an existing stack
+
+The definition can be checked in and used to create an equivalent stack with:
+
+ff init --from <filename>
+
+Settings that are not recorded in the stack, such as the paths of extra config
+files, are not included. If no filename is given, the definition is printed.
+`,
+	ValidArgsFunction: listStacks,
+	Args:              cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+		stackManager := stacks.NewStackManager(ctx)
+		if err := stackManager.LoadStack(args[0]); err != nil {
+			return err
+		}
+
+		var (
+			bytes []byte
+			err   error
+		)
+		definition := stackManager.ExportStackDefinition()
+		format := outputFormat
+		if !cmd.Flags().Changed("output") {
+			format = outputYAML
+		}
+		switch format {
+		case outputJSON:
+			bytes, err = json.MarshalIndent(definition, "", "  ")
+		case outputYAML:
+			bytes, err = yaml.Marshal(definition)
+		default:
+			return fmt.Errorf("invalid output '%s'", format)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(args) > 1 {
+			return os.WriteFile(args[1], bytes, 0755)
+		}
+		fmt.Println(string(bytes))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportDefinitionCmd)
+}
@@ -0,0 +1,77 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+var exportK8sNamespace string
+
+var exportK8sCmd = &cobra.Command{
+	Use:   "k8s <stack_name> <dir>",
+	Short: "Write Kubernetes manifests for a stack",
+	Long: `Write Kubernetes manifests for a stack
+
+This command turns the docker compose file and the generated config of a
+stack into Kubernetes manifests, with one file per object and a
+kustomization.yaml that lists them all. Each service becomes a Deployment,
+or a StatefulSet if it has a volume, along with a Service for its ports.
+Config files are put in ConfigMaps, and keys and certificates in Secrets.
+
+The stack must have been started at least once, so that its config has been
+generated. The data in the volumes of the stack is not exported, and first
+time setup is not run again, so with a local blockchain the contracts that
+FireFly uses have to be deployed again before the stack can be used.
+
+To deploy the stack run:
+
+kubectl apply -k <dir>
+`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return listStacks(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+		stackManager := stacks.NewStackManager(ctx)
+		stackName := args[0]
+		dir := args[1]
+		if err := stackManager.LoadStack(stackName); err != nil {
+			return err
+		}
+
+		if err := stackManager.ExportKubernetes(dir, exportK8sNamespace); err != nil {
+			return err
+		}
+		fmt.Printf("Kubernetes manifests for stack '%s' written to '%s'\n", stackName, dir)
+		return nil
+	},
+}
+
+func init() {
+	exportK8sCmd.Flags().StringVarP(&exportK8sNamespace, "namespace", "n", "", "The namespace to set in the kustomization.yaml")
+	exportCmd.AddCommand(exportK8sCmd)
+}
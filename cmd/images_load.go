@@ -0,0 +1,80 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+var imagesLoadCmd = &cobra.Command{
+	Use:   "load <filename>",
+	Short: "Load the images saved from a stack",
+	Long: `Load the images saved from a stack
+
+This command loads every docker image in a bundle written by the images
+save command, and writes the manifest of the stack the bundle was saved
+from to the FireFly home directory. The manifest can be passed to init
+along with --offline to create a stack without any network access. The
+new stack must use the same blockchain, connector and token providers as
+the stack the bundle was saved from.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var spin *spinner.Spinner
+		if fancyFeatures && !verbose {
+			spin = spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+			logger = log.NewSpinnerLogger(spin)
+		}
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackManager := stacks.NewStackManager(ctx)
+		filename := args[0]
+
+		fmt.Printf("loading images from '%s'... ", filename)
+		if spin != nil {
+			spin.Start()
+		}
+		bundle, manifestPath, err := stackManager.LoadImageBundle(filename)
+		if err != nil {
+			return err
+		}
+		if spin != nil {
+			spin.Stop()
+		}
+		fmt.Printf("\n\nLoaded %d images saved from stack '%s'. To create a stack that uses them run:\n\n%s init --offline --manifest %s\n\n", len(bundle.Images), bundle.StackName, rootCmd.Use, manifestPath)
+		return nil
+	},
+}
+
+func init() {
+	imagesCmd.AddCommand(imagesLoadCmd)
+}
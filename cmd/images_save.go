@@ -0,0 +1,81 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+var imagesSaveCmd = &cobra.Command{
+	Use:               "save <stack_name> <filename>",
+	Short:             "Save every image a stack needs to a file",
+	ValidArgsFunction: listStacks,
+	Long: `Save every image a stack needs to a file
+
+This command writes all of the docker images used by a stack to a single
+bundle, pulling any that are missing first. The bundle can be copied to a
+machine with no network access and loaded with the images load command.
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var spin *spinner.Spinner
+		if fancyFeatures && !verbose {
+			spin = spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+			logger = log.NewSpinnerLogger(spin)
+		}
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackManager := stacks.NewStackManager(ctx)
+		stackName := args[0]
+		filename := args[1]
+		if err := stackManager.LoadStack(stackName); err != nil {
+			return err
+		}
+
+		fmt.Printf("saving images of FireFly stack '%s'... ", stackName)
+		if spin != nil {
+			spin.Start()
+		}
+		if err := stackManager.SaveImageBundle(filename); err != nil {
+			return err
+		}
+		if spin != nil {
+			spin.Stop()
+		}
+		fmt.Printf("\n\nImages written to '%s'. To load them on another machine run:\n\n%s images load %s\n\n", filename, rootCmd.Use, filename)
+		return nil
+	},
+}
+
+func init() {
+	imagesCmd.AddCommand(imagesSaveCmd)
+}
@@ -0,0 +1,94 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+var imageLocal bool
+
+var imagesSetCmd = &cobra.Command{
+	Use:   "set <stack_name> <component> <image[:tag|@sha256:digest]>",
+	Short: "Change the image used by one component of a stack",
+	Long: fmt.Sprintf(`Change the image used by one component of a stack
+
+This command updates the manifest of the stack, regenerates the docker
+compose file, and recreates only the services that use the component if
+the stack is running. Use --local for an image that has been built locally,
+so that it is never pulled.
+
+Components: %s
+
+tokens is the token provider of a stack that has exactly one.
+`, strings.Join(stacks.ManifestComponentNames(), ", ")),
+	Args: cobra.ExactArgs(3),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return listStacks(cmd, args, toComplete)
+		case 1:
+			return stacks.ManifestComponentNames(), cobra.ShellCompDirectiveNoFileComp
+		default:
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackManager := stacks.NewStackManager(ctx)
+		stackName := args[0]
+		component := args[1]
+		image := args[2]
+		if err := stackManager.LoadStack(stackName); err != nil {
+			return err
+		}
+
+		services, recreated, err := stackManager.SetComponentImage(component, image, imageLocal)
+		if err != nil {
+			return err
+		}
+		switch {
+		case len(services) == 0:
+			fmt.Printf("Image of %s set to '%s'. No services in stack '%s' use %s\n", component, image, stackName, component)
+		case recreated:
+			fmt.Printf("Image of %s set to '%s'. Recreated %s\n", component, image, strings.Join(services, ", "))
+		default:
+			fmt.Printf("Image of %s set to '%s'. %s will use it the next time the stack is started\n", component, image, strings.Join(services, ", "))
+		}
+		return nil
+	},
+}
+
+func init() {
+	imagesSetCmd.Flags().BoolVar(&imageLocal, "local", false, "The image has been built locally, and should never be pulled")
+	imagesCmd.AddCommand(imagesSetCmd)
+}
@@ -33,7 +33,10 @@ var infoCmd = &cobra.Command{
 	Long: `Get info about a stack such as each container name
 	and image version.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = context.WithValue(ctx, docker.CtxIsLogCmdKey{}, true)
 		ctx = log.WithLogger(ctx, logger)
 
@@ -52,6 +55,13 @@ var infoCmd = &cobra.Command{
 		if err := stackManager.LoadStack(stackName); err != nil {
 			return err
 		}
+		if outputFormat != outputTable {
+			info, err := stackManager.GetStackInfo(true)
+			if err != nil {
+				return err
+			}
+			return printStructuredOutput(outputFormat, info)
+		}
 		if err := stackManager.PrintStackInfo(); err != nil {
 			return err
 		}
@@ -22,6 +22,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
@@ -29,6 +30,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/hyperledger/firefly-cli/internal/core"
 	"github.com/hyperledger/firefly-cli/internal/log"
 	"github.com/hyperledger/firefly-cli/internal/stacks"
 	"github.com/hyperledger/firefly-cli/pkg/types"
@@ -37,6 +39,11 @@ import (
 
 var initOptions types.InitOptions
 var promptNames bool
+var initFrom string
+var initHooks []string
+var remoteNodeAuth types.RemoteNodeAuth
+var remoteNodeHeaders []string
+var remoteNodeCAFile, remoteNodeCertFile, remoteNodeKeyFile string
 
 var ffNameValidator = regexp.MustCompile(`^[0-9a-zA-Z]([0-9a-zA-Z._-]{0,62}[0-9a-zA-Z])?$`)
 
@@ -48,9 +55,13 @@ var initCmd = &cobra.Command{
 	Long:  `Create a new FireFly local dev stack`,
 	Args:  cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 		stackManager := stacks.NewStackManager(ctx)
+		args, err := applyStackDefinition(cmd, args)
+		if err != nil {
+			return err
+		}
 		if err := initCommon(args); err != nil {
 			return err
 		}
@@ -66,10 +77,67 @@ var initCmd = &cobra.Command{
 	},
 }
 
+// readRemoteNodeAuth collects the remote node flags, reading in any certificate files, so
+// that the stack keeps its own copy of them
+func readRemoteNodeAuth() error {
+	auth := remoteNodeAuth
+	if len(remoteNodeHeaders) > 0 {
+		auth.Headers = make(map[string]string, len(remoteNodeHeaders))
+		for _, header := range remoteNodeHeaders {
+			name, value, ok := strings.Cut(header, "=")
+			if !ok {
+				return fmt.Errorf("invalid remote node header '%s': expected name=value", header)
+			}
+			auth.Headers[strings.TrimSpace(name)] = value
+		}
+	}
+	for _, f := range []struct {
+		path string
+		dest *string
+	}{
+		{remoteNodeCAFile, &auth.CACert},
+		{remoteNodeCertFile, &auth.ClientCert},
+		{remoteNodeKeyFile, &auth.ClientKey},
+	} {
+		if f.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			return err
+		}
+		*f.dest = string(data)
+	}
+	if auth.IsEmpty() {
+		return nil
+	}
+	if err := auth.Validate(initOptions.RemoteNodeURL); err != nil {
+		return err
+	}
+	initOptions.RemoteNodeAuth = &auth
+	return nil
+}
+
 func initCommon(args []string) error {
 	if err := validateDatabaseProvider(initOptions.DatabaseProvider); err != nil {
 		return err
 	}
+	if err := initOptions.RetryPolicy.Validate(); err != nil {
+		return err
+	}
+	hooks, err := types.ParseHooks(initHooks)
+	if err != nil {
+		return err
+	}
+	if initOptions.Hooks == nil {
+		initOptions.Hooks = map[string]string{}
+	}
+	for name, command := range hooks {
+		initOptions.Hooks[name] = command
+	}
+	if err := readRemoteNodeAuth(); err != nil {
+		return err
+	}
 	if err := validateBlockchainProvider(initOptions.BlockchainProvider, initOptions.BlockchainNodeProvider); err != nil {
 		return err
 	}
@@ -82,6 +150,9 @@ func initCommon(args []string) error {
 	if err := validateIPFSMode(initOptions.IPFSMode); err != nil {
 		return err
 	}
+	if err := validateResourceProfile(initOptions.ResourceProfile); err != nil {
+		return err
+	}
 	if err := validateConsensus(initOptions.Consensus); err != nil {
 		return err
 	}
@@ -152,6 +223,53 @@ func initCommon(args []string) error {
 	return nil
 }
 
+// applyStackDefinition applies the definition file set with --from, if there is one. Flags
+// that were set on the command line take precedence over the file.
+func applyStackDefinition(cmd *cobra.Command, args []string) ([]string, error) {
+	if initFrom == "" {
+		return args, nil
+	}
+	definition, err := stacks.ReadStackDefinition(initFrom)
+	if err != nil {
+		return nil, err
+	}
+	if err := definition.ApplyToInitOptions(&initOptions, definitionFlagChanged(cmd)); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 && initOptions.StackName != "" {
+		args = []string{initOptions.StackName}
+	}
+	switch initOptions.BlockchainProvider {
+	case types.BlockchainProviderFabric.String():
+		if err := validateFabricFlags(); err != nil {
+			return nil, err
+		}
+	case types.BlockchainProviderTezos.String():
+		if err := validateTezosFlags(); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// definitionFlagChanged returns whether a flag was set on the command line. init fabric has its
+// own --channel flag for the Fabric channel, which hides the --channel flag of the release channel.
+func definitionFlagChanged(cmd *cobra.Command) func(string) bool {
+	return func(name string) bool {
+		switch name {
+		case types.ReleaseChannelFlag, types.FabricChannelFlag:
+			flag := cmd.Flags().Lookup("channel")
+			if flag == nil || !flag.Changed {
+				return false
+			}
+			isFabricChannel := cmd.LocalNonPersistentFlags().Lookup("channel") != nil
+			return isFabricChannel == (name == types.FabricChannelFlag)
+		default:
+			return cmd.Flags().Changed(name)
+		}
+	}
+}
+
 func validateStackName(stackName string) error {
 	if strings.TrimSpace(stackName) == "" {
 		return errors.New("stack name must not be empty")
@@ -295,6 +413,11 @@ func validateIPFSMode(input string) error {
 	return err
 }
 
+func validateResourceProfile(input string) error {
+	_, err := fftypes.FFEnumParseString(context.Background(), types.ResourceProfile, input)
+	return err
+}
+
 func randomHexString(length int) (string, error) {
 	bytes := make([]byte, length)
 	if _, err := rand.Read(bytes); err != nil {
@@ -307,6 +430,7 @@ func init() {
 	initCmd.PersistentFlags().IntVarP(&initOptions.FireFlyBasePort, "firefly-base-port", "p", 5000, "Mapped port base of FireFly core API (1 added for each member)")
 	initCmd.PersistentFlags().IntVarP(&initOptions.ServicesBasePort, "services-base-port", "s", 5100, "Mapped port base of services (100 added for each member)")
 	initCmd.PersistentFlags().IntVar(&initOptions.PtmBasePort, "ptm-base-port", 4100, "Mapped port base of private transaction manager (10 added for each member)")
+	initCmd.PersistentFlags().BoolVar(&initOptions.AutoPorts, "auto-ports", false, "Move the base ports up to the first block of ports not used by another stack or process")
 	initCmd.PersistentFlags().StringVarP(&initOptions.DatabaseProvider, "database", "d", "sqlite3", fmt.Sprintf("Database type to use. Options are: %v", fftypes.FFEnumValues(types.DatabaseSelection)))
 	initCmd.Flags().StringVarP(&initOptions.BlockchainConnector, "blockchain-connector", "c", "evmconnect", fmt.Sprintf("Blockchain connector to use. Options are: %v", fftypes.FFEnumValues(types.BlockchainConnector)))
 	initCmd.Flags().StringVarP(&initOptions.BlockchainProvider, "blockchain-provider", "b", "ethereum", fmt.Sprintf("Blockchain to use. Options are: %v", fftypes.FFEnumValues(types.BlockchainProvider)))
@@ -317,6 +441,9 @@ func init() {
 	initCmd.PersistentFlags().IntVarP(&initOptions.ExternalProcesses, "external", "e", 0, "Manage a number of FireFly core processes outside of the docker-compose stack - useful for development and debugging")
 	initCmd.PersistentFlags().StringVarP(&initOptions.FireFlyVersion, "release", "r", "latest", fmt.Sprintf("Select the FireFly release version to use. Options are: %v", fftypes.FFEnumValues(types.ReleaseChannelSelection)))
 	initCmd.PersistentFlags().StringVarP(&initOptions.ManifestPath, "manifest", "m", "", "Path to a manifest.json file containing the versions of each FireFly microservice to use. Overrides the --release flag.")
+	initCmd.PersistentFlags().BoolVar(&initOptions.Offline, "offline", false, "Never contact GitHub or an image registry. The manifest must be set with --manifest or already be cached, and every image must have been loaded with the images load command")
+	initCmd.PersistentFlags().DurationVar(&initOptions.ManifestCacheTTL, "manifest-cache-ttl", core.DefaultManifestCacheTTL, "How long a cached manifest for a release or channel is used before it is fetched again")
+	initCmd.PersistentFlags().StringVar(&initFrom, "from", "", "Path to a stack definition file (YAML or JSON) to create the stack from. Values in the file override the flag defaults")
 	initCmd.PersistentFlags().BoolVar(&promptNames, "prompt-names", false, "Prompt for org and node names instead of using the defaults")
 	initCmd.PersistentFlags().BoolVar(&initOptions.PrometheusEnabled, "prometheus-enabled", false, "Enables Prometheus metrics exposition and aggregation to a shared Prometheus server")
 	initCmd.PersistentFlags().BoolVar(&initOptions.SandboxEnabled, "sandbox-enabled", true, "Enables the FireFly Sandbox to be started with your FireFly stack")
@@ -328,12 +455,25 @@ func init() {
 	initCmd.Flags().StringVar(&initOptions.RemoteNodeURL, "remote-node-url", "", "For cases where the node is pre-existing and running remotely")
 	initCmd.Flags().Int64Var(&initOptions.ChainID, "chain-id", 2021, "The chain ID (Ethereum only) - also used as the network ID")
 	initCmd.PersistentFlags().IntVar(&initOptions.RequestTimeout, "request-timeout", 0, "Custom request timeout (in seconds) - useful for registration to public chains")
+	initOptions.RetryPolicy = &types.RetryPolicy{}
+	initCmd.PersistentFlags().IntVar(&initOptions.RetryPolicy.Retries, "request-retries", core.DefaultRetryPolicy.Retries, "How many times a failed request to a service of the stack is retried while it starts")
+	initCmd.PersistentFlags().StringVar(&initOptions.RetryPolicy.InitialDelay, "request-retry-delay", core.DefaultRetryPolicy.InitialDelay, "How long to wait before retrying a failed request")
+	initCmd.PersistentFlags().StringVar(&initOptions.RetryPolicy.MaxDelay, "request-retry-max-delay", "", "If set, the delay between retries doubles after each one, up to this duration")
 	initCmd.PersistentFlags().StringVar(&initOptions.ReleaseChannel, "channel", "stable", fmt.Sprintf("Select the FireFly release channel to use. Options are: %v", fftypes.FFEnumValues(types.ReleaseChannelSelection)))
 	initCmd.PersistentFlags().BoolVar(&initOptions.MultipartyEnabled, "multiparty", true, "Enable or disable multiparty mode")
+	initCmd.PersistentFlags().StringVar(&initOptions.ResourceProfile, "resources", types.ResourceProfileDefault.String(), fmt.Sprintf("The CPU and memory limits to set on the containers of the stack. The default profile sets no limits. Options are: %v", fftypes.FFEnumValues(types.ResourceProfile)))
 	initCmd.PersistentFlags().StringVar(&initOptions.IPFSMode, "ipfs-mode", "private", fmt.Sprintf("Set the mode in which IFPS operates. Options are: %v", fftypes.FFEnumValues(types.IPFSMode)))
 	initCmd.PersistentFlags().StringArrayVar(&initOptions.OrgNames, "org-name", []string{}, "Organization name")
 	initCmd.PersistentFlags().StringArrayVar(&initOptions.NodeNames, "node-name", []string{}, "Node name")
 	initCmd.PersistentFlags().BoolVar(&initOptions.RemoteNodeDeploy, "remote-node-deploy", false, "Enable or disable deployment of FireFly contracts on remote nodes")
+	initCmd.PersistentFlags().StringVar(&remoteNodeAuth.Username, "remote-node-username", "", "Username for basic auth to the remote node")
+	initCmd.PersistentFlags().StringVar(&remoteNodeAuth.Password, "remote-node-password", "", "Password for basic auth to the remote node")
+	initCmd.PersistentFlags().StringVar(&remoteNodeAuth.BearerToken, "remote-node-token", "", "Bearer token sent to the remote node in the Authorization header")
+	initCmd.PersistentFlags().StringArrayVar(&remoteNodeHeaders, "remote-node-header", []string{}, "A header to send to the remote node, in the form name=value. Can be set more than once")
+	initCmd.PersistentFlags().StringVar(&remoteNodeCAFile, "remote-node-ca-file", "", "Path to a PEM file with the CA certificates used to verify the remote node")
+	initCmd.PersistentFlags().StringVar(&remoteNodeCertFile, "remote-node-cert-file", "", "Path to a PEM client certificate to present to the remote node")
+	initCmd.PersistentFlags().StringVar(&remoteNodeKeyFile, "remote-node-key-file", "", "Path to the PEM private key for --remote-node-cert-file")
 	initCmd.PersistentFlags().StringToStringVar(&initOptions.EnvironmentVars, "environment-vars", map[string]string{}, "Common environment variables to set on all containers in FireFly stack")
+	initCmd.PersistentFlags().StringArrayVar(&initHooks, "hook", []string{}, fmt.Sprintf("A command to run at a point in the life of the stack, in the form name=command. Can be set more than once. A failing pre-stop or pre-remove hook does not stop the stack being stopped or removed, and a failing post-first-time-setup hook does not roll back the setup. Options are: %s", strings.Join(types.HookNames, ", ")))
 	rootCmd.AddCommand(initCmd)
 }
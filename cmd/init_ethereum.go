@@ -36,7 +36,7 @@ var initEthereumCmd = &cobra.Command{
 	Long:  `Create a new FireFly local dev stack using an Ethereum blockchain`,
 	Args:  cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 		version, err := docker.CheckDockerConfig()
 		if err != nil {
@@ -47,6 +47,10 @@ var initEthereumCmd = &cobra.Command{
 		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
 
 		stackManager := stacks.NewStackManager(ctx)
+		args, err = applyStackDefinition(cmd, args)
+		if err != nil {
+			return err
+		}
 		if err := initCommon(args); err != nil {
 			return err
 		}
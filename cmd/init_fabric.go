@@ -36,7 +36,7 @@ var initFabricCmd = &cobra.Command{
 	Long:  `Create a new FireFly local dev stack using a Fabric network`,
 	Args:  cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 
 		version, err := docker.CheckDockerConfig()
@@ -46,6 +46,10 @@ var initFabricCmd = &cobra.Command{
 		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
 
 		stackManager := stacks.NewStackManager(ctx)
+		args, err = applyStackDefinition(cmd, args)
+		if err != nil {
+			return err
+		}
 		initOptions.BlockchainProvider = types.BlockchainProviderFabric.String()
 		initOptions.TokenProviders = []string{}
 		if err := validateFabricFlags(); err != nil {
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefinitionFlagChangedSeparatesChannels(t *testing.T) {
+	previousOptions := initOptions
+	t.Cleanup(func() {
+		initOptions = previousOptions
+		initFabricCmd.Flags().Lookup("channel").Changed = false
+		initCmd.PersistentFlags().Lookup("channel").Changed = false
+	})
+
+	assert.NoError(t, initFabricCmd.ParseFlags([]string{"--channel", "mychannel"}))
+	changed := definitionFlagChanged(initFabricCmd)
+	assert.True(t, changed(types.FabricChannelFlag))
+	assert.False(t, changed(types.ReleaseChannelFlag))
+
+	assert.NoError(t, initEthereumCmd.ParseFlags([]string{"--channel", "stable"}))
+	changed = definitionFlagChanged(initEthereumCmd)
+	assert.True(t, changed(types.ReleaseChannelFlag))
+	assert.False(t, changed(types.FabricChannelFlag))
+}
@@ -17,7 +17,6 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"path/filepath"
 
@@ -34,9 +33,13 @@ var initTezosCmd = &cobra.Command{
 	Long:  `Create a new FireFly local dev stack using an Tezos blockchain`,
 	Args:  cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 		stackManager := stacks.NewStackManager(ctx)
+		args, err := applyStackDefinition(cmd, args)
+		if err != nil {
+			return err
+		}
 		initOptions.BlockchainProvider = types.BlockchainProviderTezos.String()
 		initOptions.BlockchainConnector = types.BlockchainConnectorTezosconnect.String()
 		initOptions.BlockchainNodeProvider = types.BlockchainNodeProviderRemoteRPC.String()
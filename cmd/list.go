@@ -21,7 +21,9 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/hyperledger/firefly-cli/internal/log"
 	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/hyperledger/firefly-cli/pkg/types"
 )
 
 var listCommand = &cobra.Command{
@@ -31,15 +33,36 @@ var listCommand = &cobra.Command{
 	Long:    `List stacks`,
 	Args:    cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if stacks, err := stacks.ListStacks(); err != nil {
+		if err := validateOutputFormat(); err != nil {
 			return err
-		} else {
-			fmt.Print("FireFly Stacks:\n\n")
-			for _, s := range stacks {
-				fmt.Println(s)
+		}
+		stackNames, err := stacks.ListStacks()
+		if err != nil {
+			return err
+		}
+		if outputFormat != outputTable {
+			ctx := log.WithVerbosity(cmd.Context(), verbose)
+			ctx = log.WithLogger(ctx, logger)
+			stackInfo := make([]*types.StackInfo, 0, len(stackNames))
+			for _, stackName := range stackNames {
+				stackManager := stacks.NewStackManager(ctx)
+				if err := stackManager.LoadStack(stackName); err != nil {
+					return err
+				}
+				info, err := stackManager.GetStackInfo(false)
+				if err != nil {
+					return err
+				}
+				stackInfo = append(stackInfo, info)
 			}
-			fmt.Print("\n")
+			return printStructuredOutput(outputFormat, stackInfo)
+		}
+
+		fmt.Print("FireFly Stacks:\n\n")
+		for _, s := range stackNames {
+			fmt.Println(s)
 		}
+		fmt.Print("\n")
 		return nil
 	},
 }
@@ -19,6 +19,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/hyperledger/firefly-cli/internal/docker"
 	"github.com/hyperledger/firefly-cli/internal/log"
@@ -27,18 +28,30 @@ import (
 )
 
 var follow bool
+var logsOptions stacks.LogsOptions
 
 // logsCmd represents the logs command
 var logsCmd = &cobra.Command{
-	Use:               "logs <stack_name>",
+	Use:               "logs <stack_name> [service...]",
 	Short:             "View log output from a stack",
 	ValidArgsFunction: listStacks,
 	Long: `View log output from a stack.
 
 The most recent logs can be viewed, or you can follow the
-output with the -f flag.`,
+output with the -f flag.
+
+Services can be selected by their full name, such as firefly_core_0,
+or by their name without the member suffix, such as evmconnect for the
+evmconnect of every member. "core" is short for firefly_core, and
+"connector" selects the blockchain connector of the stack. Use --member
+to only see the services of one member.
+
+The --level and --grep filters are applied to each line after it is read.
+When they are set, --tail shows the last matching lines of each service,
+which means the whole of the logs are searched, and it cannot be used
+with --follow.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = context.WithValue(ctx, docker.CtxIsLogCmdKey{}, true)
 		ctx = log.WithLogger(ctx, logger)
 
@@ -65,15 +78,10 @@ output with the -f flag.`,
 
 		if stackHasRunBefore {
 			fmt.Println("getting logs... ")
-			commandLine := []string{}
-			if fancyFeatures {
-				commandLine = append(commandLine, "--ansi", "always")
-			}
-			commandLine = append(commandLine, "-p", stackName, "logs")
-			if follow {
-				commandLine = append(commandLine, "-f")
-			}
-			if err := docker.RunDockerComposeCommand(ctx, stackManager.Stack.RuntimeDir, commandLine...); err != nil {
+			logsOptions.Services = args[1:]
+			logsOptions.Follow = follow
+			logsOptions.Color = fancyFeatures
+			if err := stackManager.PrintLogs(&logsOptions, os.Stdout); err != nil {
 				return err
 			}
 		} else {
@@ -86,4 +94,9 @@ output with the -f flag.`,
 func init() {
 	rootCmd.AddCommand(logsCmd)
 	logsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "follow log output")
+	logsCmd.Flags().StringVar(&logsOptions.Member, "member", "", "Only show the logs of the services of this member, selected by index, org name or node name")
+	logsCmd.Flags().StringVar(&logsOptions.Since, "since", "", "Show logs since a timestamp (e.g. 2024-01-02T13:23:37Z) or relative time (e.g. 42m)")
+	logsCmd.Flags().StringVarP(&logsOptions.Tail, "tail", "n", "", "Number of lines to show from the end of the logs of each service, after the --level and --grep filters")
+	logsCmd.Flags().StringVar(&logsOptions.Grep, "grep", "", "Only show lines that match this regular expression")
+	logsCmd.Flags().StringVar(&logsOptions.Level, "level", "", "Only show lines at this log level or above. Options are: trace, debug, info, warn, error, fatal")
 }
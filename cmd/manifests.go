@@ -0,0 +1,37 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// manifestsCmd represents the manifests command
+var manifestsCmd = &cobra.Command{
+	Use:   "manifests",
+	Short: "Work with the cached release manifests",
+	Long: `Work with the cached release manifests
+
+The manifest for a FireFly release or release channel lists the image of
+every FireFly microservice. Manifests are cached in the FireFly home
+directory when a stack is created or upgraded, and the cached copy is
+used when the network is unavailable.`,
+}
+
+func init() {
+	rootCmd.AddCommand(manifestsCmd)
+}
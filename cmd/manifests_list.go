@@ -0,0 +1,63 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hyperledger/firefly-cli/internal/core"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var manifestsListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List the cached release manifests",
+	Long:    `List the cached release manifests`,
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		cachedManifests, err := core.ListCachedManifests()
+		if err != nil {
+			return err
+		}
+		infos := make([]*types.ManifestCacheInfo, len(cachedManifests))
+		for i, cached := range cachedManifests {
+			infos[i] = types.NewManifestCacheInfo(cached, core.DefaultManifestCacheTTL)
+		}
+		if outputFormat != outputTable {
+			return printStructuredOutput(outputFormat, infos)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tNAME\tFIREFLY\tFETCHED\tEXPIRED")
+		for _, info := range infos {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", info.Kind, info.Name, info.FireFlyVersion, info.Fetched.Local().Format(time.RFC3339), info.Expired)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	manifestsCmd.AddCommand(manifestsListCmd)
+}
@@ -0,0 +1,74 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/firefly-cli/internal/core"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var manifestsRefreshCmd = &cobra.Command{
+	Use:   "refresh [release_or_channel...]",
+	Short: "Fetch release manifests again and update the cache",
+	Long: `Fetch release manifests again and update the cache
+
+Each argument is either a release version, such as v1.3.0, or the name of a
+release channel, such as stable. With no arguments, every manifest that is
+already cached is refreshed, or the stable channel if the cache is empty.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		type manifestKey struct {
+			kind string
+			name string
+		}
+		keys := []manifestKey{}
+		for _, name := range args {
+			keys = append(keys, manifestKey{kind: core.ManifestKindForName(name), name: name})
+		}
+		if len(keys) == 0 {
+			cachedManifests, err := core.ListCachedManifests()
+			if err != nil {
+				return err
+			}
+			for _, cached := range cachedManifests {
+				keys = append(keys, manifestKey{kind: cached.Kind, name: cached.Name})
+			}
+			if len(keys) == 0 {
+				keys = append(keys, manifestKey{kind: core.ManifestKindChannel, name: types.ReleaseChannelStable.String()})
+			}
+		}
+
+		for _, key := range keys {
+			cached, err := core.RefreshCachedManifest(cmd.Context(), key.kind, key.name)
+			if err != nil {
+				return fmt.Errorf("failed to refresh the manifest for %s '%s': %s", key.kind, key.name, err)
+			}
+			version := ""
+			if cached.Manifest.FireFly != nil {
+				version = cached.Manifest.FireFly.Tag
+			}
+			fmt.Printf("refreshed manifest for %s '%s' (FireFly %s)\n", key.kind, key.name, version)
+		}
+		return nil
+	},
+}
+
+func init() {
+	manifestsCmd.AddCommand(manifestsRefreshCmd)
+}
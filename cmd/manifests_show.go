@@ -0,0 +1,92 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/hyperledger/firefly-cli/internal/core"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var manifestsShowCmd = &cobra.Command{
+	Use:   "show <release_or_channel>",
+	Short: "Show a cached release manifest",
+	Long: `Show a cached release manifest
+
+The argument is either a release version, such as v1.3.0, or the name of a
+release channel, such as stable.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		name := args[0]
+		kind := core.ManifestKindForName(name)
+		cached, err := core.ReadCachedManifest(kind, name)
+		if err != nil {
+			return err
+		}
+		if cached == nil {
+			return fmt.Errorf("there is no cached manifest for %s '%s'", kind, name)
+		}
+		format := outputFormat
+		if !cmd.Flags().Changed("output") {
+			// The manifest is shown in the same format as the manifest.json it came from
+			format = outputJSON
+		}
+		if format != outputTable {
+			return printStructuredOutput(format, cached.Manifest)
+		}
+		return printManifestTable(cached.Manifest)
+	},
+}
+
+func printManifestTable(manifest *types.VersionManifest) error {
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	var entries map[string]*types.ManifestEntry
+	if err := json.Unmarshal(manifestBytes, &entries); err != nil {
+		return err
+	}
+	components := make([]string, 0, len(entries))
+	for component, entry := range entries {
+		if entry != nil {
+			components = append(components, component)
+		}
+	}
+	sort.Strings(components)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tIMAGE\tTAG\tSHA")
+	for _, component := range components {
+		entry := entries[component]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", component, entry.Image, entry.Tag, entry.SHA)
+	}
+	return w.Flush()
+}
+
+func init() {
+	manifestsCmd.AddCommand(manifestsShowCmd)
+}
@@ -0,0 +1,106 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+var memberOrgName string
+var memberNodeName string
+
+// membersAddCmd represents the "members add" command
+var membersAddCmd = &cobra.Command{
+	Use:   "add <stack_name>",
+	Short: "Add a new member to a running FireFly stack",
+	Long: `Add a new member to a running FireFly stack
+
+The new member gets its own FireFly core, data exchange, IPFS node, database,
+blockchain connector and token connectors, a new blockchain account, and its
+org and node identities are registered with the network. The stack must have
+been started before members can be added.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: listStacks,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var spin *spinner.Spinner
+		if fancyFeatures && !verbose {
+			spin = spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+			logger = log.NewSpinnerLogger(spin)
+		}
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackName := args[0]
+		stackManager := stacks.NewStackManager(ctx)
+		if err := stackManager.LoadStack(stackName); err != nil {
+			return err
+		}
+
+		randomName, err := randomHexString(3)
+		if err != nil {
+			return err
+		}
+		orgName := memberOrgName
+		if orgName == "" {
+			orgName = fmt.Sprintf("org_%s", randomName)
+		} else if err := validateFFName(orgName); err != nil {
+			return err
+		}
+		nodeName := memberNodeName
+		if nodeName == "" {
+			nodeName = fmt.Sprintf("node_%s", randomName)
+		} else if err := validateFFName(nodeName); err != nil {
+			return err
+		}
+
+		fmt.Printf("adding member to FireFly stack '%s'... ", stackName)
+		if spin != nil {
+			spin.Start()
+		}
+		member, err := stackManager.AddMember(orgName, nodeName)
+		if spin != nil {
+			spin.Stop()
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n\nMember %s added to stack '%s'\n", member.ID, stackName)
+		fmt.Printf("Org name: %s\nNode name: %s\n", member.OrgName, member.NodeName)
+		fmt.Printf("FireFly API: http://127.0.0.1:%v/api\n\n", member.ExposedFireflyPort)
+		return nil
+	},
+}
+
+func init() {
+	membersAddCmd.Flags().StringVar(&memberOrgName, "org-name", "", "Organization name for the new member")
+	membersAddCmd.Flags().StringVar(&memberNodeName, "node-name", "", "Node name for the new member")
+	membersCmd.AddCommand(membersAddCmd)
+}
@@ -0,0 +1,100 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+var memberDisable bool
+
+// membersRemoveCmd represents the "members remove" command
+var membersRemoveCmd = &cobra.Command{
+	Use:   "remove <stack_name> <member_id>",
+	Short: "Remove a member from a FireFly stack",
+	Long: `Remove a member from a FireFly stack
+
+This command stops and removes the containers that belong to the member,
+deletes its docker volumes, and removes it from the stack config. The other
+members of the stack are left running.
+
+With --disable the member's containers are only stopped, and all of its data
+is kept. A disabled member is started again the next time the stack is started.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: listStacks,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var spin *spinner.Spinner
+		if fancyFeatures && !verbose {
+			spin = spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+			logger = log.NewSpinnerLogger(spin)
+		}
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackName := args[0]
+		memberID := args[1]
+		stackManager := stacks.NewStackManager(ctx)
+		if err := stackManager.LoadStack(stackName); err != nil {
+			return err
+		}
+
+		if memberDisable {
+			fmt.Printf("disabling member %s of FireFly stack '%s'... ", memberID, stackName)
+		} else {
+			fmt.Printf("removing member %s from FireFly stack '%s'... ", memberID, stackName)
+		}
+		if spin != nil {
+			spin.Start()
+		}
+		if memberDisable {
+			err = stackManager.DisableMember(memberID)
+		} else {
+			err = stackManager.RemoveMember(memberID)
+		}
+		if spin != nil {
+			spin.Stop()
+		}
+		if err != nil {
+			return err
+		}
+		if memberDisable {
+			fmt.Printf("done\n\nMember %s of stack '%s' is now offline. To bring it back online run:\n\n%s start %s\n\n", memberID, stackName, rootCmd.Use, stackName)
+		} else {
+			fmt.Printf("done\n\nMember %s removed from stack '%s'\n", memberID, stackName)
+		}
+		return nil
+	},
+}
+
+func init() {
+	membersRemoveCmd.Flags().BoolVar(&memberDisable, "disable", false, "Only stop the member's containers, keeping its data and config")
+	membersCmd.AddCommand(membersRemoveCmd)
+}
@@ -21,8 +21,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hyperledger/firefly-cli/internal/docker"
 	"github.com/hyperledger/firefly-cli/internal/log"
 	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/hyperledger/firefly-cli/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -35,10 +37,19 @@ var psCmd = &cobra.Command{
 	It also takes a continuous list of whitespace optional argument - stack name.`,
 	Aliases: []string{"process"},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
 
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 
+		version, err := checkDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
 		allStacks, err := stacks.ListStacks()
 		if err != nil {
 			return err
@@ -58,14 +69,26 @@ var psCmd = &cobra.Command{
 		}
 
 		stackManager := stacks.NewStackManager(ctx)
+		stackInfo := make([]*types.StackInfo, 0, len(allStacks))
 		for _, stackName := range allStacks {
 			if err := stackManager.LoadStack(stackName); err != nil {
 				return err
 			}
 
-			if err := stackManager.IsRunning(); err != nil {
+			if outputFormat == outputTable {
+				if err := stackManager.IsRunning(); err != nil {
+					return err
+				}
+				continue
+			}
+			info, err := stackManager.GetStackInfo(true)
+			if err != nil {
 				return err
 			}
+			stackInfo = append(stackInfo, info)
+		}
+		if outputFormat != outputTable {
+			return printStructuredOutput(outputFormat, stackInfo)
 		}
 		return nil
 	},
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/internal/constants"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/docker/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPsDetectsComposeVersion(t *testing.T) {
+	stacksDir := constants.StacksDir
+	constants.StacksDir = t.TempDir()
+	t.Cleanup(func() { constants.StacksDir = stacksDir })
+	t.Cleanup(func() { checkDockerConfig = docker.CheckDockerConfig })
+
+	stackDir := filepath.Join(constants.StacksDir, "ps-stack")
+	assert.NoError(t, os.MkdirAll(filepath.Join(stackDir, "init"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(stackDir, "stack.json"), []byte(`{"name":"ps-stack","database":"sqlite3","blockchainProvider":"ethereum","blockchainConnector":"evmconnect","blockchainNodeProvider":"geth"}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(stackDir, "docker-compose.yml"), []byte("services: {}\n"), 0644))
+
+	// The context the command runs with has no compose version, as is the case
+	// when it is run from the command line
+	ctx := docker.WithDockerManager(context.Background(), mocks.NewFakeDockerManager())
+
+	checkDockerConfig = func() (docker.DockerComposeVersion, error) {
+		return docker.None, nil
+	}
+	rootCmd.SetArgs([]string{"ps", "ps-stack"})
+	assert.Regexp(t, "no version for docker-compose has been detected", rootCmd.ExecuteContext(ctx))
+
+	checkDockerConfig = func() (docker.DockerComposeVersion, error) {
+		return docker.ComposeV2, nil
+	}
+	rootCmd.SetArgs([]string{"ps", "ps-stack"})
+	assert.NoError(t, rootCmd.ExecuteContext(ctx))
+}
@@ -37,7 +37,8 @@ var pullCmd = &cobra.Command{
 	ValidArgsFunction: listStacks,
 	Long: `Pull a stack
 
-Pull the images for a stack .
+Pull the images for a stack. Images are pulled in parallel, and any image
+that is already present with the same digest as the registry is skipped.
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var spin *spinner.Spinner
@@ -45,7 +46,7 @@ Pull the images for a stack .
 			spin = spinner.New(spinner.CharSets[11], 100*time.Millisecond)
 			logger = log.NewSpinnerLogger(spin)
 		}
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 
 		version, err := docker.CheckDockerConfig()
@@ -78,6 +79,7 @@ Pull the images for a stack .
 
 func init() {
 	pullCmd.Flags().IntVarP(&pullOptions.Retries, "retries", "r", 0, "Retry attempts to perform on image pull failure")
+	pullCmd.Flags().IntVar(&pullOptions.Concurrency, "concurrency", 4, "Maximum number of images to pull at the same time")
 
 	rootCmd.AddCommand(pullCmd)
 }
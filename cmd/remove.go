@@ -39,7 +39,7 @@ var removeCmd = &cobra.Command{
 This command will completely delete a stack, including all of its data
 and configuration.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 
 		version, err := docker.CheckDockerConfig()
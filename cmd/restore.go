@@ -0,0 +1,85 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <filename> [stack_name]",
+	Short: "Create a stack from a snapshot",
+	Long: `Create a stack from a snapshot
+
+This command recreates a stack, including all of its data, from a file
+written by the snapshot command. By default the stack is given the name it
+had when the snapshot was taken. The restored stack uses the same ports as
+the original, so both cannot be running at the same time.
+`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var spin *spinner.Spinner
+		if fancyFeatures && !verbose {
+			spin = spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+			logger = log.NewSpinnerLogger(spin)
+		}
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackManager := stacks.NewStackManager(ctx)
+		filename := args[0]
+		stackName := ""
+		if len(args) > 1 {
+			stackName = args[1]
+			if err := validateStackName(stackName); err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("restoring FireFly stack from '%s'... ", filename)
+		if spin != nil {
+			spin.Start()
+		}
+		err = stackManager.RestoreStack(filename, stackName)
+		if spin != nil {
+			spin.Stop()
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n\nStack '%s' restored!\nTo start your stack run:\n\n%s start %s\n\n", stackManager.Stack.Name, rootCmd.Use, stackManager.Stack.Name)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+}
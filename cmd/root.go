@@ -17,9 +17,12 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
@@ -27,6 +30,7 @@ import (
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 
+	"github.com/hyperledger/firefly-cli/internal/docker"
 	"github.com/hyperledger/firefly-cli/internal/log"
 )
 
@@ -39,6 +43,10 @@ var logger log.Logger = &log.StdoutLogger{
 	LogLevel: log.Debug,
 }
 
+// checkDockerConfig detects the docker compose version for the commands that
+// only read the state of a stack, and is replaced in tests
+var checkDockerConfig = docker.CheckDockerConfig
+
 // name of the executable, this is for the help messages
 var ExecutableName string = os.Args[0]
 
@@ -67,6 +75,7 @@ commands to manage the lifecycle of stacks.
 
 To get started run: ` + ExecutableName + ` init
 Optional: Set FIREFLY_HOME env variable for FireFly stack configuration path.
+Optional: Set FIREFLY_CONTAINER_ENGINE env variable to podman or nerdctl to use them instead of docker.
 	`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		if ansi == "always" {
@@ -87,7 +96,16 @@ Optional: Set FIREFLY_HOME env variable for FireFly stack configuration path.
 func Execute() {
 	rootCmd.PersistentFlags().StringVarP(&ansi, "ansi", "", "auto", "control when to print ANSI control characters (\"never\"|\"always\"|\"auto\")")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose log output")
-	cobra.CheckErr(rootCmd.Execute())
+
+	// The first interrupt cancels the context of the command, so that it can stop what it is
+	// doing and clean up. A second one exits straight away.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	cobra.CheckErr(rootCmd.ExecuteContext(ctx))
 }
 
 func init() {
@@ -124,4 +142,10 @@ func initConfig() {
 		}
 	}
 
+	// The container engine can be set in the config file, or overridden for a single shell
+	engine := os.Getenv(docker.EngineEnvVar)
+	if engine == "" {
+		engine = viper.GetString("containerEngine")
+	}
+	cobra.CheckErr(docker.SetEngine(engine))
 }
@@ -0,0 +1,90 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:               "snapshot <stack_name> [filename]",
+	Short:             "Save a stack and all of its data to a file",
+	ValidArgsFunction: listStacks,
+	Long: `Save a stack and all of its data to a file
+
+This command writes the stack configuration and the contents of every
+docker volume the stack owns to a single archive. The archive can be
+used to recreate the stack with the restore command.
+Note: this will also stop the stack if it is running.
+`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var spin *spinner.Spinner
+		if fancyFeatures && !verbose {
+			spin = spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+			logger = log.NewSpinnerLogger(spin)
+		}
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackManager := stacks.NewStackManager(ctx)
+		stackName := args[0]
+		filename := fmt.Sprintf("%s.snapshot.tar.gz", stackName)
+		if len(args) > 1 {
+			filename = args[1]
+		}
+
+		if err := stackManager.LoadStack(stackName); err != nil {
+			return err
+		}
+
+		fmt.Printf("saving snapshot of FireFly stack '%s'... ", stackName)
+		if spin != nil {
+			spin.Start()
+		}
+		err = stackManager.StopStack()
+		if err == nil {
+			err = stackManager.SnapshotStack(filename)
+		}
+		if spin != nil {
+			spin.Stop()
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n\nSnapshot written to '%s'. To create a stack from it run:\n\n%s restore %s\n\n", filename, rootCmd.Use, filename)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+}
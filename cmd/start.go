@@ -46,7 +46,7 @@ This command will start a stack and run it in the background.
 			spin = spinner.New(spinner.CharSets[11], 100*time.Millisecond)
 			logger = log.NewSpinnerLogger(spin)
 		}
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 
 		version, err := docker.CheckDockerConfig()
@@ -0,0 +1,128 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status <stack_name>",
+	Short: "Check the health of every service in a stack",
+	Long: `Check the health of every service in a stack
+
+For each member this shows the state and healthcheck result of each of its
+containers, followed by the containers that are shared by all members, such
+as the blockchain node. The FireFly API of each member is also queried to
+check that its org and node are registered.
+
+The command exits with a non-zero status if anything is unhealthy.`,
+	ValidArgsFunction: listStacks,
+	Args:              cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
+		ctx = log.WithLogger(ctx, logger)
+
+		version, err := docker.CheckDockerConfig()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, version)
+
+		stackName := args[0]
+		stackManager := stacks.NewStackManager(ctx)
+		if err := stackManager.LoadStack(stackName); err != nil {
+			return err
+		}
+		health, err := stackManager.CheckHealth()
+		if err != nil {
+			return err
+		}
+
+		if outputFormat != outputTable {
+			err = printStructuredOutput(outputFormat, health)
+		} else {
+			err = printHealthTable(health)
+		}
+		if err != nil {
+			return err
+		}
+		if !health.Healthy {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("stack '%s' is not healthy", stackName)
+		}
+		return nil
+	},
+}
+
+func printHealthTable(health *types.StackHealth) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "MEMBER\tSERVICE\tSTATE\tHEALTH")
+	for _, member := range health.Members {
+		for _, service := range member.Services {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", member.ID, service.Name, service.State, valueOrDash(service.Health))
+		}
+	}
+	for _, service := range health.Services {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "-", service.Name, service.State, valueOrDash(service.Health))
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "MEMBER\tORG\tORG REGISTERED\tNODE\tNODE REGISTERED\tAPI")
+	for _, member := range health.Members {
+		api := "ok"
+		if member.APIError != "" {
+			api = member.APIError
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", member.ID, member.OrgName, formatRegistered(member.OrgRegistered), member.NodeName, formatRegistered(member.NodeRegistered), api)
+	}
+	return w.Flush()
+}
+
+func formatRegistered(registered *bool) string {
+	switch {
+	case registered == nil:
+		return "-"
+	case *registered:
+		return "yes"
+	default:
+		return "no"
+	}
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
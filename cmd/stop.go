@@ -33,7 +33,7 @@ var stopCmd = &cobra.Command{
 	Long:              `Stop a stack`,
 	ValidArgsFunction: listStacks,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 
 		version, err := docker.CheckDockerConfig()
@@ -19,22 +19,28 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/hyperledger/firefly-cli/internal/core"
 	"github.com/hyperledger/firefly-cli/internal/docker"
 	"github.com/hyperledger/firefly-cli/internal/log"
 	"github.com/hyperledger/firefly-cli/internal/stacks"
+	"github.com/hyperledger/firefly-cli/pkg/types"
 	"github.com/spf13/cobra"
 )
 
-var forceUpgrade bool
+var upgradeOptions types.UpgradeOptions
 
 var upgradeCmd = &cobra.Command{
 	Use:   "upgrade <stack_name> <version>",
 	Short: "Upgrade a stack to different version",
 	Long: `Upgrade a stack by pulling updated images.
 	This operation will stop the stack if running.
+	The docker compose file is regenerated for the new version, and the
+	previous stack.json and docker compose file are restored if the new
+	images cannot be pulled, or if the stack fails to start with --start.
 	If certain containers were pinned to a specific image at init,
 	this command will have no effect on those containers.`,
 	Args:              cobra.ExactArgs(2),
@@ -45,7 +51,7 @@ var upgradeCmd = &cobra.Command{
 			spin = spinner.New(spinner.CharSets[11], 100*time.Millisecond)
 			logger = log.NewSpinnerLogger(spin)
 		}
-		ctx := log.WithVerbosity(context.Background(), verbose)
+		ctx := log.WithVerbosity(cmd.Context(), verbose)
 		ctx = log.WithLogger(ctx, logger)
 
 		dockerVersion, err := docker.CheckDockerConfig()
@@ -62,21 +68,69 @@ var upgradeCmd = &cobra.Command{
 		if len(args) <= 1 {
 			return fmt.Errorf("no version specified")
 		}
-		version := args[1]
+		upgradeOptions.Version = args[1]
 
 		if err := stackManager.LoadStack(stackName); err != nil {
 			return err
 		}
+		if upgradeOptions.DryRun {
+			plan, err := stackManager.UpgradeStack(&upgradeOptions)
+			if err != nil {
+				return err
+			}
+			printUpgradePlan(stackName, plan)
+			return nil
+		}
 		fmt.Printf("upgrading stack '%s'... ", stackName)
-		if err := stackManager.UpgradeStack(version, forceUpgrade); err != nil {
+		if spin != nil {
+			spin.Start()
+		}
+		_, err = stackManager.UpgradeStack(&upgradeOptions)
+		if spin != nil {
+			spin.Stop()
+		}
+		if err != nil {
 			return err
 		}
-		fmt.Printf("\n\nYour stack has been upgraded to %s\n\nTo start your upgraded stack run:\n\n%s start %s\n\n", version, rootCmd.Use, stackName)
+		if upgradeOptions.Start {
+			fmt.Printf("\n\nYour stack has been upgraded to %s and started\n\n", upgradeOptions.Version)
+		} else {
+			fmt.Printf("\n\nYour stack has been upgraded to %s\n\nTo start your upgraded stack run:\n\n%s start %s\n\n", upgradeOptions.Version, rootCmd.Use, stackName)
+		}
 		return nil
 	},
 }
 
+func printUpgradePlan(stackName string, plan *stacks.UpgradePlan) {
+	fmt.Printf("Upgrading stack '%s' from %s to %s ", stackName, plan.FromVersion, plan.ToVersion)
+	if len(plan.Changes) == 0 {
+		fmt.Printf("would not change any services\n")
+		return
+	}
+	fmt.Printf("would make the following changes:\n\n")
+	for _, change := range plan.Changes {
+		switch change.Change {
+		case stacks.ServiceAdded:
+			fmt.Printf("+ %s (%s)\n", change.Service, change.NewImage)
+		case stacks.ServiceRemoved:
+			fmt.Printf("- %s (%s)\n", change.Service, change.OldImage)
+		default:
+			fmt.Printf("~ %s\n", change.Service)
+			if change.NewImage != "" {
+				fmt.Printf("    image: %s -> %s\n", change.OldImage, change.NewImage)
+			}
+			if len(change.Fields) > 0 {
+				fmt.Printf("    config changed: %s\n", strings.Join(change.Fields, ", "))
+			}
+		}
+	}
+	fmt.Println()
+}
+
 func init() {
-	upgradeCmd.Flags().BoolVarP(&forceUpgrade, "force", "f", false, "Force upgrade even between unsupported versions. May result in a broken environment. Use with caution.")
+	upgradeCmd.Flags().BoolVarP(&upgradeOptions.Force, "force", "f", false, "Force upgrade even between unsupported versions. May result in a broken environment. Use with caution.")
+	upgradeCmd.Flags().BoolVar(&upgradeOptions.DryRun, "dry-run", false, "Show the changes the upgrade would make to the images and config of each service, without changing anything")
+	upgradeCmd.Flags().BoolVar(&upgradeOptions.Start, "start", false, "Start the stack after upgrading, and roll back the upgrade if it fails to start")
+	upgradeCmd.Flags().DurationVar(&upgradeOptions.ManifestCacheTTL, "manifest-cache-ttl", core.DefaultManifestCacheTTL, "How long a cached manifest for a release is used before it is fetched again")
 	rootCmd.AddCommand(upgradeCmd)
 }
@@ -28,6 +28,9 @@ type IBlockchainProvider interface {
 	PreStart() error
 	PostStart(firstTimeSetup bool) error
 	GetDockerServiceDefinitions() []*docker.ServiceDefinition
+	// GetSetupImages returns the images the provider runs directly with docker while setting up
+	// the stack, so that they are pulled and bundled along with the images of its services
+	GetSetupImages() []string
 	GetBlockchainPluginConfig(stack *types.Stack, org *types.Organization) (blockchainConfig *types.BlockchainConfig)
 	GetOrgConfig(stack *types.Stack, org *types.Organization) (coreConfig *types.OrgConfig)
 	Reset() error
@@ -38,4 +41,9 @@ type IBlockchainProvider interface {
 	GetConnectorName() string
 	GetConnectorURL(org *types.Organization) string
 	GetConnectorExternalURL(org *types.Organization) string
+	// AddMember creates the account and config for a member that is being added to a stack that has
+	// already been started. It is called before any of the new member's containers are started.
+	AddMember(member *types.Organization) (account interface{}, err error)
+	// PostAddMember finishes onboarding a new member once its containers are running
+	PostAddMember(member *types.Organization) error
 }
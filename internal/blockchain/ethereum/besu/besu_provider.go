@@ -60,15 +60,15 @@ func NewBesuProvider(ctx context.Context, stack *types.Stack) *BesuProvider {
 }
 
 func (p *BesuProvider) WriteConfig(options *types.InitOptions) error {
-	if err := p.signer.WriteConfig(options, "http://besu:8545"); err != nil {
+	if err := p.signer.WriteConfig(options, "http://besu:8545", nil); err != nil {
 		return err
 	}
 
 	initDir := filepath.Join(constants.StacksDir, p.stack.Name, "init")
-	for i, member := range p.stack.Members {
+	for _, member := range p.stack.Members {
 
 		// Generate the connector config for each member
-		connectorConfigPath := filepath.Join(initDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), i))
+		connectorConfigPath := filepath.Join(initDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
 		if err := p.connector.GenerateConfig(p.stack, member, "ethsigner").WriteConfig(connectorConfigPath, options.ExtraConnectorConfigPath); err != nil {
 			return nil
 		}
@@ -112,10 +112,10 @@ func (p *BesuProvider) FirstTimeSetup() error {
 		return err
 	}
 
-	for i := range p.stack.Members {
+	for _, member := range p.stack.Members {
 		// Copy connector config to each member's volume
-		connectorConfigPath := filepath.Join(p.stack.StackDir, "runtime", "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), i))
-		connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), i)
+		connectorConfigPath := filepath.Join(p.stack.StackDir, "runtime", "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
+		connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), member.ID)
 		if err := docker.CopyFileToVolume(p.ctx, connectorConfigVolumeName, connectorConfigPath, "config.yaml"); err != nil {
 			return err
 		}
@@ -150,6 +150,10 @@ func (p *BesuProvider) DeployFireFlyContract() (*types.ContractDeploymentResult,
 	return p.connector.DeployContract(contract, "FireFly", p.stack.Members[0], nil)
 }
 
+func (p *BesuProvider) GetSetupImages() []string {
+	return nil
+}
+
 func (p *BesuProvider) GetDockerServiceDefinitions() []*docker.ServiceDefinition {
 	addresses := ""
 	for i, member := range p.stack.Members {
@@ -261,3 +265,26 @@ func (p *BesuProvider) GetConnectorURL(org *types.Organization) string {
 func (p *BesuProvider) GetConnectorExternalURL(org *types.Organization) string {
 	return fmt.Sprintf("http://127.0.0.1:%v", org.ExposedConnectorPort)
 }
+
+func (p *BesuProvider) AddMember(member *types.Organization) (interface{}, error) {
+	account, err := p.CreateAccount([]string{member.OrgName, member.OrgName, member.ID})
+	if err != nil {
+		return nil, err
+	}
+	member.Account = account
+
+	// Generate the connector config for the new member, and copy it to the member's volume
+	connectorConfigPath := filepath.Join(p.stack.RuntimeDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
+	if err := p.connector.GenerateConfig(p.stack, member, "ethsigner").WriteConfig(connectorConfigPath, ""); err != nil {
+		return nil, err
+	}
+	connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), member.ID)
+	if err := docker.CopyFileToVolume(p.ctx, connectorConfigVolumeName, connectorConfigPath, "config.yaml"); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (p *BesuProvider) PostAddMember(member *types.Organization) error {
+	return nil
+}
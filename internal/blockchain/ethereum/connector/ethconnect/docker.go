@@ -34,7 +34,7 @@ func (e *Ethconnect) GetServiceDefinitions(s *types.Stack, dependentServices map
 			ServiceName: "ethconnect_" + member.ID,
 			Service: &docker.Service{
 				Image:         s.VersionManifest.Ethconnect.GetDockerImageString(),
-				ContainerName: fmt.Sprintf("%s_ethconnect_%v", s.Name, i),
+				ContainerName: fmt.Sprintf("%s_ethconnect_%v", s.Name, member.ID),
 				Command:       "server -f ./config/config.yaml -d 2",
 				DependsOn:     dependsOn,
 				Ports:         []string{fmt.Sprintf("%d:8080", member.ExposedConnectorPort)},
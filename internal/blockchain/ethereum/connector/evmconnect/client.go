@@ -124,12 +124,18 @@ func (e *Evmconnect) waitForTransactionSuccess(evmconnectURL, id string) (*Evmco
 		if err != nil {
 			return nil, err
 		}
-		if tx.Status == "Succeeded" {
+		switch tx.Status {
+		case "Succeeded":
 			return tx, nil
+		case "Failed":
+			return nil, fmt.Errorf("transaction %s failed", id)
+		}
+		retries--
+		if err := core.Sleep(e.ctx, time.Millisecond*3000); err != nil {
+			return nil, err
 		}
-		time.Sleep(time.Millisecond * 3000)
 	}
-	return nil, nil
+	return nil, fmt.Errorf("transaction %s did not succeed in time", id)
 }
 
 func (e *Evmconnect) getTransactionStatus(evmconnectURL, id string) (*EvmconnectTransactionResponse, error) {
@@ -2,8 +2,13 @@ package evmconnect
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/hyperledger/firefly-cli/internal/log"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,3 +36,41 @@ func TestNewEvmconnect(t *testing.T) {
 	assert.NotNil(t, EvmConnect)
 
 }
+
+func newTransactionStatusServer(t *testing.T, statuses ...string) *httptest.Server {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[len(statuses)-1]
+		if requests < len(statuses) {
+			status = statuses[requests]
+		}
+		requests++
+		fmt.Fprintf(w, `{"id":"tx1","status":"%s"}`, status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWaitForTransactionSuccess(t *testing.T) {
+	server := newTransactionStatusServer(t, "Succeeded")
+	e := NewEvmconnect(log.WithVerbosity(context.Background(), false))
+	tx, err := e.waitForTransactionSuccess(server.URL, "tx1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Succeeded", tx.Status)
+}
+
+func TestWaitForTransactionSuccessFailed(t *testing.T) {
+	server := newTransactionStatusServer(t, "Failed")
+	e := NewEvmconnect(log.WithVerbosity(context.Background(), false))
+	_, err := e.waitForTransactionSuccess(server.URL, "tx1")
+	assert.Regexp(t, "transaction tx1 failed", err)
+}
+
+func TestWaitForTransactionSuccessCanceled(t *testing.T) {
+	server := newTransactionStatusServer(t, "Pending")
+	ctx, cancel := context.WithTimeout(log.WithVerbosity(context.Background(), false), 100*time.Millisecond)
+	defer cancel()
+	e := NewEvmconnect(ctx)
+	_, err := e.waitForTransactionSuccess(server.URL, "tx1")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
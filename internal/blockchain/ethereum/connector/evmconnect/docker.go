@@ -35,7 +35,7 @@ func (e *Evmconnect) GetServiceDefinitions(s *types.Stack, dependentServices map
 			ServiceName: "evmconnect_" + member.ID,
 			Service: &docker.Service{
 				Image:         s.VersionManifest.Evmconnect.GetDockerImageString(),
-				ContainerName: fmt.Sprintf("%s_evmconnect_%v", s.Name, i),
+				ContainerName: fmt.Sprintf("%s_evmconnect_%v", s.Name, member.ID),
 				Command:       "-f /evmconnect/config.yaml",
 				DependsOn:     dependsOn,
 				Ports:         []string{fmt.Sprintf("%d:%v", member.ExposedConnectorPort, e.Port())},
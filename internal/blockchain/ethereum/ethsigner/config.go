@@ -19,6 +19,7 @@ package ethsigner
 import (
 	"os"
 
+	"github.com/hyperledger/firefly-cli/pkg/types"
 	"gopkg.in/yaml.v3"
 )
 
@@ -46,9 +47,24 @@ type ServerConfig struct {
 	Address string `yaml:"address,omitempty"`
 }
 
+type BasicAuthConfig struct {
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+}
+
+type TLSConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	CA      string `yaml:"ca,omitempty"`
+	Cert    string `yaml:"cert,omitempty"`
+	Key     string `yaml:"key,omitempty"`
+}
+
 type BackendConfig struct {
-	ChainID *int64 `yaml:"chainId,omitempty"`
-	URL     string `yaml:"url,omitempty"`
+	ChainID *int64            `yaml:"chainId,omitempty"`
+	URL     string            `yaml:"url,omitempty"`
+	Auth    *BasicAuthConfig  `yaml:"auth,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	TLS     *TLSConfig        `yaml:"tls,omitempty"`
 }
 
 type LogConfig struct {
@@ -64,7 +80,30 @@ type Config struct {
 
 func (e *Config) WriteConfig(filename string) error {
 	configYamlBytes, _ := yaml.Marshal(e)
-	return os.WriteFile(filename, configYamlBytes, 0755)
+	// The backend section can hold credentials for the node
+	return os.WriteFile(filename, configYamlBytes, 0600)
+}
+
+// SetRemoteNodeAuth adds the credentials and TLS settings for the node to the backend config
+func (b *BackendConfig) SetRemoteNodeAuth(auth *types.RemoteNodeAuth) {
+	if auth.IsEmpty() {
+		return
+	}
+	if auth.Username != "" {
+		b.Auth = &BasicAuthConfig{
+			Username: auth.Username,
+			Password: auth.Password,
+		}
+	}
+	b.Headers = auth.AllHeaders()
+	if auth.HasTLS() {
+		b.TLS = &TLSConfig{
+			Enabled: true,
+			CA:      auth.CACert,
+			Cert:    auth.ClientCert,
+			Key:     auth.ClientKey,
+		}
+	}
 }
 
 func GenerateSignerConfig(chainID int64, rpcURL string) *Config {
@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/hyperledger/firefly-cli/pkg/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,3 +66,26 @@ func TestGenerateSignerConfig(t *testing.T) {
 
 	assert.Equal(t, expectedConfig, config, "Generated config should match the expected config")
 }
+
+func TestSetRemoteNodeAuth(t *testing.T) {
+	config := GenerateSignerConfig(12345, "https://rpc.example.com")
+	config.Backend.SetRemoteNodeAuth(&types.RemoteNodeAuth{
+		BearerToken: "token",
+		Headers:     map[string]string{"X-Api-Key": "abc123"},
+		ClientCert:  "cert",
+		ClientKey:   "key",
+	})
+	assert.Nil(t, config.Backend.Auth)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer token", "X-Api-Key": "abc123"}, config.Backend.Headers)
+	assert.Equal(t, &TLSConfig{Enabled: true, Cert: "cert", Key: "key"}, config.Backend.TLS)
+
+	config = GenerateSignerConfig(12345, "http://rpc.example.com")
+	config.Backend.SetRemoteNodeAuth(&types.RemoteNodeAuth{Username: "user", Password: "pass"})
+	assert.Equal(t, &BasicAuthConfig{Username: "user", Password: "pass"}, config.Backend.Auth)
+	assert.Nil(t, config.Backend.Headers)
+	assert.Nil(t, config.Backend.TLS)
+
+	config = GenerateSignerConfig(12345, "http://besu:8545")
+	config.Backend.SetRemoteNodeAuth(nil)
+	assert.Equal(t, GenerateSignerConfig(12345, "http://besu:8545"), config)
+}
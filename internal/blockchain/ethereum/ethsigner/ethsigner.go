@@ -49,7 +49,14 @@ func NewEthSignerProvider(ctx context.Context, stack *types.Stack) *EthSignerPro
 	}
 }
 
-func (p *EthSignerProvider) WriteConfig(options *types.InitOptions, rpcURL string) error {
+// WriteConfig writes the signer config for the node at rpcURL. The auth settings are optional,
+// and only needed for nodes that are not part of the stack.
+func (p *EthSignerProvider) WriteConfig(options *types.InitOptions, rpcURL string, auth *types.RemoteNodeAuth) error {
+	if useJavaSigner && !auth.IsEmpty() {
+		// Its downstream options only cover plain TLS, which getCommand enables for https URLs, and
+		// credentials passed on its command line would end up in docker-compose.yml and docker inspect
+		return fmt.Errorf("the Java signer does not support credentials or custom TLS settings for the remote node")
+	}
 
 	// Write the password that will be used to encrypt the private key
 	initDir := filepath.Join(constants.StacksDir, p.stack.Name, "init")
@@ -62,8 +69,10 @@ func (p *EthSignerProvider) WriteConfig(options *types.InitOptions, rpcURL strin
 	}
 
 	signerConfigPath := filepath.Join(initDir, "config", "ethsigner.yaml")
-	if err := GenerateSignerConfig(options.ChainID, rpcURL).WriteConfig(signerConfigPath); err != nil {
-		return nil
+	signerConfig := GenerateSignerConfig(options.ChainID, rpcURL)
+	signerConfig.Backend.SetRemoteNodeAuth(auth)
+	if err := signerConfig.WriteConfig(signerConfigPath); err != nil {
+		return err
 	}
 
 	return nil
@@ -13,7 +13,7 @@ func TestWriteSignerConfig(t *testing.T) {
 	e := EthSignerProvider{
 		stack: stack,
 	}
-	err := e.WriteConfig(options, rpcURL)
+	err := e.WriteConfig(options, rpcURL, nil)
 	if err != nil {
 		t.Logf("unable to write config :%v", err)
 	}
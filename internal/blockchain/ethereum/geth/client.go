@@ -18,6 +18,7 @@ package geth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -53,7 +54,7 @@ func NewGethClient(rpcURL string) *GethClient {
 	}
 }
 
-func (g *GethClient) UnlockAccount(address string, password string) error {
+func (g *GethClient) UnlockAccount(ctx context.Context, address string, password string) error {
 	requestBody, err := json.Marshal(&JSONRPCRequest{
 		JSONRPC: "2.0",
 		ID:      0,
@@ -63,7 +64,7 @@ func (g *GethClient) UnlockAccount(address string, password string) error {
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequest("POST", g.rpcURL, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", g.rpcURL, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return err
 	}
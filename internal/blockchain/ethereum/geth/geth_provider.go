@@ -31,6 +31,7 @@ import (
 	"github.com/hyperledger/firefly-cli/internal/blockchain/ethereum/connector/ethconnect"
 	"github.com/hyperledger/firefly-cli/internal/blockchain/ethereum/connector/evmconnect"
 	"github.com/hyperledger/firefly-cli/internal/constants"
+	"github.com/hyperledger/firefly-cli/internal/core"
 	"github.com/hyperledger/firefly-cli/internal/docker"
 	"github.com/hyperledger/firefly-cli/internal/log"
 	"github.com/hyperledger/firefly-cli/pkg/types"
@@ -65,9 +66,9 @@ func NewGethProvider(ctx context.Context, stack *types.Stack) *GethProvider {
 
 func (p *GethProvider) WriteConfig(options *types.InitOptions) error {
 	initDir := filepath.Join(constants.StacksDir, p.stack.Name, "init")
-	for i, member := range p.stack.Members {
+	for _, member := range p.stack.Members {
 		// Generate the connector config for each member
-		connectorConfigPath := filepath.Join(initDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), i))
+		connectorConfigPath := filepath.Join(initDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
 		if err := p.connector.GenerateConfig(p.stack, member, "geth").WriteConfig(connectorConfigPath, options.ExtraConnectorConfigPath); err != nil {
 			return nil
 		}
@@ -101,10 +102,10 @@ func (p *GethProvider) FirstTimeSetup() error {
 		return err
 	}
 
-	for i := range p.stack.Members {
+	for _, member := range p.stack.Members {
 		// Copy connector config to each member's volume
-		connectorConfigPath := filepath.Join(p.stack.StackDir, "runtime", "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), i))
-		connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), i)
+		connectorConfigPath := filepath.Join(p.stack.StackDir, "runtime", "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
+		connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), member.ID)
 		if err := docker.CopyFileToVolume(p.ctx, connectorConfigVolumeName, connectorConfigPath, "config.yaml"); err != nil {
 			return err
 		}
@@ -151,20 +152,19 @@ func (p *GethProvider) unlockAccount(address, password string) error {
 	l := log.LoggerFromContext(p.ctx)
 	verbose := log.VerbosityFromContext(p.ctx)
 	gethClient := NewGethClient(fmt.Sprintf("http://127.0.0.1:%v", p.stack.ExposedBlockchainPort))
-	retries := 10
-	for {
-		if err := gethClient.UnlockAccount(address, password); err != nil {
-			if verbose {
-				l.Debug(err.Error())
-			}
-			if retries == 0 {
-				return fmt.Errorf("unable to unlock account %s", address)
-			}
-			time.Sleep(time.Second * 1)
-			retries--
-		} else {
-			break
+	// The node may still be starting up, so this is retried with the retry policy of the stack
+	err := core.Retry(p.ctx, func() error {
+		err := gethClient.UnlockAccount(p.ctx, address, password)
+		if err != nil && verbose {
+			l.Debug(err.Error())
 		}
+		return err
+	})
+	if err != nil {
+		if p.ctx.Err() != nil {
+			return p.ctx.Err()
+		}
+		return fmt.Errorf("unable to unlock account %s", address)
 	}
 	return nil
 }
@@ -177,6 +177,11 @@ func (p *GethProvider) DeployFireFlyContract() (*types.ContractDeploymentResult,
 	return p.connector.DeployContract(contract, "FireFly", p.stack.Members[0], nil)
 }
 
+func (p *GethProvider) GetSetupImages() []string {
+	// Used to initialize the chain from the genesis file
+	return []string{gethImage}
+}
+
 func (p *GethProvider) GetDockerServiceDefinitions() []*docker.ServiceDefinition {
 	gethCommand := fmt.Sprintf(`--datadir /data --syncmode 'full' --port 30311 --http --http.addr "0.0.0.0" --http.corsdomain="*"  -http.port 8545 --http.vhosts "*" --http.api 'admin,personal,eth,net,web3,txpool,miner,clique,debug' --networkid %d --miner.gasprice 0 --password /data/password --mine --allow-insecure-unlock --nodiscover --verbosity 4 --miner.gaslimit 16777215`, p.stack.ChainID())
 
@@ -307,3 +312,26 @@ func (p *GethProvider) GetConnectorURL(org *types.Organization) string {
 func (p *GethProvider) GetConnectorExternalURL(org *types.Organization) string {
 	return fmt.Sprintf("http://127.0.0.1:%v", org.ExposedConnectorPort)
 }
+
+func (p *GethProvider) AddMember(member *types.Organization) (interface{}, error) {
+	account, err := p.CreateAccount([]string{member.OrgName, member.OrgName, member.ID})
+	if err != nil {
+		return nil, err
+	}
+	member.Account = account
+
+	// Generate the connector config for the new member, and copy it to the member's volume
+	connectorConfigPath := filepath.Join(p.stack.RuntimeDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
+	if err := p.connector.GenerateConfig(p.stack, member, "geth").WriteConfig(connectorConfigPath, ""); err != nil {
+		return nil, err
+	}
+	connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), member.ID)
+	if err := docker.CopyFileToVolume(p.ctx, connectorConfigVolumeName, connectorConfigPath, "config.yaml"); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (p *GethProvider) PostAddMember(member *types.Organization) error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package quorum
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -67,7 +68,7 @@ func TestUnlockAccount(t *testing.T) {
 				httpmock.NewStringResponder(tc.StatusCode, string(apiResponse)))
 			client := NewQuorumClient(tc.RPCUrl)
 			utils.StartMockServer(t)
-			err := client.UnlockAccount(tc.Address, tc.Password)
+			err := client.UnlockAccount(context.Background(), tc.Address, tc.Password)
 			utils.StopMockServer(t)
 
 			// expect errors when returned status code != 200 or ApiResponse comes back with non nil error
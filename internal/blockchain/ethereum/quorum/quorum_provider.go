@@ -32,6 +32,7 @@ import (
 	"github.com/hyperledger/firefly-cli/internal/blockchain/ethereum/connector/ethconnect"
 	"github.com/hyperledger/firefly-cli/internal/blockchain/ethereum/connector/evmconnect"
 	"github.com/hyperledger/firefly-cli/internal/blockchain/ethereum/tessera"
+	"github.com/hyperledger/firefly-cli/internal/core"
 	"github.com/hyperledger/firefly-cli/internal/docker"
 	"github.com/hyperledger/firefly-cli/internal/log"
 	"github.com/hyperledger/firefly-cli/pkg/types"
@@ -64,7 +65,7 @@ func NewQuorumProvider(ctx context.Context, stack *types.Stack) *QuorumProvider
 		ctx:       ctx,
 		stack:     stack,
 		connector: connector,
-		dockerMgr: docker.NewDockerManager(),
+		dockerMgr: docker.DockerManagerFromContext(ctx),
 	}
 }
 
@@ -73,7 +74,7 @@ func (p *QuorumProvider) WriteConfig(options *types.InitOptions) error {
 	initDir := p.stack.InitDir
 	for i, member := range p.stack.Members {
 		// Generate the connector config for each member
-		connectorConfigPath := filepath.Join(initDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), i))
+		connectorConfigPath := filepath.Join(initDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
 		if err := p.connector.GenerateConfig(p.stack, member, fmt.Sprintf("quorum_%d", i)).WriteConfig(connectorConfigPath, options.ExtraConnectorConfigPath); err != nil {
 			return nil
 		}
@@ -126,10 +127,10 @@ func (p *QuorumProvider) FirstTimeSetup() error {
 		return err
 	}
 
-	for i := range p.stack.Members {
+	for i, member := range p.stack.Members {
 		// Copy connector config to each member's volume
-		connectorConfigPath := filepath.Join(p.stack.StackDir, "runtime", "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), i))
-		connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), i)
+		connectorConfigPath := filepath.Join(p.stack.StackDir, "runtime", "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
+		connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), member.ID)
 		if err := p.dockerMgr.CopyFileToVolume(p.ctx, connectorConfigVolumeName, connectorConfigPath, "config.yaml"); err != nil {
 			return err
 		}
@@ -211,20 +212,19 @@ func (p *QuorumProvider) unlockAccount(address, password string, memberIndex int
 	verbose := log.VerbosityFromContext(p.ctx)
 	// exposed blockchain port is the default for node 0, we need to add the port multiplier to get the right rpc for the correct node
 	quorumClient := NewQuorumClient(fmt.Sprintf("http://127.0.0.1:%v", p.stack.ExposedBlockchainPort+(memberIndex*ExposedBlockchainPortMultiplier)))
-	retries := 10
-	for {
-		if err := quorumClient.UnlockAccount(address, password); err != nil {
-			if verbose {
-				l.Debug(err.Error())
-			}
-			if retries == 0 {
-				return fmt.Errorf("unable to unlock account %s", address)
-			}
-			time.Sleep(time.Second * 1)
-			retries--
-		} else {
-			break
+	// The node may still be starting up, so this is retried with the retry policy of the stack
+	err := core.Retry(p.ctx, func() error {
+		err := quorumClient.UnlockAccount(p.ctx, address, password)
+		if err != nil && verbose {
+			l.Debug(err.Error())
 		}
+		return err
+	})
+	if err != nil {
+		if p.ctx.Err() != nil {
+			return p.ctx.Err()
+		}
+		return fmt.Errorf("unable to unlock account %s", address)
 	}
 	return nil
 }
@@ -264,6 +264,15 @@ func (p *QuorumProvider) buildTesseraServiceDefinition(memberIdx int) *docker.Se
 	}
 }
 
+func (p *QuorumProvider) GetSetupImages() []string {
+	// Used to initialize the chain from the genesis file, and to generate the tessera keys
+	images := []string{quorumImage}
+	if p.stack.PrivateTransactionManager.Equals(types.PrivateTransactionManagerTessera) {
+		images = append(images, tesseraImage)
+	}
+	return images
+}
+
 func (p *QuorumProvider) GetDockerServiceDefinitions() []*docker.ServiceDefinition {
 	memberCount := len(p.stack.Members)
 	serviceDefinitionsCount := memberCount
@@ -436,3 +445,12 @@ func (p *QuorumProvider) GetConnectorURL(org *types.Organization) string {
 func (p *QuorumProvider) GetConnectorExternalURL(org *types.Organization) string {
 	return fmt.Sprintf("http://127.0.0.1:%v", org.ExposedConnectorPort)
 }
+
+func (p *QuorumProvider) AddMember(member *types.Organization) (interface{}, error) {
+	// Every member runs its own quorum node, which would also need to be added to the network
+	return nil, fmt.Errorf("adding members is not supported for stacks using quorum")
+}
+
+func (p *QuorumProvider) PostAddMember(member *types.Organization) error {
+	return nil
+}
@@ -57,17 +57,17 @@ func NewRemoteRPCProvider(ctx context.Context, stack *types.Stack) *RemoteRPCPro
 
 func (p *RemoteRPCProvider) WriteConfig(options *types.InitOptions) error {
 	initDir := filepath.Join(constants.StacksDir, p.stack.Name, "init")
-	for i, member := range p.stack.Members {
+	for _, member := range p.stack.Members {
 
 		// Generate the connector config for each member
-		connectorConfigPath := filepath.Join(initDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), i))
+		connectorConfigPath := filepath.Join(initDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
 		if err := p.connector.GenerateConfig(p.stack, member, "ethsigner").WriteConfig(connectorConfigPath, options.ExtraConnectorConfigPath); err != nil {
 			return err
 		}
 
 	}
 
-	return p.signer.WriteConfig(options, options.RemoteNodeURL)
+	return p.signer.WriteConfig(options, options.RemoteNodeURL, options.RemoteNodeAuth)
 }
 
 func (p *RemoteRPCProvider) FirstTimeSetup() error {
@@ -79,10 +79,10 @@ func (p *RemoteRPCProvider) FirstTimeSetup() error {
 		return err
 	}
 
-	for i := range p.stack.Members {
+	for _, member := range p.stack.Members {
 		// Copy connector config to each member's volume
-		connectorConfigPath := filepath.Join(p.stack.StackDir, "runtime", "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), i))
-		connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), i)
+		connectorConfigPath := filepath.Join(p.stack.StackDir, "runtime", "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
+		connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), member.ID)
 		if err := docker.CopyFileToVolume(p.ctx, connectorConfigVolumeName, connectorConfigPath, "config.yaml"); err != nil {
 			return err
 		}
@@ -110,6 +110,10 @@ func (p *RemoteRPCProvider) DeployFireFlyContract() (*types.ContractDeploymentRe
 	return nil, fmt.Errorf("you must pre-deploy your FireFly contract when using a remote RPC endpoint")
 }
 
+func (p *RemoteRPCProvider) GetSetupImages() []string {
+	return nil
+}
+
 func (p *RemoteRPCProvider) GetDockerServiceDefinitions() []*docker.ServiceDefinition {
 	defs := []*docker.ServiceDefinition{
 		p.signer.GetDockerServiceDefinition(p.stack.RemoteNodeURL),
@@ -196,3 +200,27 @@ func (p *RemoteRPCProvider) ParseAccount(account interface{}) interface{} {
 		PrivateKey: accountMap["privateKey"].(string),
 	}
 }
+
+func (p *RemoteRPCProvider) AddMember(member *types.Organization) (interface{}, error) {
+	// Note: the new account is not funded, so it will only be able to submit transactions on chains without gas fees
+	account, err := p.CreateAccount([]string{member.OrgName, member.OrgName, member.ID})
+	if err != nil {
+		return nil, err
+	}
+	member.Account = account
+
+	// Generate the connector config for the new member, and copy it to the member's volume
+	connectorConfigPath := filepath.Join(p.stack.RuntimeDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
+	if err := p.connector.GenerateConfig(p.stack, member, "ethsigner").WriteConfig(connectorConfigPath, ""); err != nil {
+		return nil, err
+	}
+	connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), member.ID)
+	if err := docker.CopyFileToVolume(p.ctx, connectorConfigVolumeName, connectorConfigPath, "config.yaml"); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (p *RemoteRPCProvider) PostAddMember(member *types.Organization) error {
+	return nil
+}
@@ -18,6 +18,7 @@ package fabconnect
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -45,7 +46,7 @@ type EnrollIdentityResponse struct {
 	Success bool
 }
 
-func CreateIdentity(fabconnectURL string, signer string) (*CreateIdentityResponse, error) {
+func CreateIdentity(ctx context.Context, fabconnectURL string, signer string) (*CreateIdentityResponse, error) {
 	u, err := url.Parse(fabconnectURL)
 	if err != nil {
 		return nil, err
@@ -59,7 +60,7 @@ func CreateIdentity(fabconnectURL string, signer string) (*CreateIdentityRespons
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", requestURL, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +88,7 @@ func CreateIdentity(fabconnectURL string, signer string) (*CreateIdentityRespons
 	return createIdentityResponseBody, nil
 }
 
-func EnrollIdentity(fabconnectURL, signer, secret string) (*EnrollIdentityResponse, error) {
+func EnrollIdentity(ctx context.Context, fabconnectURL, signer, secret string) (*EnrollIdentityResponse, error) {
 	u, err := url.Parse(fabconnectURL)
 	if err != nil {
 		return nil, err
@@ -101,7 +102,7 @@ func EnrollIdentity(fabconnectURL, signer, secret string) (*EnrollIdentityRespon
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", requestURL, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, err
 	}
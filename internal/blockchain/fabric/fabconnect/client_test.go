@@ -1,6 +1,7 @@
 package fabconnect
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -76,7 +77,7 @@ func TestCreateIdentity(t *testing.T) {
 			httpmock.RegisterResponder(tc.Method, tc.FabconnectURL,
 				httpmock.NewStringResponder(200, tc.ApiResponse))
 
-			identityResp, err := CreateIdentity(tc.FabconnectURL, tc.Signer)
+			identityResp, err := CreateIdentity(context.Background(), tc.FabconnectURL, tc.Signer)
 			if err != nil {
 				t.Fatalf("unable to create identity: %v", err)
 			}
@@ -171,7 +172,7 @@ func TestEnrollIdentity(t *testing.T) {
 			//mockResponse
 			httpmock.RegisterResponder(tc.Method, fmt.Sprintf("%s/%s/enroll", tc.FabconnectURL, tc.Signer),
 				httpmock.NewStringResponder(200, tc.ApiResponse))
-			enrolledIdentity, err := EnrollIdentity(tc.FabconnectURL, tc.Signer, tc.Secret)
+			enrolledIdentity, err := EnrollIdentity(context.Background(), tc.FabconnectURL, tc.Signer, tc.Secret)
 			if err != nil {
 				t.Log("enroll identity failed:", err)
 			}
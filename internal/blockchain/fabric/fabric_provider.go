@@ -226,6 +226,11 @@ func (p *FabricProvider) PostStart(firstTimeSetup bool) error {
 	return nil
 }
 
+func (p *FabricProvider) GetSetupImages() []string {
+	// Used to generate the crypto material and genesis block, and to install the chaincode
+	return []string{FabricToolsImageName}
+}
+
 func (p *FabricProvider) GetDockerServiceDefinitions() []*docker.ServiceDefinition {
 	if p.stack.RemoteFabricNetwork {
 		return p.getFabconnectServiceDefinitions(p.stack.Members)
@@ -497,11 +502,11 @@ func (p *FabricProvider) commitChaincode(channel, chaincode, version string) err
 }
 
 func (p *FabricProvider) registerIdentity(member *types.Organization, name string) (*Account, error) {
-	res, err := fabconnect.CreateIdentity(fmt.Sprintf("http://127.0.0.1:%v", member.ExposedConnectorPort), name)
+	res, err := fabconnect.CreateIdentity(p.ctx, fmt.Sprintf("http://127.0.0.1:%v", member.ExposedConnectorPort), name)
 	if err != nil {
 		return nil, err
 	}
-	_, err = fabconnect.EnrollIdentity(fmt.Sprintf("http://127.0.0.1:%v", member.ExposedConnectorPort), name, res.Secret)
+	_, err = fabconnect.EnrollIdentity(p.ctx, fmt.Sprintf("http://127.0.0.1:%v", member.ExposedConnectorPort), name, res.Secret)
 	if err != nil {
 		return nil, err
 	}
@@ -628,3 +633,20 @@ func (p *FabricProvider) GetConnectorURL(org *types.Organization) string {
 func (p *FabricProvider) GetConnectorExternalURL(org *types.Organization) string {
 	return fmt.Sprintf("http://127.0.0.1:%v", org.ExposedConnectorPort)
 }
+
+func (p *FabricProvider) AddMember(member *types.Organization) (interface{}, error) {
+	if p.stack.RemoteFabricNetwork {
+		return nil, fmt.Errorf("adding members is not supported for stacks using a remote fabric network")
+	}
+	// The identity is registered with the CA through the new member's fabconnect, once it has started
+	return &Account{
+		Name:    member.OrgName,
+		OrgName: member.OrgName,
+	}, nil
+}
+
+func (p *FabricProvider) PostAddMember(member *types.Organization) error {
+	p.log.Info(fmt.Sprintf("registering identity for member %s", member.ID))
+	_, err := p.registerIdentity(member, member.OrgName)
+	return err
+}
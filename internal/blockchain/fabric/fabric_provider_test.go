@@ -392,7 +392,7 @@ func TestRegisterIdentity(t *testing.T) {
 		httpmock.RegisterResponder("POST", enrollIdentityURL,
 			httpmock.NewStringResponder(200, enrolledApiResponse))
 
-		p := &FabricProvider{}
+		p := &FabricProvider{ctx: context.Background()}
 
 		account, err := p.registerIdentity(Member, IdentityName)
 		if err != nil {
@@ -73,7 +73,12 @@ type ConfirmationsConfig struct {
 
 func (c *Config) WriteConfig(filename string, extraTezosconnectConfigPath string) error {
 	configYamlBytes, _ := yaml.Marshal(c)
-	if err := os.WriteFile(filename, configYamlBytes, 0755); err != nil {
+	// The connector URL can hold the credentials for a remote node
+	if err := os.WriteFile(filename, configYamlBytes, 0600); err != nil {
+		return err
+	}
+	// WriteFile does not change the mode of a file that already exists
+	if err := os.Chmod(filename, 0600); err != nil {
 		return err
 	}
 	if extraTezosconnectConfigPath != "" {
@@ -85,7 +90,7 @@ func (c *Config) WriteConfig(filename string, extraTezosconnectConfigPath string
 		if err != nil {
 			return err
 		}
-		if err := os.WriteFile(filename, bytes, 0755); err != nil {
+		if err := os.WriteFile(filename, bytes, 0600); err != nil {
 			return err
 		}
 	}
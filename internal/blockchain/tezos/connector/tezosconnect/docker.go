@@ -35,7 +35,7 @@ func (t *Tezosconnect) GetServiceDefinitions(s *types.Stack, dependentServices m
 			ServiceName: "tezosconnect_" + member.ID,
 			Service: &docker.Service{
 				Image:         s.VersionManifest.Tezosconnect.GetDockerImageString(),
-				ContainerName: fmt.Sprintf("%s_tezosconnect_%v", s.Name, i),
+				ContainerName: fmt.Sprintf("%s_tezosconnect_%v", s.Name, member.ID),
 				Command:       "-f /tezosconnect/config.yaml",
 				DependsOn:     dependsOn,
 				Ports:         []string{fmt.Sprintf("%d:%v", member.ExposedConnectorPort, t.Port())},
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"path/filepath"
 
 	"github.com/hyperledger/firefly-cli/internal/blockchain/tezos"
@@ -49,11 +50,15 @@ func NewRemoteRPCProvider(ctx context.Context, stack *types.Stack) *RemoteRPCPro
 }
 
 func (p *RemoteRPCProvider) WriteConfig(options *types.InitOptions) error {
+	rpcURL, err := remoteNodeURL(options.RemoteNodeURL, options.RemoteNodeAuth)
+	if err != nil {
+		return err
+	}
 	initDir := filepath.Join(constants.StacksDir, p.stack.Name, "init")
-	for i, member := range p.stack.Members {
+	for _, member := range p.stack.Members {
 		// Generate the connector config for each member
-		connectorConfigPath := filepath.Join(initDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), i))
-		if err := p.connector.GenerateConfig(p.stack, member, "tezossigner", options.RemoteNodeURL).WriteConfig(connectorConfigPath, options.ExtraConnectorConfigPath); err != nil {
+		connectorConfigPath := filepath.Join(initDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
+		if err := p.connector.GenerateConfig(p.stack, member, "tezossigner", rpcURL).WriteConfig(connectorConfigPath, options.ExtraConnectorConfigPath); err != nil {
 			return err
 		}
 	}
@@ -66,10 +71,10 @@ func (p *RemoteRPCProvider) FirstTimeSetup() error {
 		return err
 	}
 
-	for i := range p.stack.Members {
+	for _, member := range p.stack.Members {
 		// Copy connector config to each member's volume
-		connectorConfigPath := filepath.Join(p.stack.StackDir, "runtime", "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), i))
-		connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), i)
+		connectorConfigPath := filepath.Join(p.stack.StackDir, "runtime", "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
+		connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), member.ID)
 		if err := docker.CopyFileToVolume(p.ctx, connectorConfigVolumeName, connectorConfigPath, "config.yaml"); err != nil {
 			return err
 		}
@@ -90,6 +95,10 @@ func (p *RemoteRPCProvider) DeployFireFlyContract() (*types.ContractDeploymentRe
 	return nil, fmt.Errorf("you must pre-deploy your FireFly contract when using a remote RPC endpoint")
 }
 
+func (p *RemoteRPCProvider) GetSetupImages() []string {
+	return nil
+}
+
 func (p *RemoteRPCProvider) GetDockerServiceDefinitions() []*docker.ServiceDefinition {
 	defs := []*docker.ServiceDefinition{
 		p.signer.GetDockerServiceDefinition(p.stack.RemoteNodeURL),
@@ -165,3 +174,47 @@ func (p *RemoteRPCProvider) ParseAccount(account interface{}) interface{} {
 		PrivateKey: accountMap["privateKey"].(string),
 	}
 }
+
+func (p *RemoteRPCProvider) AddMember(member *types.Organization) (interface{}, error) {
+	account, err := p.signer.AddMember(member)
+	if err != nil {
+		return nil, err
+	}
+	member.Account = account
+
+	// Generate the connector config for the new member, and copy it to the member's volume
+	rpcURL, err := remoteNodeURL(p.stack.RemoteNodeURL, p.stack.RemoteNodeAuth)
+	if err != nil {
+		return nil, err
+	}
+	connectorConfigPath := filepath.Join(p.stack.RuntimeDir, "config", fmt.Sprintf("%s_%v.yaml", p.connector.Name(), member.ID))
+	if err := p.connector.GenerateConfig(p.stack, member, "tezossigner", rpcURL).WriteConfig(connectorConfigPath, ""); err != nil {
+		return nil, err
+	}
+	connectorConfigVolumeName := fmt.Sprintf("%s_%s_config_%v", p.stack.Name, p.connector.Name(), member.ID)
+	if err := docker.CopyFileToVolume(p.ctx, connectorConfigVolumeName, connectorConfigPath, "config.yaml"); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (p *RemoteRPCProvider) PostAddMember(member *types.Organization) error {
+	return nil
+}
+
+// remoteNodeURL returns the URL tezosconnect uses to reach the node. tezosconnect only takes
+// a URL, so basic auth credentials are sent as part of it and other settings are rejected.
+func remoteNodeURL(rpcURL string, auth *types.RemoteNodeAuth) (string, error) {
+	if auth.IsEmpty() {
+		return rpcURL, nil
+	}
+	if auth.HasHeaders() || auth.HasTLS() {
+		return "", fmt.Errorf("tezosconnect only supports basic auth credentials for the remote node")
+	}
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid remote node URL '%s': %s", rpcURL, err)
+	}
+	u.User = url.UserPassword(auth.Username, auth.Password)
+	return u.String(), nil
+}
@@ -18,6 +18,7 @@ package tezossigner
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -171,3 +172,58 @@ func (p *TezosSignerProvider) CreateAccount(args []string) (interface{}, error)
 		PrivateKey: pk,
 	}, nil
 }
+
+// AddMember generates a key for a member that is being added to a stack that has already been
+// started. The signer holds the keys of every member in a single secret file, so the secret file
+// and the signer config are both rewritten, and the signer is restarted to pick them up.
+func (p *TezosSignerProvider) AddMember(member *types.Organization) (interface{}, error) {
+	address, pk, err := tezos.GenerateAddressAndPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	account := &tezos.Account{
+		Address:    address,
+		PrivateKey: pk,
+	}
+
+	secrets := make([]map[string]string, 0, len(p.stack.Members)+1)
+	addresses := make([]string, 0, len(p.stack.Members)+1)
+	for _, m := range p.stack.Members {
+		if m.Account != nil {
+			a := m.Account.(*tezos.Account)
+			secrets = append(secrets, map[string]string{"name": a.Address, "value": "unencrypted:" + a.PrivateKey})
+			addresses = append(addresses, a.Address)
+		}
+	}
+	secrets = append(secrets, map[string]string{"name": address, "value": "unencrypted:" + pk})
+	addresses = append(addresses, address)
+
+	outputDirectory := filepath.Join(p.stack.RuntimeDir, "blockchain", "keystore")
+	if err := os.MkdirAll(outputDirectory, 0755); err != nil {
+		return nil, err
+	}
+	secretBytes, err := json.MarshalIndent(secrets, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	secretPath := filepath.Join(outputDirectory, "secret.json")
+	if err := os.WriteFile(secretPath, secretBytes, 0755); err != nil {
+		return nil, err
+	}
+	signerConfigPath := filepath.Join(p.stack.RuntimeDir, "config", "tezossigner.yaml")
+	if err := GenerateSignerConfig(addresses).WriteConfig(signerConfigPath); err != nil {
+		return nil, err
+	}
+
+	signerConfigVolumeName := fmt.Sprintf("%s_tezossigner_config", p.stack.Name)
+	if err := docker.CopyFileToVolume(p.ctx, signerConfigVolumeName, signerConfigPath, "signatory.yaml"); err != nil {
+		return nil, err
+	}
+	if err := docker.CopyFileToVolume(p.ctx, signerConfigVolumeName, secretPath, "secret.json"); err != nil {
+		return nil, err
+	}
+	if err := docker.RunDockerCommand(p.ctx, p.stack.StackDir, "restart", fmt.Sprintf("%s_tezossigner", p.stack.Name)); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
@@ -27,6 +27,7 @@ var IPFSImageName = "ipfs/go-ipfs:v0.10.0"
 var PostgresImageName = "postgres"
 var PrometheusImageName = "prom/prometheus"
 var SandboxImageName = "ghcr.io/hyperledger/firefly-sandbox:latest"
+var AlpineImageName = "alpine"
 
 func checkHome() string {
 	var homeDir, _ = os.UserHomeDir()
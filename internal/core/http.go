@@ -26,35 +26,82 @@ import (
 	"time"
 
 	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/pkg/types"
 )
 
 var requestTimeout int = -1
 
+// DefaultRetryPolicy retries a failed request every second for 30 seconds
+var DefaultRetryPolicy = &types.RetryPolicy{
+	Retries:      30,
+	InitialDelay: "1s",
+}
+
+type ctxRetryPolicyKey struct{}
+
 func SetRequestTimeout(customRequestTimeoutSecs int) {
 	requestTimeout = customRequestTimeoutSecs
 }
 
-func RequestWithRetry(ctx context.Context, method, url string, body, result interface{}) (err error) {
+// WithRetryPolicy returns a context in which failed requests are retried with policy
+func WithRetryPolicy(ctx context.Context, policy *types.RetryPolicy) context.Context {
+	return context.WithValue(ctx, ctxRetryPolicyKey{}, policy)
+}
+
+// RetryPolicyFromContext returns the policy set with WithRetryPolicy, or DefaultRetryPolicy
+func RetryPolicyFromContext(ctx context.Context) *types.RetryPolicy {
+	if policy, ok := ctx.Value(ctxRetryPolicyKey{}).(*types.RetryPolicy); ok && policy != nil {
+		return policy
+	}
+	return DefaultRetryPolicy
+}
+
+// Sleep waits for d, returning early with an error if ctx is done first
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Retry calls fn until it succeeds, or it has failed as many times as the retry policy of
+// ctx allows. It stops as soon as ctx is done.
+func Retry(ctx context.Context, fn func() error) error {
 	verbose := log.VerbosityFromContext(ctx)
-	retries := 30
-	for {
-		if err := request(method, url, body, result); err != nil {
-			if retries > 0 {
-				if verbose {
-					fmt.Printf("%s - retrying request...", err.Error())
-				}
-				retries--
-				time.Sleep(1 * time.Second)
-			} else {
-				return err
-			}
-		} else {
-			return nil
+	policy := RetryPolicyFromContext(ctx)
+	for retry := 0; ; retry++ {
+		err := fn()
+		if err == nil || retry >= policy.Retries {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if verbose {
+			fmt.Printf("%s - retrying request...", err.Error())
+		}
+		if err := Sleep(ctx, policy.DelayBefore(retry)); err != nil {
+			return err
 		}
 	}
 }
 
-func request(method, url string, body, result interface{}) (err error) {
+func RequestWithRetry(ctx context.Context, method, url string, body, result interface{}) (err error) {
+	return Retry(ctx, func() error {
+		return request(ctx, method, url, body, result)
+	})
+}
+
+// Request makes a single request, without retrying if it fails
+func Request(ctx context.Context, method, url string, body, result interface{}) error {
+	return request(ctx, method, url, body, result)
+}
+
+func request(ctx context.Context, method, url string, body, result interface{}) (err error) {
 	if body == nil {
 		body = make(map[string]interface{})
 	}
@@ -68,7 +115,7 @@ func request(method, url string, body, result interface{}) (err error) {
 		bodyReader = bytes.NewReader(requestBody)
 	}
 
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,98 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryUsesPolicyFromContext(t *testing.T) {
+	ctx := log.WithVerbosity(context.Background(), false)
+	ctx = WithRetryPolicy(ctx, &types.RetryPolicy{Retries: 3})
+
+	attempts := 0
+	err := Retry(ctx, func() error {
+		attempts++
+		return fmt.Errorf("pop")
+	})
+	assert.Regexp(t, "pop", err)
+	assert.Equal(t, 4, attempts)
+
+	attempts = 0
+	err = Retry(ctx, func() error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("pop")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryStopsWhenContextIsCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(log.WithVerbosity(context.Background(), false))
+	ctx = WithRetryPolicy(ctx, &types.RetryPolicy{Retries: 30, InitialDelay: "1h"})
+
+	attempts := 0
+	err := Retry(ctx, func() error {
+		attempts++
+		cancel()
+		return fmt.Errorf("pop")
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryPolicyDelays(t *testing.T) {
+	policy := &types.RetryPolicy{InitialDelay: "1s", MaxDelay: "5s"}
+	assert.NoError(t, policy.Validate())
+	assert.Equal(t, time.Second, policy.DelayBefore(0))
+	assert.Equal(t, 2*time.Second, policy.DelayBefore(1))
+	assert.Equal(t, 4*time.Second, policy.DelayBefore(2))
+	assert.Equal(t, 5*time.Second, policy.DelayBefore(3))
+
+	policy = DefaultRetryPolicy
+	assert.Equal(t, time.Second, policy.DelayBefore(0))
+	assert.Equal(t, time.Second, policy.DelayBefore(10))
+
+	assert.Regexp(t, "invalid retry delay 'soon'", (&types.RetryPolicy{InitialDelay: "soon"}).Validate())
+	assert.Regexp(t, "cannot be negative", (&types.RetryPolicy{Retries: -1}).Validate())
+}
+
+func TestRequestWithRetryCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(log.WithVerbosity(context.Background(), false), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := RequestWithRetry(ctx, http.MethodGet, server.URL, nil, nil)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
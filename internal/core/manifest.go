@@ -30,7 +30,7 @@ import (
 	"github.com/hyperledger/firefly-common/pkg/fftypes"
 )
 
-func GetManifestForChannel(releaseChannel fftypes.FFEnum) (*types.VersionManifest, error) {
+func GetManifestForChannel(ctx context.Context, releaseChannel fftypes.FFEnum) (*types.VersionManifest, error) {
 	dockerTag := releaseChannel.String()
 	if releaseChannel == types.ReleaseChannelStable {
 		dockerTag = "latest"
@@ -38,17 +38,17 @@ func GetManifestForChannel(releaseChannel fftypes.FFEnum) (*types.VersionManifes
 
 	imageName := fmt.Sprintf("%s:%s", constants.FireFlyCoreImageName, dockerTag)
 
-	gitCommit, err := docker.GetImageLabel(imageName, "commit")
+	gitCommit, err := docker.GetImageLabel(ctx, imageName, "commit")
 	if err != nil {
 		return nil, err
 	}
 
-	sha, err := getSHA(constants.FireFlyCoreImageName, dockerTag)
+	sha, err := getSHA(ctx, constants.FireFlyCoreImageName, dockerTag)
 	if err != nil {
 		return nil, err
 	}
 
-	manifest, err := getManifest(gitCommit)
+	manifest, err := getManifest(ctx, gitCommit)
 	if err != nil {
 		return nil, err
 	}
@@ -65,17 +65,17 @@ func GetManifestForChannel(releaseChannel fftypes.FFEnum) (*types.VersionManifes
 	return manifest, nil
 }
 
-func GetManifestForRelease(version string) (*types.VersionManifest, error) {
+func GetManifestForRelease(ctx context.Context, version string) (*types.VersionManifest, error) {
 	tag := version
 	if version == "main" {
 		tag = "head"
 	}
-	sha, err := getSHA(constants.FireFlyCoreImageName, tag)
+	sha, err := getSHA(ctx, constants.FireFlyCoreImageName, tag)
 	if err != nil {
 		return nil, err
 	}
 
-	manifest, err := getManifest(version)
+	manifest, err := getManifest(ctx, version)
 	if err != nil {
 		return nil, err
 	}
@@ -92,16 +92,16 @@ func GetManifestForRelease(version string) (*types.VersionManifest, error) {
 	return manifest, nil
 }
 
-func getManifest(version string) (*types.VersionManifest, error) {
+func getManifest(ctx context.Context, version string) (*types.VersionManifest, error) {
 	manifest := &types.VersionManifest{}
-	if err := request("GET", fmt.Sprintf("https://raw.githubusercontent.com/hyperledger/firefly/%s/manifest.json", version), nil, &manifest); err != nil {
+	if err := request(ctx, "GET", fmt.Sprintf("https://raw.githubusercontent.com/hyperledger/firefly/%s/manifest.json", version), nil, &manifest); err != nil {
 		return nil, err
 	}
 	return manifest, nil
 }
 
-func getSHA(imageName, imageTag string) (string, error) {
-	digest, err := docker.GetImageDigest(fmt.Sprintf("%s:%s", imageName, imageTag))
+func getSHA(ctx context.Context, imageName, imageTag string) (string, error) {
+	digest, err := docker.GetImageDigest(ctx, fmt.Sprintf("%s:%s", imageName, imageTag))
 	if err != nil {
 		return "", err
 	} else {
@@ -0,0 +1,192 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/firefly-cli/internal/constants"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+)
+
+const (
+	ManifestKindRelease = "release"
+	ManifestKindChannel = "channel"
+)
+
+// DefaultManifestCacheTTL is how long a cached manifest is used before it is fetched again
+var DefaultManifestCacheTTL = 24 * time.Hour
+
+// fetchManifest is replaced in tests, so that they do not depend on the network
+var fetchManifest = FetchManifest
+
+func ManifestCacheDir() string {
+	return filepath.Join(filepath.Dir(constants.StacksDir), "manifests", "cache")
+}
+
+// ManifestKindForName returns whether a name refers to a release channel or a release version
+func ManifestKindForName(name string) string {
+	for _, channel := range fftypes.FFEnumValues(types.ReleaseChannelSelection) {
+		if strings.EqualFold(name, fmt.Sprint(channel)) {
+			return ManifestKindChannel
+		}
+	}
+	return ManifestKindRelease
+}
+
+func cachedManifestPath(kind, name string) string {
+	safeName := strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+	return filepath.Join(ManifestCacheDir(), fmt.Sprintf("%s-%s.json", kind, safeName))
+}
+
+// FetchManifest fetches the manifest for a release or release channel from GitHub and the registry
+func FetchManifest(ctx context.Context, kind, name string) (*types.VersionManifest, error) {
+	if kind == ManifestKindChannel {
+		return GetManifestForChannel(ctx, fftypes.FFEnum(strings.ToLower(name)))
+	}
+	return GetManifestForRelease(ctx, name)
+}
+
+// ReadCachedManifest returns the cached manifest for a release or release channel, or nil if
+// it has never been fetched
+func ReadCachedManifest(kind, name string) (*types.CachedManifest, error) {
+	d, err := os.ReadFile(cachedManifestPath(kind, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var cached *types.CachedManifest
+	if err := json.Unmarshal(d, &cached); err != nil {
+		return nil, fmt.Errorf("failed to read cached manifest for %s '%s': %s", kind, name, err)
+	}
+	return cached, nil
+}
+
+func WriteCachedManifest(kind, name string, manifest *types.VersionManifest) (*types.CachedManifest, error) {
+	cached := &types.CachedManifest{
+		Kind:     kind,
+		Name:     name,
+		Fetched:  time.Now().UTC(),
+		Manifest: manifest,
+	}
+	d, err := json.MarshalIndent(cached, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(ManifestCacheDir(), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(cachedManifestPath(kind, name), d, 0644); err != nil {
+		return nil, err
+	}
+	return cached, nil
+}
+
+// ListCachedManifests returns every cached manifest, sorted by kind and name
+func ListCachedManifests() ([]*types.CachedManifest, error) {
+	files, err := os.ReadDir(ManifestCacheDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return []*types.CachedManifest{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cachedManifests := make([]*types.CachedManifest, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		d, err := os.ReadFile(filepath.Join(ManifestCacheDir(), f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var cached *types.CachedManifest
+		if err := json.Unmarshal(d, &cached); err != nil {
+			return nil, fmt.Errorf("failed to read cached manifest '%s': %s", f.Name(), err)
+		}
+		cachedManifests = append(cachedManifests, cached)
+	}
+	sort.Slice(cachedManifests, func(i, j int) bool {
+		if cachedManifests[i].Kind != cachedManifests[j].Kind {
+			return cachedManifests[i].Kind < cachedManifests[j].Kind
+		}
+		return cachedManifests[i].Name < cachedManifests[j].Name
+	})
+	return cachedManifests, nil
+}
+
+// RefreshCachedManifest fetches the manifest for a release or release channel, and replaces
+// the cached copy with it
+func RefreshCachedManifest(ctx context.Context, kind, name string) (*types.CachedManifest, error) {
+	manifest, err := fetchManifest(ctx, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	return WriteCachedManifest(kind, name, manifest)
+}
+
+// ResolveManifest returns the cached manifest for a release or release channel if it was
+// fetched within the TTL. Otherwise the manifest is fetched and cached, falling back to the
+// cached copy, however old, if it cannot be fetched.
+func ResolveManifest(ctx context.Context, kind, name string, ttl time.Duration) (*types.VersionManifest, error) {
+	l := log.LoggerFromContext(ctx)
+	cached, err := ReadCachedManifest(kind, name)
+	if err != nil {
+		l.Warn(err.Error())
+		cached = nil
+	}
+	if cached != nil && !cached.Expired(ttl) {
+		l.Info(fmt.Sprintf("using manifest for %s '%s' cached at %s", kind, name, cached.Fetched.Format(time.RFC3339)))
+		return cached.Manifest, nil
+	}
+
+	manifest, fetchErr := fetchManifest(ctx, kind, name)
+	if fetchErr != nil {
+		if cached == nil {
+			return nil, fetchErr
+		}
+		l.Warn(fmt.Sprintf("unable to fetch the manifest for %s '%s', using the copy cached at %s: %s", kind, name, cached.Fetched.Format(time.RFC3339), fetchErr))
+		return cached.Manifest, nil
+	}
+	if _, err := WriteCachedManifest(kind, name, manifest); err != nil {
+		l.Warn(fmt.Sprintf("unable to cache the manifest for %s '%s': %s", kind, name, err))
+	}
+	return manifest, nil
+}
+
+// ResolveCachedManifest returns the cached manifest for a release or release channel, however
+// old it is, without contacting the network
+func ResolveCachedManifest(kind, name string) (*types.VersionManifest, error) {
+	cached, err := ReadCachedManifest(kind, name)
+	if err != nil {
+		return nil, err
+	}
+	if cached == nil {
+		return nil, fmt.Errorf("there is no cached manifest for %s '%s'", kind, name)
+	}
+	return cached.Manifest, nil
+}
@@ -0,0 +1,131 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-cli/internal/constants"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupManifestCacheTest(t *testing.T, fetch func(ctx context.Context, kind, name string) (*types.VersionManifest, error)) context.Context {
+	stacksDir := constants.StacksDir
+	originalFetch := fetchManifest
+	t.Cleanup(func() {
+		constants.StacksDir = stacksDir
+		fetchManifest = originalFetch
+	})
+	constants.StacksDir = filepath.Join(t.TempDir(), "stacks")
+	fetchManifest = fetch
+	return log.WithLogger(context.Background(), &log.StdoutLogger{})
+}
+
+func testManifest(version string) *types.VersionManifest {
+	return &types.VersionManifest{
+		FireFly: &types.ManifestEntry{Image: "ghcr.io/hyperledger/firefly", Tag: version, SHA: "abcd"},
+	}
+}
+
+func TestManifestKindForName(t *testing.T) {
+	assert.Equal(t, ManifestKindChannel, ManifestKindForName("stable"))
+	assert.Equal(t, ManifestKindChannel, ManifestKindForName("RC"))
+	assert.Equal(t, ManifestKindRelease, ManifestKindForName("v1.3.0"))
+	assert.Equal(t, ManifestKindRelease, ManifestKindForName("main"))
+}
+
+func TestResolveManifestCachesFetchedManifest(t *testing.T) {
+	fetches := 0
+	ctx := setupManifestCacheTest(t, func(ctx context.Context, kind, name string) (*types.VersionManifest, error) {
+		fetches++
+		return testManifest(name), nil
+	})
+
+	manifest, err := ResolveManifest(ctx, ManifestKindRelease, "v1.3.0", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0", manifest.FireFly.Tag)
+	manifest, err = ResolveManifest(ctx, ManifestKindRelease, "v1.3.0", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0", manifest.FireFly.Tag)
+	assert.Equal(t, 1, fetches)
+
+	// An expired manifest is fetched again
+	_, err = ResolveManifest(ctx, ManifestKindRelease, "v1.3.0", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fetches)
+}
+
+func TestResolveManifestFallsBackToCache(t *testing.T) {
+	ctx := setupManifestCacheTest(t, func(ctx context.Context, kind, name string) (*types.VersionManifest, error) {
+		return nil, fmt.Errorf("network unavailable")
+	})
+
+	_, err := ResolveManifest(ctx, ManifestKindChannel, "stable", time.Hour)
+	assert.Regexp(t, "network unavailable", err)
+
+	_, err = WriteCachedManifest(ManifestKindChannel, "stable", testManifest("v1.3.0"))
+	assert.NoError(t, err)
+	manifest, err := ResolveManifest(ctx, ManifestKindChannel, "stable", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0", manifest.FireFly.Tag)
+}
+
+func TestResolveCachedManifest(t *testing.T) {
+	setupManifestCacheTest(t, nil)
+
+	_, err := ResolveCachedManifest(ManifestKindRelease, "v1.3.0")
+	assert.Regexp(t, "there is no cached manifest for release 'v1.3.0'", err)
+
+	_, err = WriteCachedManifest(ManifestKindRelease, "v1.3.0", testManifest("v1.3.0"))
+	assert.NoError(t, err)
+	manifest, err := ResolveCachedManifest(ManifestKindRelease, "v1.3.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0", manifest.FireFly.Tag)
+}
+
+func TestListAndRefreshCachedManifests(t *testing.T) {
+	setupManifestCacheTest(t, func(ctx context.Context, kind, name string) (*types.VersionManifest, error) {
+		return testManifest("v1.3.1"), nil
+	})
+
+	cachedManifests, err := ListCachedManifests()
+	assert.NoError(t, err)
+	assert.Empty(t, cachedManifests)
+
+	_, err = WriteCachedManifest(ManifestKindRelease, "v1.3.0", testManifest("v1.3.0"))
+	assert.NoError(t, err)
+	_, err = WriteCachedManifest(ManifestKindChannel, "stable", testManifest("v1.3.0"))
+	assert.NoError(t, err)
+	cached, err := RefreshCachedManifest(context.Background(), ManifestKindChannel, "stable")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.1", cached.Manifest.FireFly.Tag)
+
+	cachedManifests, err = ListCachedManifests()
+	assert.NoError(t, err)
+	assert.Len(t, cachedManifests, 2)
+	assert.Equal(t, "channel", cachedManifests[0].Kind)
+	assert.Equal(t, "stable", cachedManifests[0].Name)
+	assert.Equal(t, "v1.3.1", cachedManifests[0].Manifest.FireFly.Tag)
+	assert.Equal(t, "release", cachedManifests[1].Kind)
+	assert.Equal(t, "v1.3.0", cachedManifests[1].Name)
+}
@@ -22,17 +22,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
-	"path"
 	"strings"
+	"time"
 
-	"github.com/google/go-containerregistry/pkg/crane"
 	"github.com/hyperledger/firefly-cli/internal/log"
 )
 
 type (
 	CtxIsLogCmdKey       struct{}
+	CtxLogWriterKey      struct{}
 	CtxComposeVersionKey struct{}
+	CtxDockerManagerKey  struct{}
 	DockerComposeVersion int
 )
 
@@ -42,42 +44,165 @@ const (
 	ComposeV2
 )
 
+// The functions below run each container operation with the IDockerManager in the context,
+// which is the real container engine unless one has been set with WithDockerManager
+
 func CreateVolume(ctx context.Context, volumeName string) error {
-	return RunDockerCommand(ctx, ".", "volume", "create", volumeName)
+	return DockerManagerFromContext(ctx).CreateVolume(ctx, volumeName)
 }
 
 func CopyFileToVolume(ctx context.Context, volumeName string, sourcePath string, destPath string) error {
-	fileName := path.Base(sourcePath)
-	source := path.Join("/", "source", fileName)
-	dest := path.Join("/", "dest", destPath)
-	// command := fmt.Sprintf("run --rm -v %s:%s -v %s:%s alpine /bin/sh -c 'cp -R %s %s '", sourcePath, source, volumeName, dest, source, dest, dest, dest)
-	command := fmt.Sprintf("cp -R %s %s && chgrp -R 0 %s && chmod -R g+rwX %s", source, dest, dest, dest)
-	return RunDockerCommand(ctx, ".", "run", "--rm", "-v", fmt.Sprintf("%s:%s", sourcePath, source), "-v", fmt.Sprintf("%s:/dest", volumeName), "alpine", "/bin/sh", "-c", command)
+	return DockerManagerFromContext(ctx).CopyFileToVolume(ctx, volumeName, sourcePath, destPath)
 }
 
 func MkdirInVolume(ctx context.Context, volumeName string, directory string) error {
-	dest := path.Join("/", "dest", directory)
-	command := fmt.Sprintf("mkdir -p %s && chgrp -R 0 %s && chmod -R g+rwX %s", dest, dest, dest)
-	return RunDockerCommand(ctx, ".", "run", "--rm", "-v", fmt.Sprintf("%s:/dest", volumeName), "alpine", "/bin/sh", "-c", command)
+	return DockerManagerFromContext(ctx).MkdirInVolume(ctx, volumeName, directory)
 }
 
 func RemoveVolume(ctx context.Context, volumeName string) error {
-	return RunDockerCommand(ctx, ".", "volume", "remove", volumeName)
+	return DockerManagerFromContext(ctx).RemoveVolume(ctx, volumeName)
+}
+
+func VolumeExists(ctx context.Context, volumeName string) (bool, error) {
+	return DockerManagerFromContext(ctx).VolumeExists(ctx, volumeName)
+}
+
+// ExportVolume writes the full contents of a volume to a tar file in destDir
+func ExportVolume(ctx context.Context, volumeName string, destDir string, fileName string) error {
+	return DockerManagerFromContext(ctx).ExportVolume(ctx, volumeName, destDir, fileName)
+}
+
+// ImportVolume extracts a tar file previously written by ExportVolume into a volume
+func ImportVolume(ctx context.Context, volumeName string, sourcePath string) error {
+	return DockerManagerFromContext(ctx).ImportVolume(ctx, volumeName, sourcePath)
+}
+
+// SaveImages writes the given images, which must all be present locally, to a single tar file
+func SaveImages(ctx context.Context, filename string, images ...string) error {
+	return DockerManagerFromContext(ctx).SaveImages(ctx, filename, images...)
+}
+
+// LoadImages loads every image in a tar file written by SaveImages
+func LoadImages(ctx context.Context, filename string) error {
+	return DockerManagerFromContext(ctx).LoadImages(ctx, filename)
+}
+
+func TagImage(ctx context.Context, source string, target string) error {
+	return DockerManagerFromContext(ctx).TagImage(ctx, source, target)
+}
+
+// UntagImage removes a tag from an image, without removing an image that still has other tags
+func UntagImage(ctx context.Context, image string) error {
+	return DockerManagerFromContext(ctx).UntagImage(ctx, image)
 }
 
 func CopyFromContainer(ctx context.Context, containerName string, sourcePath string, destPath string) error {
-	if err := RunDockerCommand(ctx, ".", "cp", containerName+":"+sourcePath, destPath); err != nil {
-		return err
+	return DockerManagerFromContext(ctx).CopyFromContainer(ctx, containerName, sourcePath, destPath)
+}
+
+const (
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+	HealthStarting  = "starting"
+)
+
+// ContainerState is the state of a single container created by docker compose
+type ContainerState struct {
+	Service   string `json:"service"`
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	State     string `json:"state"`
+	Status    string `json:"status"`
+	Health    string `json:"health,omitempty"`
+}
+
+// GetContainerStates returns the state of every container, running or not, that docker compose
+// created for the compose project in workingDir
+func GetContainerStates(ctx context.Context, workingDir string) ([]*ContainerState, error) {
+	return DockerManagerFromContext(ctx).GetContainerStates(ctx, workingDir)
+}
+
+// composeContainer is a container in the JSON output of docker compose ps. Newer versions print
+// one object per line, while older versions print an array.
+type composeContainer struct {
+	Name    string `json:"Name"`
+	Service string `json:"Service"`
+	Image   string `json:"Image"`
+	State   string `json:"State"`
+	Status  string `json:"Status"`
+	Health  string `json:"Health"`
+}
+
+func parseContainerStates(output string) ([]*ContainerState, error) {
+	var containers []*composeContainer
+	if trimmed := strings.TrimSpace(output); strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal([]byte(trimmed), &containers); err != nil {
+			return nil, fmt.Errorf("failed to parse container state '%s': %s", trimmed, err)
+		}
+	} else {
+		for _, line := range strings.Split(trimmed, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			var c *composeContainer
+			if err := json.Unmarshal([]byte(line), &c); err != nil {
+				return nil, fmt.Errorf("failed to parse container state '%s': %s", line, err)
+			}
+			containers = append(containers, c)
+		}
+	}
+	states := make([]*ContainerState, 0, len(containers))
+	for _, c := range containers {
+		state := &ContainerState{
+			Service:   c.Service,
+			Container: c.Name,
+			Image:     c.Image,
+			State:     strings.ToLower(c.State),
+			Status:    c.Status,
+			Health:    c.Health,
+		}
+		if state.Health == "" {
+			state.Health = parseHealth(state.Status)
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// parseHealth extracts the result of the container's healthcheck from the status docker reports
+// for it, such as "Up 2 minutes (healthy)". It is empty if the container has no healthcheck.
+func parseHealth(status string) string {
+	switch {
+	case strings.Contains(status, "(healthy)"):
+		return HealthHealthy
+	case strings.Contains(status, "(unhealthy)"):
+		return HealthUnhealthy
+	case strings.Contains(status, "(health: starting)"):
+		return HealthStarting
+	default:
+		return ""
 	}
-	return nil
 }
 
+// RetryBaseDelay is how long RunDockerCommandRetry waits before its first retry. The delay
+// doubles after each failed attempt, up to RetryMaxDelay.
+var RetryBaseDelay = time.Second
+
+var RetryMaxDelay = 30 * time.Second
+
 func RunDockerCommandRetry(ctx context.Context, workingDir string, retries int, command ...string) error {
 	attempt := 0
 	for {
 		err := RunDockerCommand(ctx, workingDir, command...)
 		if err != nil && attempt < retries {
+			delay := retryDelay(attempt)
 			attempt++
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 			continue
 		} else if err != nil {
 			return err
@@ -87,62 +212,49 @@ func RunDockerCommandRetry(ctx context.Context, workingDir string, retries int,
 	return nil
 }
 
-func RunDockerCommand(ctx context.Context, workingDir string, command ...string) error {
-	//nolint:gosec
-	dockerCmd := exec.Command("docker", command...)
-	dockerCmd.Dir = workingDir
-	output, err := runCommand(ctx, dockerCmd)
-	if err != nil && output != "" {
-		return fmt.Errorf("%s", output)
+func retryDelay(attempt int) time.Duration {
+	delay := RetryBaseDelay
+	for i := 0; i < attempt && delay < RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > RetryMaxDelay {
+		delay = RetryMaxDelay
 	}
-	return err
+	return delay
+}
+
+func RunDockerCommand(ctx context.Context, workingDir string, command ...string) error {
+	return DockerManagerFromContext(ctx).RunDockerCommand(ctx, workingDir, command...)
 }
 
 func RunDockerCommandLine(ctx context.Context, workingDir string, command string) error {
-	parsedCommand := strings.Split(command, " ")
-	fmt.Println(parsedCommand)
-	dockerCmd := exec.Command("docker", parsedCommand...)
-	dockerCmd.Dir = workingDir
-	_, err := runCommand(ctx, dockerCmd)
-	return err
+	return DockerManagerFromContext(ctx).RunDockerCommandLine(ctx, workingDir, command)
 }
 
 func RunDockerComposeCommand(ctx context.Context, workingDir string, command ...string) error {
-	switch ctx.Value(CtxComposeVersionKey{}) {
-	case ComposeV1:
-		//nolint:gosec
-		dockerCmd := exec.Command("docker-compose", command...)
-		dockerCmd.Dir = workingDir
-		_, err := runCommand(ctx, dockerCmd)
-		return err
-	case ComposeV2:
-		//nolint:gosec
-		dockerCmd := exec.Command("docker", append([]string{"compose"}, command...)...)
-		dockerCmd.Dir = workingDir
-		_, err := runCommand(ctx, dockerCmd)
-		return err
-	default:
-		return fmt.Errorf("no version for docker-compose has been detected")
-	}
+	return DockerManagerFromContext(ctx).RunDockerComposeCommand(ctx, workingDir, command...)
 }
 
 func RunDockerCommandBuffered(ctx context.Context, workingDir string, command ...string) (string, error) {
-	//nolint:gosec
-	dockerCmd := exec.Command("docker", command...)
-	dockerCmd.Dir = workingDir
-	return runCommand(ctx, dockerCmd)
+	return DockerManagerFromContext(ctx).RunDockerCommandBuffered(ctx, workingDir, command...)
 }
 
-func RunDockerComposeCommandReturnsStdout(workingDir string, command ...string) ([]byte, error) {
-	//nolint:gosec
-	dockerCmd := exec.Command("docker", append([]string{"compose"}, command...)...)
-	dockerCmd.Dir = workingDir
-	return dockerCmd.Output()
+func RunDockerComposeCommandReturnsStdout(ctx context.Context, workingDir string, command ...string) ([]byte, error) {
+	return DockerManagerFromContext(ctx).RunDockerComposeCommandReturnsStdout(workingDir, command...)
 }
 
 func runCommand(ctx context.Context, cmd *exec.Cmd) (string, error) {
+	if err := ctx.Err(); err != nil {
+		// Nothing new is started once the command has been interrupted
+		return "", err
+	}
 	verbose := log.VerbosityFromContext(ctx)
 	isLogCmd, _ := ctx.Value(CtxIsLogCmdKey{}).(bool)
+	// The output of a log command can be sent somewhere other than stdout, for example to be filtered
+	var logOutput io.Writer = os.Stdout
+	if w, ok := ctx.Value(CtxLogWriterKey{}).(io.Writer); ok {
+		logOutput = w
+	}
 	if verbose {
 		fmt.Println(cmd.String())
 	}
@@ -160,7 +272,7 @@ outputCapture:
 				if !ok {
 					break outputCapture
 				}
-				fmt.Print(s)
+				fmt.Fprint(logOutput, s)
 			}
 			outputBuff.WriteString(s)
 		case s, ok := <-stderrChan:
@@ -176,6 +288,10 @@ outputCapture:
 		}
 	}
 	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			// The command was killed because it was interrupted
+			return outputBuff.String(), ctx.Err()
+		}
 		return outputBuff.String(), err
 	}
 	statusCode := cmd.ProcessState.ExitCode()
@@ -222,39 +338,20 @@ func readPipe(pipe io.ReadCloser, outputChan chan string, errChan chan error) {
 	}
 }
 
-func GetImageConfig(image string) (map[string]interface{}, error) {
-	b, err := crane.Config(image)
-	if err != nil {
-		return nil, err
-	}
-	var jsonMap map[string]interface{}
-	err = json.Unmarshal(b, &jsonMap)
-	if err != nil {
-		return nil, err
-	}
-	return jsonMap, nil
+func GetImageConfig(ctx context.Context, image string) (map[string]interface{}, error) {
+	return DockerManagerFromContext(ctx).GetImageConfig(image)
 }
 
-func GetImageLabel(image, label string) (string, error) {
-	config, err := GetImageConfig(image)
-	if err != nil {
-		return "", err
-	}
-	c, ok := config["config"]
-	if !ok {
-		return "", nil
-	}
-	labels, ok := c.(map[string]interface{})["Labels"]
-	if !ok {
-		return "", nil
-	}
-	val, ok := labels.(map[string]interface{})[label]
-	if !ok {
-		return "", nil
-	}
-	return val.(string), nil
+func GetImageLabel(ctx context.Context, image, label string) (string, error) {
+	return DockerManagerFromContext(ctx).GetImageLabel(image, label)
+}
+
+func GetImageDigest(ctx context.Context, image string) (string, error) {
+	return DockerManagerFromContext(ctx).GetImageDigest(image)
 }
 
-func GetImageDigest(image string) (string, error) {
-	return crane.Digest(image)
+// GetLocalImageDigests returns the registry digests of an image that has already been pulled,
+// and false if the image is not present locally
+func GetLocalImageDigests(ctx context.Context, image string) ([]string, bool) {
+	return DockerManagerFromContext(ctx).GetLocalImageDigests(ctx, image)
 }
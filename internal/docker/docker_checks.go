@@ -17,37 +17,47 @@
 package docker
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 )
 
 func CheckDockerConfig() (DockerComposeVersion, error) {
 
-	dockerCmd := exec.Command("docker", "-v")
+	dockerCmd := engineCommand(context.Background(), "-v")
 	_, err := dockerCmd.Output()
 	if err != nil {
-		return None, fmt.Errorf("an error occurred while running docker. Is docker installed on your computer?")
+		return None, fmt.Errorf("an error occurred while running %s. Is %s installed on your computer?", Engine, Engine)
 	}
 
-	dockerDeamonCheck := exec.Command("docker", "ps")
+	dockerDeamonCheck := engineCommand(context.Background(), "ps")
 	_, err = dockerDeamonCheck.Output()
 	if err != nil {
-		return None, fmt.Errorf("an error occurred while running docker. Is docker running on your computer?")
+		return None, fmt.Errorf("an error occurred while running %s. Is %s running on your computer?", Engine, Engine)
 	}
 
-	// check for docker-compose (V2) version
-	dockerComposeCmd := exec.Command("docker", "compose", "version")
+	// check for the compose command of the engine, such as docker compose (V2)
+	dockerComposeCmd := engineCommand(context.Background(), "compose", "version")
 	_, err = dockerComposeCmd.Output()
 	if err == nil {
 		return ComposeV2, nil
 	}
 
-	// check for docker-compose (v1) version
-	dockerComposeCmd = exec.Command("docker-compose", "-v")
-	_, err = dockerComposeCmd.Output()
-	if err == nil {
-		return ComposeV1, nil
+	// check for a standalone compose binary, such as docker-compose (v1) or podman-compose
+	if composeCommand := Engine.ComposeCommand(); composeCommand != "" {
+		dockerComposeCmd = exec.Command(composeCommand, "-v")
+		_, err = dockerComposeCmd.Output()
+		if err == nil {
+			return ComposeV1, nil
+		}
 	}
 
-	return None, fmt.Errorf("an error occurred while running docker-compose. Is docker-compose installed on your computer?")
+	return None, fmt.Errorf("an error occurred while running %s. Is %s installed on your computer?", composeName(), composeName())
+}
+
+func composeName() string {
+	if composeCommand := Engine.ComposeCommand(); composeCommand != "" {
+		return composeCommand
+	}
+	return fmt.Sprintf("%s compose", Engine)
 }
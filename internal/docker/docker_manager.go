@@ -18,6 +18,14 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/hyperledger/firefly-cli/internal/constants"
 )
 
 // DockerInterface combines all Docker-related operations into a single interface.
@@ -33,15 +41,42 @@ type IDockerManager interface {
 	GetImageConfig(image string) (map[string]interface{}, error)
 	GetImageLabel(image, label string) (string, error)
 	GetImageDigest(image string) (string, error)
+	GetLocalImageDigests(ctx context.Context, image string) ([]string, bool)
+
+	// Image Management
+	SaveImages(ctx context.Context, filename string, images ...string) error
+	LoadImages(ctx context.Context, filename string) error
+	TagImage(ctx context.Context, source string, target string) error
+	UntagImage(ctx context.Context, image string) error
 
 	// Volume Management
 	CreateVolume(ctx context.Context, volumeName string) error
 	CopyFileToVolume(ctx context.Context, volumeName string, sourcePath string, destPath string) error
 	MkdirInVolume(ctx context.Context, volumeName string, directory string) error
 	RemoveVolume(ctx context.Context, volumeName string) error
+	VolumeExists(ctx context.Context, volumeName string) (bool, error)
+	ExportVolume(ctx context.Context, volumeName string, destDir string, fileName string) error
+	ImportVolume(ctx context.Context, volumeName string, sourcePath string) error
 
 	// Container Interaction
 	CopyFromContainer(ctx context.Context, containerName string, sourcePath string, destPath string) error
+	GetContainerStates(ctx context.Context, workingDir string) ([]*ContainerState, error)
+}
+
+// WithDockerManager returns a context in which every container operation is run by mgr
+func WithDockerManager(ctx context.Context, mgr IDockerManager) context.Context {
+	return context.WithValue(ctx, CtxDockerManagerKey{}, mgr)
+}
+
+// DockerManagerFromContext returns the IDockerManager set with WithDockerManager, or one that
+// runs the selected container engine if none has been set
+func DockerManagerFromContext(ctx context.Context) IDockerManager {
+	if ctx != nil {
+		if mgr, ok := ctx.Value(CtxDockerManagerKey{}).(IDockerManager); ok && mgr != nil {
+			return mgr
+		}
+	}
+	return NewDockerManager()
 }
 
 // DockerManager implements IDockerManager
@@ -52,53 +87,186 @@ func NewDockerManager() *DockerManager {
 }
 
 func (mgr *DockerManager) RunDockerCommand(ctx context.Context, workingDir string, command ...string) error {
-	return RunDockerCommand(ctx, workingDir, command...)
+	dockerCmd := engineCommand(ctx, command...)
+	dockerCmd.Dir = workingDir
+	output, err := runCommand(ctx, dockerCmd)
+	if err != nil && output != "" {
+		return fmt.Errorf("%s", output)
+	}
+	return err
 }
 
 func (mgr *DockerManager) RunDockerCommandLine(ctx context.Context, workingDir string, command string) error {
-	return RunDockerCommandLine(ctx, workingDir, command)
+	parsedCommand := strings.Split(command, " ")
+	fmt.Println(parsedCommand)
+	dockerCmd := engineCommand(ctx, parsedCommand...)
+	dockerCmd.Dir = workingDir
+	_, err := runCommand(ctx, dockerCmd)
+	return err
 }
 
 func (mgr *DockerManager) RunDockerComposeCommand(ctx context.Context, workingDir string, command ...string) error {
-	return RunDockerComposeCommand(ctx, workingDir, command...)
+	dockerCmd, err := composeCommand(ctx, workingDir, command...)
+	if err != nil {
+		return err
+	}
+	_, err = runCommand(ctx, dockerCmd)
+	return err
+}
+
+// composeCommand returns a command that runs the compose version that was detected
+func composeCommand(ctx context.Context, workingDir string, command ...string) (*exec.Cmd, error) {
+	var dockerCmd *exec.Cmd
+	switch ctx.Value(CtxComposeVersionKey{}) {
+	case ComposeV1:
+		//nolint:gosec
+		dockerCmd = exec.CommandContext(ctx, Engine.ComposeCommand(), command...)
+	case ComposeV2:
+		dockerCmd = engineCommand(ctx, append([]string{"compose"}, command...)...)
+	default:
+		return nil, fmt.Errorf("no version for docker-compose has been detected")
+	}
+	dockerCmd.Dir = workingDir
+	return dockerCmd, nil
 }
 
 func (mgr *DockerManager) RunDockerCommandBuffered(ctx context.Context, workingDir string, command ...string) (string, error) {
-	return RunDockerCommandBuffered(ctx, workingDir, command...)
+	dockerCmd := engineCommand(ctx, command...)
+	dockerCmd.Dir = workingDir
+	return runCommand(ctx, dockerCmd)
 }
 
 func (mgr *DockerManager) RunDockerComposeCommandReturnsStdout(workingDir string, command ...string) ([]byte, error) {
-	return RunDockerComposeCommandReturnsStdout(workingDir, command...)
+	dockerCmd := engineCommand(context.Background(), append([]string{"compose"}, command...)...)
+	dockerCmd.Dir = workingDir
+	return dockerCmd.Output()
 }
 
 func (mgr *DockerManager) GetImageConfig(image string) (map[string]interface{}, error) {
-	return GetImageConfig(image)
+	b, err := crane.Config(image)
+	if err != nil {
+		return nil, err
+	}
+	var jsonMap map[string]interface{}
+	err = json.Unmarshal(b, &jsonMap)
+	if err != nil {
+		return nil, err
+	}
+	return jsonMap, nil
 }
 
 func (mgr *DockerManager) GetImageLabel(image, label string) (string, error) {
-	return GetImageLabel(image, label)
+	config, err := mgr.GetImageConfig(image)
+	if err != nil {
+		return "", err
+	}
+	c, ok := config["config"]
+	if !ok {
+		return "", nil
+	}
+	labels, ok := c.(map[string]interface{})["Labels"]
+	if !ok {
+		return "", nil
+	}
+	val, ok := labels.(map[string]interface{})[label]
+	if !ok {
+		return "", nil
+	}
+	return val.(string), nil
 }
 
 func (mgr *DockerManager) GetImageDigest(image string) (string, error) {
-	return GetImageDigest(image)
+	return crane.Digest(image)
+}
+
+func (mgr *DockerManager) GetLocalImageDigests(ctx context.Context, image string) ([]string, bool) {
+	//nolint:gosec
+	output, err := exec.CommandContext(ctx, string(Engine), "image", "inspect", "--format", "{{json .RepoDigests}}", image).Output()
+	if err != nil {
+		return nil, false
+	}
+	var digests []string
+	if err := json.Unmarshal(output, &digests); err != nil {
+		return nil, false
+	}
+	return digests, true
+}
+
+func (mgr *DockerManager) SaveImages(ctx context.Context, filename string, images ...string) error {
+	return mgr.RunDockerCommand(ctx, ".", append([]string{"save", "-o", filename}, images...)...)
+}
+
+func (mgr *DockerManager) LoadImages(ctx context.Context, filename string) error {
+	return mgr.RunDockerCommand(ctx, ".", "load", "-i", filename)
+}
+
+func (mgr *DockerManager) TagImage(ctx context.Context, source string, target string) error {
+	return mgr.RunDockerCommand(ctx, ".", "tag", source, target)
+}
+
+func (mgr *DockerManager) UntagImage(ctx context.Context, image string) error {
+	return mgr.RunDockerCommand(ctx, ".", untagImageArgs(image)...)
 }
 
 func (mgr *DockerManager) CreateVolume(ctx context.Context, volumeName string) error {
-	return CreateVolume(ctx, volumeName)
+	return mgr.RunDockerCommand(ctx, ".", "volume", "create", volumeName)
 }
 
 func (mgr *DockerManager) CopyFileToVolume(ctx context.Context, volumeName string, sourcePath string, destPath string) error {
-	return CopyFileToVolume(ctx, volumeName, sourcePath, destPath)
+	fileName := path.Base(sourcePath)
+	source := path.Join("/", "source", fileName)
+	dest := path.Join("/", "dest", destPath)
+	command := fmt.Sprintf("cp -R %s %s && chgrp -R 0 %s && chmod -R g+rwX %s", source, dest, dest, dest)
+	return mgr.RunDockerCommand(ctx, ".", append(helperRunArgs(), "-v", fmt.Sprintf("%s:%s", sourcePath, source), "-v", fmt.Sprintf("%s:/dest", volumeName), constants.AlpineImageName, "/bin/sh", "-c", command)...)
 }
 
 func (mgr *DockerManager) MkdirInVolume(ctx context.Context, volumeName string, directory string) error {
-	return MkdirInVolume(ctx, volumeName, directory)
+	dest := path.Join("/", "dest", directory)
+	command := fmt.Sprintf("mkdir -p %s && chgrp -R 0 %s && chmod -R g+rwX %s", dest, dest, dest)
+	return mgr.RunDockerCommand(ctx, ".", append(helperRunArgs(), "-v", fmt.Sprintf("%s:/dest", volumeName), constants.AlpineImageName, "/bin/sh", "-c", command)...)
 }
 
 func (mgr *DockerManager) RemoveVolume(ctx context.Context, volumeName string) error {
-	return RemoveVolume(ctx, volumeName)
+	return mgr.RunDockerCommand(ctx, ".", "volume", "remove", volumeName)
+}
+
+func (mgr *DockerManager) VolumeExists(ctx context.Context, volumeName string) (bool, error) {
+	if _, err := mgr.RunDockerCommandBuffered(ctx, ".", "volume", "inspect", volumeName); err != nil {
+		if IsNoSuchVolumeError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (mgr *DockerManager) ExportVolume(ctx context.Context, volumeName string, destDir string, fileName string) error {
+	dest := path.Join("/", "backup", fileName)
+	return mgr.RunDockerCommand(ctx, ".", append(helperRunArgs(), "-v", fmt.Sprintf("%s:/source:ro", volumeName), "-v", fmt.Sprintf("%s:/backup", destDir), constants.AlpineImageName, "tar", "-cf", dest, "-C", "/source", ".")...)
+}
+
+func (mgr *DockerManager) ImportVolume(ctx context.Context, volumeName string, sourcePath string) error {
+	source := path.Join("/", "backup", path.Base(sourcePath))
+	return mgr.RunDockerCommand(ctx, ".", append(helperRunArgs(), "-v", fmt.Sprintf("%s:%s:ro", sourcePath, source), "-v", fmt.Sprintf("%s:/dest", volumeName), constants.AlpineImageName, "tar", "-xf", source, "-C", "/dest")...)
 }
 
 func (mgr *DockerManager) CopyFromContainer(ctx context.Context, containerName string, sourcePath string, destPath string) error {
-	return CopyFromContainer(ctx, containerName, sourcePath, destPath)
+	return mgr.RunDockerCommand(ctx, ".", "cp", containerName+":"+sourcePath, destPath)
+}
+
+func (mgr *DockerManager) GetContainerStates(ctx context.Context, workingDir string) ([]*ContainerState, error) {
+	if Engine != EngineDocker {
+		return getInspectedContainerStates(ctx, workingDir)
+	}
+	dockerCmd, err := composeCommand(ctx, workingDir, "ps", "--all", "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	// The output is not passed through runCommand, as it must not be echoed to stdout when
+	// it is being used to build machine readable output
+	output, err := dockerCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", dockerCmd.String(), err)
+	}
+	return parseContainerStates(string(output))
 }
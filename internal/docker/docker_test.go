@@ -0,0 +1,61 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseContainerStates(t *testing.T) {
+	output := `{"Name":"dev_firefly_core_0","Service":"firefly_core_0","Image":"ghcr.io/hyperledger/firefly:v1.3.0","State":"running","Status":"Up 2 minutes (healthy)","Health":"healthy"}
+{"Name":"dev_postgres_0","Service":"postgres_0","Image":"postgres","State":"exited","Status":"Exited (0) 5 seconds ago","Health":""}
+`
+	states, err := parseContainerStates(output)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ContainerState{
+		{Service: "firefly_core_0", Container: "dev_firefly_core_0", Image: "ghcr.io/hyperledger/firefly:v1.3.0", State: "running", Status: "Up 2 minutes (healthy)", Health: HealthHealthy},
+		{Service: "postgres_0", Container: "dev_postgres_0", Image: "postgres", State: "exited", Status: "Exited (0) 5 seconds ago"},
+	}, states)
+}
+
+func TestParseContainerStatesArray(t *testing.T) {
+	output := `[{"Name":"dev_firefly_core_0","Service":"firefly_core_0","Image":"ghcr.io/hyperledger/firefly:v1.3.0","State":"running","Status":"Up 2 minutes"},
+{"Name":"dev_ipfs_0","Service":"ipfs_0","Labels":"com.docker.compose.service=ipfs_0","Image":"ipfs/go-ipfs:v0.10.0","State":"running","Status":"Up 3 seconds (health: starting)"}]`
+	states, err := parseContainerStates(output)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ContainerState{
+		{Service: "firefly_core_0", Container: "dev_firefly_core_0", Image: "ghcr.io/hyperledger/firefly:v1.3.0", State: "running", Status: "Up 2 minutes"},
+		{Service: "ipfs_0", Container: "dev_ipfs_0", Image: "ipfs/go-ipfs:v0.10.0", State: "running", Status: "Up 3 seconds (health: starting)", Health: HealthStarting},
+	}, states)
+
+	_, err = parseContainerStates("[not json")
+	assert.Regexp(t, "failed to parse container state", err)
+}
+
+func TestParseContainerStatesEmpty(t *testing.T) {
+	states, err := parseContainerStates("\n")
+	assert.NoError(t, err)
+	assert.Empty(t, states)
+}
+
+func TestParseContainerStatesInvalid(t *testing.T) {
+	_, err := parseContainerStates("not json")
+	assert.Regexp(t, "failed to parse container state", err)
+}
+
+func TestParseHealth(t *testing.T) {
+	assert.Equal(t, HealthHealthy, parseHealth("Up 2 minutes (healthy)"))
+	assert.Equal(t, HealthUnhealthy, parseHealth("Up 2 minutes (unhealthy)"))
+	assert.Equal(t, HealthStarting, parseHealth("Up 3 seconds (health: starting)"))
+	assert.Equal(t, "", parseHealth("Up 2 minutes"))
+	assert.Equal(t, "", parseHealth("Exited (1) 2 minutes ago"))
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Second, retryDelay(0))
+	assert.Equal(t, 2*time.Second, retryDelay(1))
+	assert.Equal(t, 8*time.Second, retryDelay(3))
+	assert.Equal(t, 30*time.Second, retryDelay(5))
+	assert.Equal(t, 30*time.Second, retryDelay(100))
+}
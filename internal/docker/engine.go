@@ -0,0 +1,210 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ContainerEngine is the CLI used to run containers. Podman and nerdctl accept the same
+// commands as docker for everything the CLI does, apart from the differences handled here.
+type ContainerEngine string
+
+const (
+	EngineDocker  ContainerEngine = "docker"
+	EnginePodman  ContainerEngine = "podman"
+	EngineNerdctl ContainerEngine = "nerdctl"
+)
+
+// EngineEnvVar selects the container engine, and takes precedence over the config file
+const EngineEnvVar = "FIREFLY_CONTAINER_ENGINE"
+
+var ContainerEngines = []ContainerEngine{EngineDocker, EnginePodman, EngineNerdctl}
+
+// Engine is the container engine that every command in this package runs
+var Engine = EngineDocker
+
+// SetEngine selects the container engine by name. An empty name selects docker.
+func SetEngine(name string) error {
+	if name == "" {
+		Engine = EngineDocker
+		return nil
+	}
+	for _, engine := range ContainerEngines {
+		if strings.EqualFold(name, string(engine)) {
+			Engine = engine
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown container engine '%s'. Options are: %v", name, ContainerEngines)
+}
+
+// engineCommand returns a command that runs the selected container engine, which is killed
+// if ctx is canceled before it completes
+func engineCommand(ctx context.Context, args ...string) *exec.Cmd {
+	//nolint:gosec
+	return exec.CommandContext(ctx, string(Engine), args...)
+}
+
+// ComposeCommand returns the standalone compose binary of the engine, such as docker-compose
+// or podman-compose, which is used when the engine has no compose subcommand
+func (e ContainerEngine) ComposeCommand() string {
+	if e == EngineNerdctl {
+		// nerdctl only has a built in compose command
+		return ""
+	}
+	return string(e) + "-compose"
+}
+
+// helperRunArgs returns the arguments that start a short lived container used to read and
+// write the contents of volumes. The helper runs as root in the container, which a rootless
+// engine maps to the user running the CLI, so the files it writes to bind mounts are owned by
+// that user. Files it writes to volumes are given to group 0, so that images which run as
+// a non-root user can still use them whatever uid the engine maps that user to.
+//
+// No other user mapping is done. In particular --userns=keep-id is not passed to rootless
+// podman: it would run the helper as the uid of the user instead of root, which cannot read
+// the files that other services, such as postgres, write to their volumes with mode 0700, or
+// give files to group 0. Rootless nerdctl has no equivalent option. With a rootful engine the
+// files the helper writes to bind mounts, such as the archives of a snapshot, belong to root.
+func helperRunArgs() []string {
+	args := []string{"run", "--rm"}
+	if Engine == EnginePodman {
+		// On hosts with SELinux enforcing, podman does not allow a container to read a bind mounted
+		// file unless it is relabelled, which would change the labels of files in the stack directory
+		args = append(args, "--security-opt", "label=disable")
+	}
+	return args
+}
+
+// untagImageArgs returns the arguments that remove a tag from an image, without removing an
+// image that still has other tags
+func untagImageArgs(image string) []string {
+	switch Engine {
+	case EnginePodman:
+		return []string{"untag", image}
+	case EngineNerdctl:
+		// nerdctl only removes the tag, and not the image, when the image has other tags
+		return []string{"rmi", image}
+	default:
+		return []string{"rmi", "--no-prune", image}
+	}
+}
+
+// IsNoSuchVolumeError returns true if the error from inspecting or removing a volume is because
+// the volume does not exist, which each engine reports differently
+func IsNoSuchVolumeError(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "no such volume") || strings.Contains(message, "not found")
+}
+
+var composeProjectNameRegex = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// composeProjectName returns the project name that compose gives to the compose file in
+// workingDir, which is the name of the directory in the form compose requires
+func composeProjectName(workingDir string) string {
+	return composeProjectNameRegex.ReplaceAllString(strings.ToLower(filepath.Base(workingDir)), "")
+}
+
+// inspectedContainer is the part of the output of inspect that is the same for every engine
+type inspectedContainer struct {
+	Name   string `json:"Name"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	State struct {
+		Status   string `json:"Status"`
+		ExitCode int    `json:"ExitCode"`
+		Health   *struct {
+			Status string `json:"Status"`
+		} `json:"Health,omitempty"`
+		Healthcheck *struct {
+			Status string `json:"Status"`
+		} `json:"Healthcheck,omitempty"`
+	} `json:"State"`
+}
+
+// getInspectedContainerStates finds the containers of a compose project with inspect, as the
+// compose commands of podman and nerdctl do not print the JSON that docker compose ps does
+func getInspectedContainerStates(ctx context.Context, workingDir string) ([]*ContainerState, error) {
+	//nolint:gosec
+	psCmd := exec.CommandContext(ctx, string(Engine), "ps", "--all", "--quiet", "--filter", "label=com.docker.compose.project="+composeProjectName(workingDir))
+	output, err := psCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", psCmd.String(), err)
+	}
+	ids := strings.Fields(string(output))
+	if len(ids) == 0 {
+		return []*ContainerState{}, nil
+	}
+	//nolint:gosec
+	inspectCmd := exec.CommandContext(ctx, string(Engine), append([]string{"inspect"}, ids...)...)
+	output, err = inspectCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", inspectCmd.String(), err)
+	}
+	return parseInspectedContainerStates(output)
+}
+
+func parseInspectedContainerStates(output []byte) ([]*ContainerState, error) {
+	var containers []*inspectedContainer
+	if err := json.Unmarshal(output, &containers); err != nil {
+		return nil, fmt.Errorf("failed to parse container state: %s", err)
+	}
+	states := make([]*ContainerState, 0, len(containers))
+	for _, c := range containers {
+		state := &ContainerState{
+			Service:   c.Config.Labels["com.docker.compose.service"],
+			Container: strings.TrimPrefix(c.Name, "/"),
+			Image:     c.Config.Image,
+			State:     strings.ToLower(c.State.Status),
+		}
+		switch {
+		case c.State.Health != nil:
+			state.Health = c.State.Health.Status
+		case c.State.Healthcheck != nil:
+			state.Health = c.State.Healthcheck.Status
+		}
+		// Build a status in the same form as docker ps, as that is what is shown to the user
+		switch state.State {
+		case "running":
+			state.Status = "Up"
+		case "exited":
+			state.Status = fmt.Sprintf("Exited (%d)", c.State.ExitCode)
+		default:
+			if state.State != "" {
+				state.Status = strings.ToUpper(state.State[:1]) + state.State[1:]
+			}
+		}
+		if state.Health != "" && state.State == "running" {
+			if state.Health == HealthStarting {
+				state.Status += " (health: starting)"
+			} else {
+				state.Status += fmt.Sprintf(" (%s)", state.Health)
+			}
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
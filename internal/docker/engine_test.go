@@ -0,0 +1,77 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setTestEngine(t *testing.T, engine ContainerEngine) {
+	previous := Engine
+	Engine = engine
+	t.Cleanup(func() { Engine = previous })
+}
+
+func TestSetEngine(t *testing.T) {
+	setTestEngine(t, EngineDocker)
+	assert.NoError(t, SetEngine("Podman"))
+	assert.Equal(t, EnginePodman, Engine)
+	assert.NoError(t, SetEngine(""))
+	assert.Equal(t, EngineDocker, Engine)
+	assert.Regexp(t, "unknown container engine 'rkt'", SetEngine("rkt"))
+}
+
+func TestComposeCommand(t *testing.T) {
+	assert.Equal(t, "docker-compose", EngineDocker.ComposeCommand())
+	assert.Equal(t, "podman-compose", EnginePodman.ComposeCommand())
+	assert.Equal(t, "", EngineNerdctl.ComposeCommand())
+}
+
+func TestEngineArgs(t *testing.T) {
+	setTestEngine(t, EngineDocker)
+	assert.Equal(t, []string{"run", "--rm"}, helperRunArgs())
+	assert.Equal(t, []string{"rmi", "--no-prune", "dev/firefly:latest"}, untagImageArgs("dev/firefly:latest"))
+	assert.Equal(t, "docker", engineCommand(context.Background(), "ps").Args[0])
+
+	setTestEngine(t, EnginePodman)
+	assert.Equal(t, []string{"run", "--rm", "--security-opt", "label=disable"}, helperRunArgs())
+	assert.Equal(t, []string{"untag", "dev/firefly:latest"}, untagImageArgs("dev/firefly:latest"))
+	assert.Equal(t, "podman", engineCommand(context.Background(), "ps").Args[0])
+
+	setTestEngine(t, EngineNerdctl)
+	assert.Equal(t, []string{"run", "--rm"}, helperRunArgs())
+	assert.Equal(t, []string{"rmi", "dev/firefly:latest"}, untagImageArgs("dev/firefly:latest"))
+}
+
+func TestIsNoSuchVolumeError(t *testing.T) {
+	assert.True(t, IsNoSuchVolumeError(fmt.Errorf("Error: No such volume: dev_geth")))
+	assert.True(t, IsNoSuchVolumeError(fmt.Errorf(`time="2024-01-01" level=fatal msg="volume \"dev_geth\" not found"`)))
+	assert.False(t, IsNoSuchVolumeError(fmt.Errorf("permission denied")))
+}
+
+func TestComposeProjectName(t *testing.T) {
+	assert.Equal(t, "my_stack-1", composeProjectName("/home/user/.firefly/stacks/My_Stack-1"))
+	assert.Equal(t, "devv2", composeProjectName("/stacks/dev.v2"))
+}
+
+func TestParseInspectedContainerStates(t *testing.T) {
+	output := `[
+  {"Name":"dev_firefly_core_0","Config":{"Image":"ghcr.io/hyperledger/firefly:v1.3.0","Labels":{"com.docker.compose.service":"firefly_core_0"}},"State":{"Status":"running","Health":{"Status":"healthy"}}},
+  {"Name":"/dev_postgres_0","Config":{"Image":"postgres","Labels":{"com.docker.compose.service":"postgres_0"}},"State":{"Status":"exited","ExitCode":1}},
+  {"Name":"dev_ipfs_0","Config":{"Image":"ipfs/go-ipfs:v0.10.0","Labels":{"com.docker.compose.service":"ipfs_0"}},"State":{"Status":"running","Healthcheck":{"Status":"starting"}}},
+  {"Name":"dev_sandbox_0","Config":{"Image":"sandbox","Labels":{"com.docker.compose.service":"sandbox_0"}},"State":{"Status":"created"}}
+]`
+	states, err := parseInspectedContainerStates([]byte(output))
+	assert.NoError(t, err)
+	assert.Equal(t, []*ContainerState{
+		{Service: "firefly_core_0", Container: "dev_firefly_core_0", Image: "ghcr.io/hyperledger/firefly:v1.3.0", State: "running", Status: "Up (healthy)", Health: HealthHealthy},
+		{Service: "postgres_0", Container: "dev_postgres_0", Image: "postgres", State: "exited", Status: "Exited (1)"},
+		{Service: "ipfs_0", Container: "dev_ipfs_0", Image: "ipfs/go-ipfs:v0.10.0", State: "running", Status: "Up (health: starting)", Health: HealthStarting},
+		{Service: "sandbox_0", Container: "dev_sandbox_0", Image: "sandbox", State: "created", Status: "Created"},
+	}, states)
+
+	_, err = parseInspectedContainerStates([]byte("not json"))
+	assert.Regexp(t, "failed to parse container state", err)
+}
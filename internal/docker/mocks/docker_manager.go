@@ -1,7 +1,11 @@
 // DockerManager is a mock that implements IDockerManager
 package mocks
 
-import "context"
+import (
+	"context"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+)
 
 type DockerManager struct{}
 
@@ -41,6 +45,26 @@ func (mgr *DockerManager) GetImageDigest(image string) (string, error) {
 	return "", nil
 }
 
+func (mgr *DockerManager) GetLocalImageDigests(ctx context.Context, image string) ([]string, bool) {
+	return nil, false
+}
+
+func (mgr *DockerManager) SaveImages(ctx context.Context, filename string, images ...string) error {
+	return nil
+}
+
+func (mgr *DockerManager) LoadImages(ctx context.Context, filename string) error {
+	return nil
+}
+
+func (mgr *DockerManager) TagImage(ctx context.Context, source string, target string) error {
+	return nil
+}
+
+func (mgr *DockerManager) UntagImage(ctx context.Context, image string) error {
+	return nil
+}
+
 func (mgr *DockerManager) CreateVolume(ctx context.Context, volumeName string) error {
 	return nil
 }
@@ -57,6 +81,22 @@ func (mgr *DockerManager) RemoveVolume(ctx context.Context, volumeName string) e
 	return nil
 }
 
+func (mgr *DockerManager) VolumeExists(ctx context.Context, volumeName string) (bool, error) {
+	return true, nil
+}
+
+func (mgr *DockerManager) ExportVolume(ctx context.Context, volumeName string, destDir string, fileName string) error {
+	return nil
+}
+
+func (mgr *DockerManager) ImportVolume(ctx context.Context, volumeName string, sourcePath string) error {
+	return nil
+}
+
 func (mgr *DockerManager) CopyFromContainer(ctx context.Context, containerName string, sourcePath string, destPath string) error {
 	return nil
 }
+
+func (mgr *DockerManager) GetContainerStates(ctx context.Context, workingDir string) ([]*docker.ContainerState, error) {
+	return []*docker.ContainerState{}, nil
+}
@@ -0,0 +1,504 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocks
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"gopkg.in/yaml.v3"
+)
+
+// FakeVolume is the contents of a volume in a FakeDockerManager
+type FakeVolume struct {
+	// Files holds the contents of each file, by its path in the volume
+	Files map[string][]byte
+	// Dirs holds every directory that has been created in the volume
+	Dirs map[string]bool
+}
+
+// FakeDockerManager is an in-memory IDockerManager, which keeps track of the volumes, images
+// and containers that the real engine would have. Each compose project is read from the
+// docker-compose.yml in its working directory, so that its containers can be started and
+// stopped. Nothing is run, so a test that needs a command to have a result, such as a file
+// it writes, can produce it with OnCommand.
+type FakeDockerManager struct {
+	mu sync.Mutex
+	// Volumes holds every volume that exists, by name
+	Volumes map[string]*FakeVolume
+	// Images holds every image that has been pulled, loaded or tagged
+	Images map[string]bool
+	// Containers holds the containers of each compose project, by working directory and then service
+	Containers map[string]map[string]*docker.ContainerState
+	// Commands records every docker command, and ComposeCommands every compose command, in the order they were run
+	Commands        [][]string
+	ComposeCommands [][]string
+	// OnCommand, if set, is called before every command is run. Compose commands start with
+	// "compose". If it returns an error the command fails, and otherwise the output is returned
+	// from RunDockerCommandBuffered.
+	OnCommand func(workingDir string, command []string) (string, error)
+}
+
+var _ docker.IDockerManager = &FakeDockerManager{}
+
+func NewFakeDockerManager() *FakeDockerManager {
+	return &FakeDockerManager{
+		Volumes:    map[string]*FakeVolume{},
+		Images:     map[string]bool{},
+		Containers: map[string]map[string]*docker.ContainerState{},
+	}
+}
+
+// Volume returns the volume with the given name, or nil if it does not exist
+func (f *FakeDockerManager) Volume(volumeName string) *FakeVolume {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Volumes[volumeName]
+}
+
+// VolumeNames returns the name of every volume that exists, in order
+func (f *FakeDockerManager) VolumeNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.Volumes))
+	for name := range f.Volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f *FakeDockerManager) onCommand(workingDir string, command []string) (string, error) {
+	if f.OnCommand == nil {
+		return "", nil
+	}
+	return f.OnCommand(workingDir, command)
+}
+
+func (f *FakeDockerManager) RunDockerCommand(ctx context.Context, workingDir string, command ...string) error {
+	_, err := f.RunDockerCommandBuffered(ctx, workingDir, command...)
+	return err
+}
+
+func (f *FakeDockerManager) RunDockerCommandLine(ctx context.Context, workingDir string, command string) error {
+	return f.RunDockerCommand(ctx, workingDir, strings.Split(command, " ")...)
+}
+
+func (f *FakeDockerManager) RunDockerCommandBuffered(ctx context.Context, workingDir string, command ...string) (string, error) {
+	output, err := f.onCommand(workingDir, command)
+	if err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Commands = append(f.Commands, command)
+	if len(command) < 2 {
+		return output, nil
+	}
+	switch command[0] {
+	case "volume":
+		if len(command) < 3 {
+			return output, nil
+		}
+		switch command[1] {
+		case "create":
+			f.createVolume(command[2])
+		case "remove", "rm", "inspect":
+			if f.Volumes[command[2]] == nil {
+				return "", fmt.Errorf("Error: No such volume: %s", command[2])
+			}
+			if command[1] != "inspect" {
+				delete(f.Volumes, command[2])
+			}
+		}
+	case "pull":
+		f.Images[command[1]] = true
+	case "tag":
+		f.Images[command[len(command)-1]] = true
+	case "rmi", "untag":
+		delete(f.Images, command[len(command)-1])
+	case "run":
+		// Volumes that are mounted into a container are created if they do not exist
+		for i := 1; i < len(command)-1; i++ {
+			if command[i] == "-v" {
+				if source, _, ok := strings.Cut(command[i+1], ":"); ok && !strings.Contains(source, "/") {
+					f.createVolume(source)
+				}
+			}
+		}
+	}
+	return output, nil
+}
+
+func (f *FakeDockerManager) RunDockerComposeCommand(ctx context.Context, workingDir string, command ...string) error {
+	if err := requireComposeVersion(ctx); err != nil {
+		return err
+	}
+	if _, err := f.onCommand(workingDir, append([]string{"compose"}, command...)); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ComposeCommands = append(f.ComposeCommands, command)
+	if len(command) == 0 {
+		return nil
+	}
+	services := []string{}
+	for _, arg := range command[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			services = append(services, arg)
+		}
+	}
+	switch command[0] {
+	case "up", "start", "restart":
+		compose, err := readComposeFile(workingDir)
+		if err != nil {
+			return err
+		}
+		if len(services) == 0 {
+			for name := range compose.Services {
+				services = append(services, name)
+			}
+		}
+		containers := f.Containers[workingDir]
+		if containers == nil {
+			containers = map[string]*docker.ContainerState{}
+			f.Containers[workingDir] = containers
+		}
+		for _, name := range services {
+			service, ok := compose.Services[name]
+			if !ok {
+				return fmt.Errorf("no such service: %s", name)
+			}
+			if command[0] == "up" {
+				for _, volume := range service.Volumes {
+					if source, _, ok := strings.Cut(volume, ":"); ok && !strings.Contains(source, "/") {
+						f.createVolume(fmt.Sprintf("%s_%s", filepath.Base(workingDir), source))
+					}
+				}
+			} else if containers[name] == nil {
+				continue
+			}
+			containerName := service.ContainerName
+			if containerName == "" {
+				containerName = fmt.Sprintf("%s-%s-1", filepath.Base(workingDir), name)
+			}
+			containers[name] = &docker.ContainerState{Service: name, Container: containerName, Image: service.Image, State: "running", Status: "Up"}
+		}
+	case "stop":
+		for name, container := range f.Containers[workingDir] {
+			if len(services) == 0 || contains(services, name) {
+				container.State = "exited"
+				container.Status = "Exited (0)"
+			}
+		}
+	case "down":
+		delete(f.Containers, workingDir)
+	case "rm":
+		for _, name := range services {
+			delete(f.Containers[workingDir], name)
+		}
+	}
+	return nil
+}
+
+func (f *FakeDockerManager) RunDockerComposeCommandReturnsStdout(workingDir string, command ...string) ([]byte, error) {
+	output, err := f.onCommand(workingDir, append([]string{"compose"}, command...))
+	return []byte(output), err
+}
+
+func (f *FakeDockerManager) GetImageConfig(image string) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (f *FakeDockerManager) GetImageLabel(image, label string) (string, error) {
+	return "", nil
+}
+
+// GetImageDigest returns a digest made from the name of the image, so that every image that
+// has been pulled is up to date
+func (f *FakeDockerManager) GetImageDigest(image string) (string, error) {
+	hash := sha256.Sum256([]byte(image))
+	return "sha256:" + hex.EncodeToString(hash[:]), nil
+}
+
+func (f *FakeDockerManager) GetLocalImageDigests(ctx context.Context, image string) ([]string, bool) {
+	f.mu.Lock()
+	present := f.Images[image]
+	f.mu.Unlock()
+	if !present {
+		return nil, false
+	}
+	digest, _ := f.GetImageDigest(image)
+	repository := image
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repository = image[:i]
+	}
+	return []string{repository + "@" + digest}, true
+}
+
+func (f *FakeDockerManager) SaveImages(ctx context.Context, filename string, images ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, image := range images {
+		if !f.Images[image] {
+			return fmt.Errorf("No such image: %s", image)
+		}
+	}
+	return os.WriteFile(filename, []byte(strings.Join(images, "\n")), 0755)
+}
+
+func (f *FakeDockerManager) LoadImages(ctx context.Context, filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, image := range strings.Fields(string(b)) {
+		f.Images[image] = true
+	}
+	return nil
+}
+
+func (f *FakeDockerManager) TagImage(ctx context.Context, source string, target string) error {
+	return f.RunDockerCommand(ctx, ".", "tag", source, target)
+}
+
+func (f *FakeDockerManager) UntagImage(ctx context.Context, image string) error {
+	return f.RunDockerCommand(ctx, ".", "rmi", image)
+}
+
+func (f *FakeDockerManager) CreateVolume(ctx context.Context, volumeName string) error {
+	return f.RunDockerCommand(ctx, ".", "volume", "create", volumeName)
+}
+
+// CopyFileToVolume copies a file or directory in the same way as cp -R, so a source that is
+// copied to a directory that exists is put inside it
+func (f *FakeDockerManager) CopyFileToVolume(ctx context.Context, volumeName string, sourcePath string, destPath string) error {
+	if _, err := f.onCommand(".", []string{"cp", sourcePath, volumeName + ":" + destPath}); err != nil {
+		return err
+	}
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	volume := f.createVolume(volumeName)
+	dest := cleanVolumePath(destPath)
+	if volume.Dirs[dest] {
+		dest = path.Join(dest, filepath.Base(sourcePath))
+	}
+	if !info.IsDir() {
+		b, err := os.ReadFile(sourcePath)
+		if err != nil {
+			return err
+		}
+		volume.addFile(dest, b)
+		return nil
+	}
+	return filepath.Walk(sourcePath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(sourcePath, p)
+		if err != nil {
+			return err
+		}
+		target := path.Join(dest, filepath.ToSlash(relativePath))
+		if info.IsDir() {
+			volume.addDir(target)
+			return nil
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		volume.addFile(target, b)
+		return nil
+	})
+}
+
+func (f *FakeDockerManager) MkdirInVolume(ctx context.Context, volumeName string, directory string) error {
+	if _, err := f.onCommand(".", []string{"mkdir", volumeName + ":" + directory}); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.createVolume(volumeName).addDir(cleanVolumePath(directory))
+	return nil
+}
+
+func (f *FakeDockerManager) RemoveVolume(ctx context.Context, volumeName string) error {
+	return f.RunDockerCommand(ctx, ".", "volume", "remove", volumeName)
+}
+
+func (f *FakeDockerManager) VolumeExists(ctx context.Context, volumeName string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Volumes[volumeName] != nil, nil
+}
+
+// ExportVolume writes the files in the volume to a tar file, which ImportVolume can read
+func (f *FakeDockerManager) ExportVolume(ctx context.Context, volumeName string, destDir string, fileName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	volume := f.Volumes[volumeName]
+	if volume == nil {
+		return fmt.Errorf("Error: No such volume: %s", volumeName)
+	}
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range volume.sortedFiles() {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(volume.Files[name])), Typeflag: tar.TypeReg}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(volume.Files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(destDir, fileName), buf.Bytes(), 0755)
+}
+
+func (f *FakeDockerManager) ImportVolume(ctx context.Context, volumeName string, sourcePath string) error {
+	file, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	volume := f.createVolume(volumeName)
+	tr := tar.NewReader(file)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		volume.addFile(cleanVolumePath(header.Name), b)
+	}
+}
+
+func (f *FakeDockerManager) CopyFromContainer(ctx context.Context, containerName string, sourcePath string, destPath string) error {
+	return f.RunDockerCommand(ctx, ".", "cp", containerName+":"+sourcePath, destPath)
+}
+
+func (f *FakeDockerManager) GetContainerStates(ctx context.Context, workingDir string) ([]*docker.ContainerState, error) {
+	if err := requireComposeVersion(ctx); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.Containers[workingDir]))
+	for name := range f.Containers[workingDir] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	states := make([]*docker.ContainerState, 0, len(names))
+	for _, name := range names {
+		state := *f.Containers[workingDir][name]
+		states = append(states, &state)
+	}
+	return states, nil
+}
+
+func (f *FakeDockerManager) createVolume(volumeName string) *FakeVolume {
+	volume := f.Volumes[volumeName]
+	if volume == nil {
+		volume = &FakeVolume{Files: map[string][]byte{}, Dirs: map[string]bool{"/": true}}
+		f.Volumes[volumeName] = volume
+	}
+	return volume
+}
+
+func (v *FakeVolume) addDir(dir string) {
+	for ; dir != "/" && dir != "."; dir = path.Dir(dir) {
+		v.Dirs[dir] = true
+	}
+}
+
+func (v *FakeVolume) addFile(name string, b []byte) {
+	v.addDir(path.Dir(name))
+	v.Files[name] = b
+}
+
+func (v *FakeVolume) sortedFiles() []string {
+	names := make([]string, 0, len(v.Files))
+	for name := range v.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func cleanVolumePath(p string) string {
+	return path.Join("/", p)
+}
+
+func readComposeFile(workingDir string) (*docker.DockerComposeConfig, error) {
+	b, err := os.ReadFile(filepath.Join(workingDir, "docker-compose.yml"))
+	if err != nil {
+		return nil, err
+	}
+	var compose *docker.DockerComposeConfig
+	if err := yaml.Unmarshal(b, &compose); err != nil {
+		return nil, err
+	}
+	return compose, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// requireComposeVersion fails the same way the real docker manager does when a command
+// has not detected the docker compose version before running compose
+func requireComposeVersion(ctx context.Context) error {
+	switch ctx.Value(docker.CtxComposeVersionKey{}) {
+	case docker.ComposeV1, docker.ComposeV2:
+		return nil
+	default:
+		return fmt.Errorf("no version for docker-compose has been detected")
+	}
+}
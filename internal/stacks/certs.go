@@ -0,0 +1,305 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+)
+
+const (
+	stackCADir       = "ca"
+	stackCACertFile  = "ca.pem"
+	stackCAKeyFile   = "ca-key.pem"
+	stackCAValidity  = 10 * 365 * 24 * time.Hour
+	dxCertValidity   = 365 * 24 * time.Hour
+	dxCertFile       = "cert.pem"
+	dxKeyFile        = "key.pem"
+	dxPeerCertsDir   = "peer-certs"
+	certSerialLength = 128
+)
+
+// dataExchangePeerID is the ID data exchange uses for a member, which it reads from the
+// organization in the subject of the member's certificate
+func dataExchangePeerID(member *types.Organization) string {
+	return "member_" + member.ID
+}
+
+// loadOrCreateStackCA returns the root CA that signs the data exchange certificates of every member
+// of the stack. Stacks created before the CA was introduced get a new one the first time it is needed.
+func (s *StackManager) loadOrCreateStackCA() (*x509.Certificate, crypto.Signer, error) {
+	caDir := filepath.Join(s.Stack.InitDir, "config", stackCADir)
+	certPEM, err := os.ReadFile(filepath.Join(caDir, stackCACertFile))
+	if os.IsNotExist(err) {
+		return s.createStackCA(caDir)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(caDir, stackCAKeyFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := parseCertificatePEM(certPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CA certificate in %s: %s", caDir, err)
+	}
+	key, err := parsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CA key in %s: %s", caDir, err)
+	}
+	return cert, key, nil
+}
+
+func (s *StackManager) createStackCA(caDir string) (*x509.Certificate, crypto.Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("%s_ca", s.Stack.Name), Organization: []string{s.Stack.Name}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(stackCAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := encodePrivateKeyPEM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(caDir, 0755); err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(filepath.Join(caDir, stackCACertFile), encodeCertificatePEM(der), 0755); err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(filepath.Join(caDir, stackCAKeyFile), keyPEM, 0600); err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// issueDataExchangeCert creates a key and a certificate for a member's data exchange, signed by the
+// stack CA. The CA certificate is appended to the returned certificate, so that every peer that is
+// given the certificate also trusts the certificates the CA issues when they are rotated.
+func (s *StackManager) issueDataExchangeCert(caCert *x509.Certificate, caKey crypto.Signer, member *types.Organization) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	serviceName := fmt.Sprintf("dataexchange_%s", member.ID)
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: serviceName, Organization: []string{dataExchangePeerID(member)}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(dxCertValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{serviceName, fmt.Sprintf("%s_%s", s.Stack.Name, serviceName), "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err = encodePrivateKeyPEM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = append(encodeCertificatePEM(der), encodeCertificatePEM(caCert.Raw)...)
+	return certPEM, keyPEM, nil
+}
+
+func (s *StackManager) writeMemberDataExchangeKeyPair(memberDXDir string, member *types.Organization) error {
+	caCert, caKey, err := s.loadOrCreateStackCA()
+	if err != nil {
+		return err
+	}
+	certPEM, keyPEM, err := s.issueDataExchangeCert(caCert, caKey, member)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(memberDXDir, dxCertFile), certPEM, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(memberDXDir, dxKeyFile), keyPEM, 0600)
+}
+
+// RotateDataExchangeCerts issues a new data exchange certificate for every member of the stack.
+// If the stack has been started, the new certificates are copied into the data exchange volumes,
+// along with a copy of each certificate for the other members to trust, and any data exchange
+// containers that are running are restarted to pick them up.
+func (s *StackManager) RotateDataExchangeCerts() error {
+	if s.IsOldFileStructure {
+		return fmt.Errorf("the FireFly stack '%s' was created with an older version of the CLI and rotating certificates is not supported", s.Stack.Name)
+	}
+	hasRunBefore, err := s.Stack.HasRunBefore()
+	if err != nil {
+		return err
+	}
+	caCert, caKey, err := s.loadOrCreateStackCA()
+	if err != nil {
+		return err
+	}
+
+	configDirs := []string{filepath.Join(s.Stack.InitDir, "config")}
+	if hasRunBefore {
+		configDirs = append(configDirs, filepath.Join(s.Stack.RuntimeDir, "config"))
+	}
+	certs := make(map[string][]byte, len(s.Stack.Members))
+	for _, member := range s.Stack.Members {
+		s.Log.Info(fmt.Sprintf("issuing data exchange certificate for member %s", member.ID))
+		certPEM, keyPEM, err := s.issueDataExchangeCert(caCert, caKey, member)
+		if err != nil {
+			return err
+		}
+		certs[member.ID] = certPEM
+		for _, configDir := range configDirs {
+			memberDXDir := filepath.Join(configDir, "dataexchange_"+member.ID)
+			if err := os.MkdirAll(memberDXDir, 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(memberDXDir, dxCertFile), certPEM, 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(memberDXDir, dxKeyFile), keyPEM, 0600); err != nil {
+				return err
+			}
+		}
+	}
+	if !hasRunBefore {
+		return nil
+	}
+
+	runtimeConfigDir := filepath.Join(s.Stack.RuntimeDir, "config")
+	for _, member := range s.Stack.Members {
+		volumeName := fmt.Sprintf("%s_dataexchange_%s", s.Stack.Name, member.ID)
+		memberDXDir := path.Join(runtimeConfigDir, "dataexchange_"+member.ID)
+		s.Log.Info(fmt.Sprintf("copying certificates to volume '%s'", volumeName))
+		if err := docker.CopyFileToVolume(s.ctx, volumeName, path.Join(memberDXDir, dxCertFile), "/"+dxCertFile); err != nil {
+			return err
+		}
+		if err := docker.CopyFileToVolume(s.ctx, volumeName, path.Join(memberDXDir, dxKeyFile), "/"+dxKeyFile); err != nil {
+			return err
+		}
+		// Replace the certificates this member holds for its peers, so that peers with certificates
+		// that were not issued by the stack CA are still trusted
+		for _, peer := range s.Stack.Members {
+			if peer.ID == member.ID {
+				continue
+			}
+			peerCertPath := path.Join(runtimeConfigDir, "dataexchange_"+peer.ID, dxCertFile)
+			if err := docker.CopyFileToVolume(s.ctx, volumeName, peerCertPath, path.Join("/", dxPeerCertsDir, dataExchangePeerID(peer)+".pem")); err != nil {
+				return err
+			}
+		}
+	}
+
+	containers, err := docker.GetContainerStates(s.ctx, s.Stack.StackDir)
+	if err != nil {
+		return err
+	}
+	toRestart := []string{}
+	for _, member := range s.Stack.Members {
+		serviceName := "dataexchange_" + member.ID
+		for _, container := range containers {
+			if container.Service == serviceName && container.State == "running" {
+				toRestart = append(toRestart, serviceName)
+			}
+		}
+	}
+	if len(toRestart) > 0 {
+		s.Log.Info("restarting data exchange")
+		return s.runDockerComposeCommand(append([]string{"restart"}, toRestart...)...)
+	}
+	return nil
+}
+
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), certSerialLength))
+}
+
+func encodeCertificatePEM(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func encodePrivateKeyPEM(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func parseCertificatePEM(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parsePrivateKeyPEM(keyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no private key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
@@ -0,0 +1,90 @@
+package stacks
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCertTestStackManager(t *testing.T) *StackManager {
+	stackDir := t.TempDir()
+	return &StackManager{
+		ctx: context.Background(),
+		Log: &log.StdoutLogger{},
+		Stack: &types.Stack{
+			Name:       "dev",
+			StackDir:   stackDir,
+			InitDir:    filepath.Join(stackDir, "init"),
+			RuntimeDir: filepath.Join(stackDir, "runtime"),
+			Members:    []*types.Organization{{ID: "0"}, {ID: "1"}},
+		},
+	}
+}
+
+func TestIssueDataExchangeCert(t *testing.T) {
+	s := newCertTestStackManager(t)
+	caCert, caKey, err := s.loadOrCreateStackCA()
+	assert.NoError(t, err)
+	assert.True(t, caCert.IsCA)
+
+	certPEM, keyPEM, err := s.issueDataExchangeCert(caCert, caKey, s.Stack.Members[1])
+	assert.NoError(t, err)
+	_, err = parsePrivateKeyPEM(keyPEM)
+	assert.NoError(t, err)
+
+	leafBlock, rest := pem.Decode(certPEM)
+	assert.NotNil(t, leafBlock)
+	caBlock, _ := pem.Decode(rest)
+	assert.NotNil(t, caBlock)
+	assert.Equal(t, caCert.Raw, caBlock.Bytes)
+
+	leaf, err := x509.ParseCertificate(leafBlock.Bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, "dataexchange_1", leaf.Subject.CommonName)
+	assert.Equal(t, []string{"member_1"}, leaf.Subject.Organization)
+	assert.Contains(t, leaf.DNSNames, "dataexchange_1")
+	assert.Contains(t, leaf.DNSNames, "dev_dataexchange_1")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	_, err = leaf.Verify(x509.VerifyOptions{
+		DNSName:   "dataexchange_1",
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	assert.NoError(t, err)
+}
+
+func TestLoadStackCAIsReused(t *testing.T) {
+	s := newCertTestStackManager(t)
+	caCert1, _, err := s.loadOrCreateStackCA()
+	assert.NoError(t, err)
+	caCert2, _, err := s.loadOrCreateStackCA()
+	assert.NoError(t, err)
+	assert.Equal(t, caCert1.Raw, caCert2.Raw)
+
+	info, err := os.Stat(filepath.Join(s.Stack.InitDir, "config", stackCADir, stackCAKeyFile))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestRotateDataExchangeCertsBeforeFirstStart(t *testing.T) {
+	s := newCertTestStackManager(t)
+	// A name that does not exist under the stacks dir, so the stack has not run before
+	s.Stack.Name = filepath.Base(s.Stack.StackDir)
+	assert.NoError(t, s.RotateDataExchangeCerts())
+	for _, member := range s.Stack.Members {
+		certPEM, err := os.ReadFile(filepath.Join(s.Stack.InitDir, "config", "dataexchange_"+member.ID, dxCertFile))
+		assert.NoError(t, err)
+		cert, err := parseCertificatePEM(certPEM)
+		assert.NoError(t, err)
+		assert.Equal(t, "dataexchange_"+member.ID, cert.Subject.CommonName)
+	}
+}
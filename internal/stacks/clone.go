@@ -0,0 +1,245 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/otiai10/copy"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	extraCoreConfigFile      = "extra_core_config.yml"
+	extraConnectorConfigFile = "extra_connector_config.yml"
+)
+
+// writeExtraConfig keeps a copy of any extra core and connector config the stack was
+// created with, so that the stack can be cloned later
+func (s *StackManager) writeExtraConfig(options *types.InitOptions) error {
+	if options.ExtraCoreConfigPath != "" {
+		if err := copy.Copy(options.ExtraCoreConfigPath, filepath.Join(s.Stack.StackDir, extraCoreConfigFile)); err != nil {
+			return err
+		}
+	}
+	if options.ExtraConnectorConfigPath != "" {
+		if err := copy.Copy(options.ExtraConnectorConfigPath, filepath.Join(s.Stack.StackDir, extraConnectorConfigFile)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloneStack creates a new stack with the same configuration as an existing one. The new
+// stack gets its own ports, blockchain accounts, swarm key and data exchange certificates.
+func (s *StackManager) CloneStack(sourceName, stackName string) error {
+	exists, err := CheckExists(stackName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("stack '%s' already exists", stackName)
+	}
+
+	source := NewStackManager(s.ctx)
+	if err := source.LoadStack(sourceName); err != nil {
+		return err
+	}
+	if source.IsOldFileStructure {
+		return fmt.Errorf("the FireFly stack '%s' was created with an older version of the CLI and cannot be cloned", sourceName)
+	}
+
+	workDir, err := os.MkdirTemp("", "ff-clone-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	options, err := source.cloneInitOptions(stackName, workDir)
+	if err != nil {
+		return err
+	}
+	if err := s.InitStack(options); err != nil {
+		if cleanupErr := s.RemoveStack(); cleanupErr != nil {
+			s.Log.Warn(fmt.Sprintf("unable to remove the partly created stack '%s': %s", stackName, cleanupErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// cloneInitOptions builds the options that recreate the loaded stack under a new name. Any
+// files the options refer to are written to workDir.
+func (s *StackManager) cloneInitOptions(stackName, workDir string) (*types.InitOptions, error) {
+	if s.Stack.RemoteFabricNetwork {
+		return nil, fmt.Errorf("the FireFly stack '%s' uses a remote Fabric network and cannot be cloned", s.Stack.Name)
+	}
+
+	options := &types.InitOptions{
+		StackName:                 stackName,
+		MemberCount:               len(s.Stack.Members),
+		FireFlyBasePort:           5000,
+		ServicesBasePort:          5100,
+		PtmBasePort:               4100,
+		PrometheusPort:            9090,
+		AutoPorts:                 true,
+		DatabaseProvider:          s.Stack.Database.String(),
+		BlockchainProvider:        s.Stack.BlockchainProvider.String(),
+		BlockchainConnector:       s.Stack.BlockchainConnector.String(),
+		BlockchainNodeProvider:    s.Stack.BlockchainNodeProvider.String(),
+		PrivateTransactionManager: s.Stack.PrivateTransactionManager.String(),
+		Consensus:                 s.Stack.Consensus.String(),
+		Offline:                   s.Stack.Offline,
+		PrometheusEnabled:         s.Stack.PrometheusEnabled,
+		SandboxEnabled:            s.Stack.SandboxEnabled,
+		BlockPeriod:               -1,
+		ContractAddress:           s.Stack.ContractAddress,
+		RemoteNodeURL:             s.Stack.RemoteNodeURL,
+		RemoteNodeAuth:            s.Stack.RemoteNodeAuth,
+		ChainID:                   s.Stack.ChainID(),
+		DisableTokenFactories:     s.Stack.DisableTokenFactories,
+		RequestTimeout:            s.Stack.RequestTimeout,
+		RetryPolicy:               s.Stack.RetryPolicy,
+		MultipartyEnabled:         s.Stack.MultipartyEnabled,
+		IPFSMode:                  s.Stack.IPFSMode.String(),
+		ResourceProfile:           s.Stack.ResourceProfile,
+		Resources:                 s.Stack.Resources,
+		ChannelName:               s.Stack.ChannelName,
+		ChaincodeName:             s.Stack.ChaincodeName,
+		CustomPinSupport:          s.Stack.CustomPinSupport,
+		RemoteNodeDeploy:          s.Stack.RemoteNodeDeploy,
+		EnvironmentVars:           map[string]string{},
+	}
+	if s.Stack.BlockPeriod != nil {
+		options.BlockPeriod = *s.Stack.BlockPeriod
+	}
+	for _, member := range s.Stack.Members {
+		options.OrgNames = append(options.OrgNames, member.OrgName)
+		options.NodeNames = append(options.NodeNames, member.NodeName)
+		if member.External {
+			options.ExternalProcesses++
+		}
+	}
+	for _, tp := range s.Stack.TokenProviders {
+		options.TokenProviders = append(options.TokenProviders, tp.String())
+	}
+	for k, v := range s.Stack.EnvironmentVars {
+		options.EnvironmentVars[k] = fmt.Sprint(v)
+	}
+	if len(s.Stack.Hooks) > 0 {
+		options.Hooks = make(map[string]string, len(s.Stack.Hooks))
+		for name, command := range s.Stack.Hooks {
+			options.Hooks[name] = command
+		}
+	}
+
+	// Use exactly the same versions as the original, rather than resolving the manifest again
+	manifestBytes, err := json.Marshal(s.Stack.VersionManifest)
+	if err != nil {
+		return nil, err
+	}
+	options.ManifestPath = filepath.Join(workDir, "manifest.json")
+	if err := os.WriteFile(options.ManifestPath, manifestBytes, 0644); err != nil {
+		return nil, err
+	}
+
+	coreConfigPath := filepath.Join(s.Stack.StackDir, extraCoreConfigFile)
+	if _, err := os.Stat(coreConfigPath); err == nil {
+		options.ExtraCoreConfigPath = coreConfigPath
+	} else {
+		// Stacks created before the extra config was saved with them only have the merged
+		// config, so work out what was added to the config the CLI generates
+		if options.ExtraCoreConfigPath, err = s.recoverExtraCoreConfig(workDir); err != nil {
+			return nil, err
+		}
+	}
+	connectorConfigPath := filepath.Join(s.Stack.StackDir, extraConnectorConfigFile)
+	if _, err := os.Stat(connectorConfigPath); err == nil {
+		options.ExtraConnectorConfigPath = connectorConfigPath
+	}
+	return options, nil
+}
+
+// recoverExtraCoreConfig compares the core config of the first member with the config the
+// CLI generates for it, and writes any differences to a file in workDir. An empty path is
+// returned if there are no differences.
+func (s *StackManager) recoverExtraCoreConfig(workDir string) (string, error) {
+	if len(s.Stack.Members) == 0 {
+		return "", nil
+	}
+	member := s.Stack.Members[0]
+	actual, err := readYAMLMap(filepath.Join(s.Stack.InitDir, "config", fmt.Sprintf("firefly_core_%s.yml", member.ID)))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if err := s.writeFireflyCoreConfig(workDir, member, ""); err != nil {
+		return "", err
+	}
+	generated, err := readYAMLMap(filepath.Join(workDir, fmt.Sprintf("firefly_core_%s.yml", member.ID)))
+	if err != nil {
+		return "", err
+	}
+
+	overlay := configOverlay(actual, generated)
+	if len(overlay) == 0 {
+		return "", nil
+	}
+	b, err := yaml.Marshal(overlay)
+	if err != nil {
+		return "", err
+	}
+	overlayPath := filepath.Join(workDir, extraCoreConfigFile)
+	return overlayPath, os.WriteFile(overlayPath, b, 0644)
+}
+
+func readYAMLMap(filename string) (map[string]interface{}, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	return m, yaml.Unmarshal(b, &m)
+}
+
+// configOverlay returns the parts of actual that are missing from, or different to, generated
+func configOverlay(actual, generated map[string]interface{}) map[string]interface{} {
+	overlay := map[string]interface{}{}
+	for k, v := range actual {
+		g, ok := generated[k]
+		if !ok {
+			overlay[k] = v
+			continue
+		}
+		vMap, vIsMap := v.(map[string]interface{})
+		gMap, gIsMap := g.(map[string]interface{})
+		if vIsMap && gIsMap {
+			if sub := configOverlay(vMap, gMap); len(sub) > 0 {
+				overlay[k] = sub
+			}
+		} else if !reflect.DeepEqual(v, g) {
+			overlay[k] = v
+		}
+	}
+	return overlay
+}
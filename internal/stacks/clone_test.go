@@ -0,0 +1,79 @@
+package stacks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigOverlay(t *testing.T) {
+	generated := map[string]interface{}{
+		"log":  map[string]interface{}{"level": "debug"},
+		"http": map[string]interface{}{"port": 5000, "address": "0.0.0.0"},
+	}
+	actual := map[string]interface{}{
+		"log":   map[string]interface{}{"level": "trace"},
+		"http":  map[string]interface{}{"port": 5000, "address": "0.0.0.0"},
+		"event": map[string]interface{}{"dbevents": map[string]interface{}{"bufferSize": 10000}},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"log":   map[string]interface{}{"level": "trace"},
+		"event": map[string]interface{}{"dbevents": map[string]interface{}{"bufferSize": 10000}},
+	}, configOverlay(actual, generated))
+	assert.Empty(t, configOverlay(generated, generated))
+}
+
+func TestCloneInitOptions(t *testing.T) {
+	stackDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(stackDir, extraConnectorConfigFile), []byte("confirmations:\n  required: 0\n"), 0755))
+	blockPeriod := 5
+	s := &StackManager{Stack: &types.Stack{
+		Name: "alice",
+		Members: []*types.Organization{
+			{ID: "0", OrgName: "org_0", NodeName: "node_0", External: true},
+			{ID: "1", OrgName: "org_1", NodeName: "node_1"},
+		},
+		Database:               fftypes.FFEnum("sqlite3"),
+		BlockchainProvider:     fftypes.FFEnum("ethereum"),
+		BlockchainConnector:    fftypes.FFEnum("evmconnect"),
+		BlockchainNodeProvider: fftypes.FFEnum("geth"),
+		TokenProviders:         []fftypes.FFEnum{"erc20_erc721"},
+		IPFSMode:               fftypes.FFEnum("private"),
+		BlockPeriod:            &blockPeriod,
+		EnvironmentVars:        map[string]interface{}{"HTTP_PROXY": "http://proxy:3128"},
+		VersionManifest:        &types.VersionManifest{FireFly: &types.ManifestEntry{Image: "ghcr.io/hyperledger/firefly", Tag: "v1.3.0"}},
+		StackDir:               stackDir,
+		InitDir:                filepath.Join(stackDir, "init"),
+	}}
+
+	workDir := t.TempDir()
+	options, err := s.cloneInitOptions("bob", workDir)
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", options.StackName)
+	assert.Equal(t, 2, options.MemberCount)
+	assert.Equal(t, []string{"org_0", "org_1"}, options.OrgNames)
+	assert.Equal(t, []string{"node_0", "node_1"}, options.NodeNames)
+	assert.Equal(t, 1, options.ExternalProcesses)
+	assert.Equal(t, "evmconnect", options.BlockchainConnector)
+	assert.Equal(t, []string{"erc20_erc721"}, options.TokenProviders)
+	assert.Equal(t, 5, options.BlockPeriod)
+	assert.Equal(t, int64(2021), options.ChainID)
+	assert.True(t, options.AutoPorts)
+	assert.Equal(t, map[string]string{"HTTP_PROXY": "http://proxy:3128"}, options.EnvironmentVars)
+	assert.Equal(t, filepath.Join(stackDir, extraConnectorConfigFile), options.ExtraConnectorConfigPath)
+	assert.Empty(t, options.ExtraCoreConfigPath)
+
+	manifest, err := os.ReadFile(options.ManifestPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(manifest), `"tag":"v1.3.0"`)
+}
+
+func TestCloneInitOptionsRemoteFabric(t *testing.T) {
+	s := &StackManager{Stack: &types.Stack{Name: "alice", RemoteFabricNetwork: true}}
+	_, err := s.cloneInitOptions("bob", t.TempDir())
+	assert.Regexp(t, "remote Fabric network", err)
+}
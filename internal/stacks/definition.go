@@ -0,0 +1,59 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadStackDefinition reads a stack definition file. JSON is a subset of YAML, so
+// either format is accepted.
+func ReadStackDefinition(filename string) (*types.StackDefinition, error) {
+	d, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var definition *types.StackDefinition
+	decoder := yaml.NewDecoder(bytes.NewReader(d))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&definition); err != nil {
+		return nil, fmt.Errorf("failed to parse stack definition '%s': %s", filename, err)
+	}
+	if definition == nil {
+		return nil, fmt.Errorf("stack definition '%s' is empty", filename)
+	}
+	// Paths in the file are relative to the file, wherever the CLI is run from
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	definition.ResolvePaths(dir)
+	definition.Hooks = resolveHooks(definition.Hooks, dir)
+	return definition, nil
+}
+
+// ExportStackDefinition builds a stack definition that can be used to create a new
+// stack with the same settings as the one that is currently loaded
+func (s *StackManager) ExportStackDefinition() *types.StackDefinition {
+	return types.NewStackDefinition(s.Stack)
+}
@@ -0,0 +1,202 @@
+package stacks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestReadStackDefinitionYAML(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stack.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte(`version: 1
+name: mystack
+members:
+  - orgName: org_a
+    nodeName: node_a
+  - orgName: org_b
+    nodeName: node_b
+blockchain:
+  provider: ethereum
+  node: besu
+  chainID: 1337
+  blockPeriod: 0
+tokenProviders: [erc1155]
+ports:
+  fireflyBase: 6000
+sandboxEnabled: false
+environmentVars:
+  HTTP_PROXY: http://proxy
+`), 0755))
+
+	definition, err := ReadStackDefinition(filename)
+	assert.NoError(t, err)
+
+	options := &types.InitOptions{
+		FireFlyBasePort:  5000,
+		ServicesBasePort: 5100,
+		SandboxEnabled:   true,
+		BlockPeriod:      -1,
+		DatabaseProvider: "sqlite3",
+	}
+	assert.NoError(t, definition.ApplyToInitOptions(options, nil))
+	assert.Equal(t, "mystack", options.StackName)
+	assert.Equal(t, 2, options.MemberCount)
+	assert.Equal(t, []string{"org_a", "org_b"}, options.OrgNames)
+	assert.Equal(t, []string{"node_a", "node_b"}, options.NodeNames)
+	assert.Equal(t, "besu", options.BlockchainNodeProvider)
+	assert.Equal(t, int64(1337), options.ChainID)
+	assert.Equal(t, 0, options.BlockPeriod)
+	assert.Equal(t, []string{"erc1155"}, options.TokenProviders)
+	assert.Equal(t, 6000, options.FireFlyBasePort)
+	assert.Equal(t, 5100, options.ServicesBasePort)
+	assert.False(t, options.SandboxEnabled)
+	assert.Equal(t, "sqlite3", options.DatabaseProvider)
+	assert.Equal(t, "http://proxy", options.EnvironmentVars["HTTP_PROXY"])
+}
+
+func TestReadStackDefinitionJSON(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stack.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(`{"version": 1, "name": "mystack", "ipfsMode": "public"}`), 0755))
+
+	definition, err := ReadStackDefinition(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "mystack", definition.Name)
+	assert.Equal(t, "public", definition.IPFSMode)
+}
+
+func TestReadStackDefinitionUnknownField(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stack.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("version: 1\nmemberCount: 2\n"), 0755))
+
+	_, err := ReadStackDefinition(filename)
+	assert.Regexp(t, "memberCount", err)
+}
+
+func TestApplyStackDefinitionVersion(t *testing.T) {
+	err := (&types.StackDefinition{}).ApplyToInitOptions(&types.InitOptions{}, nil)
+	assert.Regexp(t, "missing a version", err)
+
+	err = (&types.StackDefinition{Version: 99}).ApplyToInitOptions(&types.InitOptions{}, nil)
+	assert.Regexp(t, "version 99 is not supported", err)
+}
+
+func TestExportStackDefinitionRoundTrip(t *testing.T) {
+	chainID := int64(1337)
+	index0, index1 := 0, 1
+	s := &StackManager{
+		Stack: &types.Stack{
+			Name:                      "mystack",
+			ExposedBlockchainPort:     5100,
+			Database:                  types.DatabaseSelectionPostgres,
+			BlockchainProvider:        types.BlockchainProviderEthereum,
+			BlockchainNodeProvider:    types.BlockchainNodeProviderGeth,
+			BlockchainConnector:       types.BlockchainConnectorEvmconnect,
+			PrivateTransactionManager: types.PrivateTransactionManagerNone,
+			Consensus:                 types.ConsensusClique,
+			TokenProviders:            []fftypes.FFEnum{types.TokenProviderERC20ERC721},
+			IPFSMode:                  types.IPFSModePrivate,
+			SandboxEnabled:            true,
+			MultipartyEnabled:         true,
+			ChainIDPtr:                &chainID,
+			Members: []*types.Organization{
+				{ID: "0", Index: &index0, OrgName: "org_a", NodeName: "node_a", ExposedFireflyPort: 5000, External: true},
+				{ID: "1", Index: &index1, OrgName: "org_b", NodeName: "node_b", ExposedFireflyPort: 5001},
+			},
+			VersionManifest: &types.VersionManifest{
+				FireFly: &types.ManifestEntry{Image: "ghcr.io/hyperledger/firefly", Tag: "v1.3.0"},
+			},
+			EnvironmentVars: map[string]interface{}{"HTTP_PROXY": "http://proxy"},
+		},
+	}
+
+	b, err := yaml.Marshal(s.ExportStackDefinition())
+	assert.NoError(t, err)
+	filename := filepath.Join(t.TempDir(), "stack.yaml")
+	assert.NoError(t, os.WriteFile(filename, b, 0755))
+	definition, err := ReadStackDefinition(filename)
+	assert.NoError(t, err)
+
+	options := &types.InitOptions{}
+	assert.NoError(t, definition.ApplyToInitOptions(options, nil))
+	assert.Equal(t, "mystack", options.StackName)
+	assert.Equal(t, 2, options.MemberCount)
+	assert.Equal(t, 1, options.ExternalProcesses)
+	assert.Equal(t, []string{"org_a", "org_b"}, options.OrgNames)
+	assert.Equal(t, "postgres", options.DatabaseProvider)
+	assert.Equal(t, "geth", options.BlockchainNodeProvider)
+	assert.Equal(t, "evmconnect", options.BlockchainConnector)
+	assert.Equal(t, int64(1337), options.ChainID)
+	assert.Equal(t, []string{"erc20_erc721"}, options.TokenProviders)
+	assert.Equal(t, 5000, options.FireFlyBasePort)
+	assert.Equal(t, 5100, options.ServicesBasePort)
+	assert.Equal(t, "v1.3.0", options.FireFlyVersion)
+	assert.True(t, options.SandboxEnabled)
+	assert.True(t, options.MultipartyEnabled)
+	assert.False(t, options.PrometheusEnabled)
+	assert.Equal(t, "http://proxy", options.EnvironmentVars["HTTP_PROXY"])
+}
+
+func TestApplyStackDefinitionExplicitFlagsWin(t *testing.T) {
+	definition := &types.StackDefinition{
+		Version:        1,
+		Database:       "postgres",
+		TokenProviders: []string{"erc1155"},
+		Ports:          &types.PortsDefinition{FireFlyBase: 6000, ServicesBase: 6100},
+	}
+	options := &types.InitOptions{
+		DatabaseProvider: "sqlite3",
+		TokenProviders:   []string{"erc20_erc721"},
+		FireFlyBasePort:  7000,
+		ServicesBasePort: 5100,
+	}
+	explicit := map[string]bool{"database": true, "token-providers": true, "firefly-base-port": true}
+	assert.NoError(t, definition.ApplyToInitOptions(options, func(flag string) bool { return explicit[flag] }))
+	assert.Equal(t, "sqlite3", options.DatabaseProvider)
+	assert.Equal(t, []string{"erc20_erc721"}, options.TokenProviders)
+	assert.Equal(t, 7000, options.FireFlyBasePort)
+	assert.Equal(t, 6100, options.ServicesBasePort)
+}
+
+func TestReadStackDefinitionResolvesRelativePaths(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "stack.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte(`version: 1
+coreConfig: config/core.yaml
+connectorConfig: /etc/connector.yaml
+release:
+  manifest: ../manifest.json
+blockchain:
+  provider: fabric
+  ccp: [ccp.yaml]
+  msp: [msp]
+`), 0755))
+
+	definition, err := ReadStackDefinition(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "config", "core.yaml"), definition.ExtraCoreConfigPath)
+	assert.Equal(t, "/etc/connector.yaml", definition.ExtraConnectorConfigPath)
+	assert.Equal(t, filepath.Join(filepath.Dir(dir), "manifest.json"), definition.Release.ManifestPath)
+	assert.Equal(t, []string{filepath.Join(dir, "ccp.yaml")}, definition.Blockchain.CCPYAMLPaths)
+	assert.Equal(t, []string{filepath.Join(dir, "msp")}, definition.Blockchain.MSPPaths)
+}
+
+func TestReadStackDefinitionResolvesHookScripts(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "seed.sh"), []byte("#!/bin/sh\n"), 0755))
+	filename := filepath.Join(dir, "stack.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte(`version: 1
+hooks:
+  post-start: ./seed.sh --count 10
+  pre-stop: make clean
+`), 0755))
+
+	definition, err := ReadStackDefinition(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "seed.sh")+" --count 10", definition.Hooks["post-start"])
+	assert.Equal(t, "make clean", definition.Hooks["pre-stop"])
+}
@@ -0,0 +1,520 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+)
+
+const (
+	devDir            = "dev"
+	devHostGateway    = "host.docker.internal"
+	devHostGatewayMap = devHostGateway + ":host-gateway"
+	devLocalHost      = "127.0.0.1"
+)
+
+type devCommand struct {
+	binary string
+	args   string
+}
+
+// devCommands are the commands used to run a local build of each kind of service that
+// can be detached. If args is empty, the command from the docker compose file is used.
+var devCommands = map[string]devCommand{
+	"firefly_core_": {binary: "firefly", args: "-f /etc/firefly/firefly.core.yml"},
+	"evmconnect_":   {binary: "evmconnect"},
+	"ethconnect_":   {binary: "ethconnect"},
+	"fabconnect_":   {binary: "fabconnect"},
+	"tezosconnect_": {binary: "tezosconnect"},
+	"tokens_":       {binary: "npm start"},
+	"dataexchange_": {binary: "npm start"},
+}
+
+// DetachedService describes what is needed to run a local build in place of a
+// service that has been taken out of the docker compose file
+type DetachedService struct {
+	Service string
+	Dir     string
+	// Ports maps each port the container listened on to the port published on the host
+	Ports map[int]int
+	// Files maps each path mounted into the container to a local copy of it
+	Files   map[string]string
+	EnvFile string
+	Command string
+}
+
+func getDevCommand(serviceName string) (devCommand, bool) {
+	for prefix, command := range devCommands {
+		if strings.HasPrefix(serviceName, prefix) {
+			return command, true
+		}
+	}
+	return devCommand{}, false
+}
+
+func (s *StackManager) devServiceDir(serviceName string) string {
+	return filepath.Join(s.Stack.StackDir, devDir, serviceName)
+}
+
+// DetachService takes a service out of the docker compose file for the stack, so that a
+// local build can be run in its place. The config files and environment of the service
+// are copied, with any references to other services changed to their ports on the host,
+// and every other service is changed to use the local build.
+func (s *StackManager) DetachService(serviceName string) (detached *DetachedService, err error) {
+	if s.IsOldFileStructure {
+		return nil, fmt.Errorf("the FireFly stack '%s' was created with an older version of the CLI and its services cannot be detached", s.Stack.Name)
+	}
+	command, ok := getDevCommand(serviceName)
+	if !ok {
+		return nil, fmt.Errorf("service '%s' cannot be detached - only FireFly core, blockchain connector, tokens and data exchange services are supported", serviceName)
+	}
+	if s.Stack.IsDetached(serviceName) {
+		return nil, fmt.Errorf("service '%s' is already detached", serviceName)
+	}
+	hasRunBefore, err := s.Stack.HasRunBefore()
+	if err != nil {
+		return nil, err
+	}
+	if !hasRunBefore {
+		return nil, fmt.Errorf("the FireFly stack '%s' must be started once before its services can be detached", s.Stack.Name)
+	}
+
+	oldCompose := s.buildDockerCompose()
+	service, ok := oldCompose.Services[serviceName]
+	if !ok {
+		return nil, fmt.Errorf("service '%s' is not part of the FireFly stack '%s'", serviceName, s.Stack.Name)
+	}
+
+	detached = &DetachedService{
+		Service: serviceName,
+		Dir:     s.devServiceDir(serviceName),
+		Ports:   parsePortMappings(service.Ports),
+		Files:   map[string]string{},
+	}
+	if err := os.RemoveAll(detached.Dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(detached.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	// The local build reaches every other service through its port on the host
+	toLocal := func(text string) string {
+		text = strings.ReplaceAll(text, devHostGateway, devLocalHost)
+		for name, other := range oldCompose.Services {
+			if name == serviceName {
+				continue
+			}
+			var unpublished []int
+			text, unpublished = rewriteServiceRefs(text, name, parsePortMappings(other.Ports), devLocalHost)
+			for _, port := range unpublished {
+				s.Log.Warn(fmt.Sprintf("port %d of '%s' is not published on the host, so it cannot be reached by the local build of '%s'", port, name, serviceName))
+			}
+		}
+		return text
+	}
+
+	for _, volume := range service.Volumes {
+		source, target := parseVolumeMount(volume)
+		switch {
+		case s.isStackFile(source):
+			b, err := os.ReadFile(source)
+			if err != nil {
+				return nil, err
+			}
+			localPath := filepath.Join(detached.Dir, filepath.Base(source))
+			if err := os.WriteFile(localPath, []byte(toLocal(string(b))), 0644); err != nil {
+				return nil, err
+			}
+			detached.Files[target] = localPath
+		case !filepath.IsAbs(source) && !strings.Contains(source, "/"):
+			localPath, err := s.exportDevVolume(source, detached.Dir)
+			if err != nil {
+				return nil, err
+			}
+			if localPath != "" {
+				detached.Files[target] = localPath
+			}
+		}
+	}
+
+	if len(service.Environment) > 0 {
+		detached.EnvFile = filepath.Join(detached.Dir, serviceName+".env")
+		if err := os.WriteFile(detached.EnvFile, []byte(toLocal(formatEnvFile(service.Environment))), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	args := command.args
+	if args == "" {
+		args = service.Command
+	}
+	detached.Command = strings.TrimSpace(command.binary + " " + replaceContainerPaths(args, detached.Files))
+
+	running, err := s.isAnyServiceRunning(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	if running {
+		s.Log.Info(fmt.Sprintf("removing container for '%s'", serviceName))
+		if err := s.runDockerComposeCommand("rm", "--stop", "--force", serviceName); err != nil {
+			return nil, err
+		}
+	}
+
+	s.Stack.DetachedServices = append(s.Stack.DetachedServices, serviceName)
+	changed, err := s.rewriteDetachedServiceRefs(oldCompose, serviceName, detached.Ports, true)
+	if err != nil {
+		return nil, err
+	}
+	return detached, s.updateDetachedServices(oldCompose, changed, running)
+}
+
+// AttachService puts a service that was taken out of the stack by DetachService back into
+// the docker compose file, and changes every other service to use its container again
+func (s *StackManager) AttachService(serviceName string) error {
+	if !s.Stack.IsDetached(serviceName) {
+		return fmt.Errorf("service '%s' is not detached", serviceName)
+	}
+	oldCompose := s.buildDockerCompose()
+	detachedServices := []string{}
+	for _, detached := range s.Stack.DetachedServices {
+		if detached != serviceName {
+			detachedServices = append(detachedServices, detached)
+		}
+	}
+	s.Stack.DetachedServices = detachedServices
+
+	service, ok := s.buildStackServices().Services[serviceName]
+	if !ok {
+		return fmt.Errorf("service '%s' is not part of the FireFly stack '%s'", serviceName, s.Stack.Name)
+	}
+	changed, err := s.rewriteDetachedServiceRefs(oldCompose, serviceName, parsePortMappings(service.Ports), false)
+	if err != nil {
+		return err
+	}
+	running, err := s.isAnyServiceRunning(s.ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.updateDetachedServices(oldCompose, append(changed, serviceName), running); err != nil {
+		return err
+	}
+	return os.RemoveAll(s.devServiceDir(serviceName))
+}
+
+// rewriteDetachedServiceRefs changes the config files of every service in the stack that
+// refers to a detached service, so that they use its port on the host (or back again, if
+// the service is being attached). It returns the names of the services that were changed.
+func (s *StackManager) rewriteDetachedServiceRefs(compose *docker.DockerComposeConfig, serviceName string, ports map[int]int, detach bool) ([]string, error) {
+	changed := []string{}
+	for name, service := range compose.Services {
+		if name == serviceName {
+			continue
+		}
+		for _, volume := range service.Volumes {
+			source, _ := parseVolumeMount(volume)
+			if !s.isStackFile(source) {
+				continue
+			}
+			b, err := os.ReadFile(source)
+			if err != nil {
+				return nil, err
+			}
+			var text string
+			if detach {
+				var unpublished []int
+				text, unpublished = rewriteServiceRefs(string(b), serviceName, ports, devHostGateway)
+				for _, port := range unpublished {
+					s.Log.Warn(fmt.Sprintf("'%s' uses port %d of '%s', which is not published on the host", name, port, serviceName))
+				}
+			} else {
+				text = restoreServiceRefs(string(b), serviceName, ports, devHostGateway)
+			}
+			if text == string(b) {
+				continue
+			}
+			if err := os.WriteFile(source, []byte(text), 0644); err != nil {
+				return nil, err
+			}
+			changed = append(changed, name)
+		}
+	}
+	return changed, nil
+}
+
+// updateDetachedServices writes the stack config and docker compose file after a service
+// has been detached or attached, and recreates any affected containers if the stack is running
+func (s *StackManager) updateDetachedServices(oldCompose *docker.DockerComposeConfig, changed []string, running bool) error {
+	if err := s.writeStackConfig(); err != nil {
+		return err
+	}
+	newCompose := s.buildDockerCompose()
+	changes, err := diffDockerCompose(oldCompose, newCompose)
+	if err != nil {
+		return err
+	}
+	if err := s.writeDockerCompose(newCompose); err != nil {
+		return err
+	}
+	if !running {
+		return nil
+	}
+
+	services := map[string]bool{}
+	for _, change := range changes {
+		if change.Change != ServiceRemoved {
+			services[change.Service] = true
+		}
+	}
+	for _, name := range changed {
+		if _, ok := newCompose.Services[name]; ok {
+			services[name] = true
+		}
+	}
+	if len(services) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s.Log.Info(fmt.Sprintf("recreating %s", strings.Join(names, ", ")))
+	return s.runDockerComposeCommand(append([]string{"up", "-d", "--no-deps", "--force-recreate"}, names...)...)
+}
+
+// applyDetachedServices removes the detached services from a docker compose file, and
+// changes every other service to reach them through their ports on the host
+func (s *StackManager) applyDetachedServices(compose *docker.DockerComposeConfig) {
+	if len(s.Stack.DetachedServices) == 0 {
+		return
+	}
+	for _, serviceName := range s.Stack.DetachedServices {
+		detached, ok := compose.Services[serviceName]
+		if !ok {
+			continue
+		}
+		delete(compose.Services, serviceName)
+		ports := parsePortMappings(detached.Ports)
+		for _, service := range compose.Services {
+			delete(service.DependsOn, serviceName)
+			if len(service.Environment) == 0 {
+				continue
+			}
+			environment := make(map[string]interface{}, len(service.Environment))
+			for k, v := range service.Environment {
+				if str, ok := v.(string); ok {
+					v, _ = rewriteServiceRefs(str, serviceName, ports, devHostGateway)
+				}
+				environment[k] = v
+			}
+			service.Environment = environment
+		}
+	}
+	for _, service := range compose.Services {
+		hasGateway := false
+		for _, host := range service.ExtraHosts {
+			if host == devHostGatewayMap {
+				hasGateway = true
+			}
+		}
+		if !hasGateway {
+			service.ExtraHosts = append(service.ExtraHosts, devHostGatewayMap)
+		}
+	}
+}
+
+// ensureDetachedServicesUp waits for a local build of each detached service to be started
+func (s *StackManager) ensureDetachedServicesUp() error {
+	compose := s.buildStackServices()
+	for _, serviceName := range s.Stack.DetachedServices {
+		if strings.HasPrefix(serviceName, "firefly_core_") {
+			// FireFly core is handled along with the external members
+			continue
+		}
+		service, ok := compose.Services[serviceName]
+		if !ok {
+			continue
+		}
+		port := firstHostPort(parsePortMappings(service.Ports))
+		if port == 0 {
+			continue
+		}
+		available, err := checkPortAvailable(port)
+		if err != nil {
+			return err
+		}
+		if available {
+			s.Log.Info(fmt.Sprintf("please start your local build of %s on port %d, using the files in %s", serviceName, port, s.devServiceDir(serviceName)))
+			if err := s.waitForServiceStart(serviceName, port); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *StackManager) isStackFile(path string) bool {
+	if !filepath.IsAbs(path) || !strings.HasPrefix(path, s.Stack.StackDir+string(os.PathSeparator)) {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+func (s *StackManager) exportDevVolume(volumeName, dir string) (string, error) {
+	fullVolumeName := fmt.Sprintf("%s_%s", s.Stack.Name, volumeName)
+	exists, err := docker.VolumeExists(s.ctx, fullVolumeName)
+	if err != nil || !exists {
+		return "", err
+	}
+	s.Log.Info(fmt.Sprintf("copying the contents of volume '%s'", fullVolumeName))
+	if err := docker.ExportVolume(s.ctx, fullVolumeName, dir, volumeName+".tar"); err != nil {
+		return "", err
+	}
+	tarPath := filepath.Join(dir, volumeName+".tar")
+	defer os.Remove(tarPath)
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	localPath := filepath.Join(dir, volumeName)
+	if err := os.MkdirAll(localPath, 0755); err != nil {
+		return "", err
+	}
+	return localPath, extractTar(f, localPath)
+}
+
+// parsePortMappings returns a map of container ports to host ports, from the
+// ports section of a docker compose service
+func parsePortMappings(ports []string) map[int]int {
+	mappings := map[int]int{}
+	for _, port := range ports {
+		parts := strings.Split(port, ":")
+		if len(parts) < 2 {
+			continue
+		}
+		hostPort, err := strconv.Atoi(parts[len(parts)-2])
+		if err != nil {
+			continue
+		}
+		containerPort, err := strconv.Atoi(strings.SplitN(parts[len(parts)-1], "/", 2)[0])
+		if err != nil {
+			continue
+		}
+		mappings[containerPort] = hostPort
+	}
+	return mappings
+}
+
+func firstHostPort(ports map[int]int) int {
+	containerPorts := make([]int, 0, len(ports))
+	for containerPort := range ports {
+		containerPorts = append(containerPorts, containerPort)
+	}
+	if len(containerPorts) == 0 {
+		return 0
+	}
+	sort.Ints(containerPorts)
+	return ports[containerPorts[0]]
+}
+
+// parseVolumeMount splits a docker compose volume into its source and target
+func parseVolumeMount(volume string) (source, target string) {
+	volume = strings.TrimSuffix(strings.TrimSuffix(volume, ":ro"), ":rw")
+	idx := strings.LastIndex(volume, ":")
+	if idx < 0 {
+		return "", volume
+	}
+	return volume[:idx], volume[idx+1:]
+}
+
+func serviceRefRegexp(host string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^A-Za-z0-9_.-])` + regexp.QuoteMeta(host) + `:(\d+)`)
+}
+
+// rewriteServiceRefs replaces each "service:port" in the text with "host:port", using
+// the port the service publishes on the host. The ports that are used, but not
+// published, are returned.
+func rewriteServiceRefs(text, serviceName string, ports map[int]int, host string) (string, []int) {
+	unpublished := []int{}
+	text = serviceRefRegexp(serviceName).ReplaceAllStringFunc(text, func(match string) string {
+		idx := strings.LastIndex(match, serviceName+":")
+		port, _ := strconv.Atoi(match[idx+len(serviceName)+1:])
+		hostPort, ok := ports[port]
+		if !ok {
+			unpublished = append(unpublished, port)
+			return match
+		}
+		return fmt.Sprintf("%s%s:%d", match[:idx], host, hostPort)
+	})
+	return text, unpublished
+}
+
+// restoreServiceRefs reverses rewriteServiceRefs
+func restoreServiceRefs(text, serviceName string, ports map[int]int, host string) string {
+	containerPorts := map[int]int{}
+	for containerPort, hostPort := range ports {
+		containerPorts[hostPort] = containerPort
+	}
+	return serviceRefRegexp(host).ReplaceAllStringFunc(text, func(match string) string {
+		idx := strings.LastIndex(match, host+":")
+		port, _ := strconv.Atoi(match[idx+len(host)+1:])
+		containerPort, ok := containerPorts[port]
+		if !ok {
+			return match
+		}
+		return fmt.Sprintf("%s%s:%d", match[:idx], serviceName, containerPort)
+	})
+}
+
+func replaceContainerPaths(text string, files map[string]string) string {
+	targets := make([]string, 0, len(files))
+	for target := range files {
+		targets = append(targets, target)
+	}
+	// Prefer the longest paths, in case one is a prefix of another
+	sort.Slice(targets, func(i, j int) bool { return len(targets[i]) > len(targets[j]) })
+	oldnew := make([]string, 0, len(targets)*2)
+	for _, target := range targets {
+		oldnew = append(oldnew, target, files[target])
+	}
+	return strings.NewReplacer(oldnew...).Replace(text)
+}
+
+func formatEnvFile(environment map[string]interface{}) string {
+	keys := make([]string, 0, len(environment))
+	for k := range environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("%s=%v\n", k, environment[k]))
+	}
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+package stacks
+
+import (
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePortMappings(t *testing.T) {
+	ports := parsePortMappings([]string{"5102:5008", "127.0.0.1:5104:5432", "9090:9090/tcp", "bad"})
+	assert.Equal(t, map[int]int{5008: 5102, 5432: 5104, 9090: 9090}, ports)
+	assert.Equal(t, 5102, firstHostPort(ports))
+	assert.Equal(t, 0, firstHostPort(map[int]int{}))
+}
+
+func TestParseVolumeMount(t *testing.T) {
+	source, target := parseVolumeMount("/stacks/dev/runtime/config/firefly_core_0.yml:/etc/firefly/firefly.core.yml:ro")
+	assert.Equal(t, "/stacks/dev/runtime/config/firefly_core_0.yml", source)
+	assert.Equal(t, "/etc/firefly/firefly.core.yml", target)
+
+	source, target = parseVolumeMount("dataexchange_0:/data")
+	assert.Equal(t, "dataexchange_0", source)
+	assert.Equal(t, "/data", target)
+}
+
+func TestRewriteServiceRefs(t *testing.T) {
+	config := "connector:\n  url: http://evmconnect_0:5008\n  other: http://evmconnect_01:5008\nmetrics: evmconnect_0:6000\n"
+	ports := map[int]int{5008: 5102}
+
+	rewritten, unpublished := rewriteServiceRefs(config, "evmconnect_0", ports, devHostGateway)
+	assert.Equal(t, "connector:\n  url: http://host.docker.internal:5102\n  other: http://evmconnect_01:5008\nmetrics: evmconnect_0:6000\n", rewritten)
+	assert.Equal(t, []int{6000}, unpublished)
+
+	assert.Equal(t, config, restoreServiceRefs(rewritten, "evmconnect_0", ports, devHostGateway))
+}
+
+func TestApplyDetachedServices(t *testing.T) {
+	s := &StackManager{Stack: &types.Stack{DetachedServices: []string{"evmconnect_0"}}}
+	compose := &docker.DockerComposeConfig{
+		Services: map[string]*docker.Service{
+			"evmconnect_0": {Ports: []string{"5102:5008"}},
+			"tokens_0_0": {
+				Environment: map[string]interface{}{"ETHCONNECT_URL": "http://evmconnect_0:5008", "AUTO_INIT": "false"},
+				DependsOn:   map[string]map[string]string{"evmconnect_0": {"condition": "service_started"}},
+			},
+		},
+	}
+	s.applyDetachedServices(compose)
+
+	assert.NotContains(t, compose.Services, "evmconnect_0")
+	tokens := compose.Services["tokens_0_0"]
+	assert.Empty(t, tokens.DependsOn)
+	assert.Equal(t, "http://host.docker.internal:5102", tokens.Environment["ETHCONNECT_URL"])
+	assert.Equal(t, "false", tokens.Environment["AUTO_INIT"])
+	assert.Equal(t, []string{devHostGatewayMap}, tokens.ExtraHosts)
+}
+
+func TestReplaceContainerPaths(t *testing.T) {
+	files := map[string]string{
+		"/evmconnect/config.yaml": "/stacks/dev/dev/evmconnect_0/evmconnect_0.yaml",
+		"/evmconnect":             "/other",
+	}
+	assert.Equal(t, "-f /stacks/dev/dev/evmconnect_0/evmconnect_0.yaml", replaceContainerPaths("-f /evmconnect/config.yaml", files))
+}
+
+func TestFormatEnvFile(t *testing.T) {
+	assert.Equal(t, "A=1\nB=two\n", formatEnvFile(map[string]interface{}{"B": "two", "A": 1}))
+}
@@ -0,0 +1,476 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/firefly-cli/internal/constants"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+)
+
+const (
+	minDockerMajorVersion = 20
+	freeDiskSpaceWarn     = 10 << 30
+	freeDiskSpaceFail     = 2 << 30
+)
+
+// Every stack that has been started has a FireFly core data volume for each member, so these
+// are used to find the names of stacks that have been deleted without removing their volumes
+var coreDataVolumeRegex = regexp.MustCompile(`^(.+)_firefly_core_data_[^_]+$`)
+
+// RunDoctorChecks checks that the local environment is able to create and run FireFly stacks,
+// and that the existing stacks are not in conflict with each other or with other processes
+func RunDoctorChecks(ctx context.Context) []*types.DoctorCheck {
+	dockerCheck := checkDockerDaemon(ctx)
+	dockerAvailable := dockerCheck.Result != types.DoctorResultFail
+	checks := []*types.DoctorCheck{
+		dockerCheck,
+		checkDockerCompose(ctx),
+		checkFireFlyHome(constants.StacksDir),
+		checkDiskSpace(constants.StacksDir),
+	}
+
+	stackManagers, loadChecks := loadAllStacks(ctx)
+	checks = append(checks, loadChecks...)
+	checks = append(checks,
+		checkArchitecture(runtime.GOARCH, stackManagers),
+		checkStackPortConflicts(stackManagers),
+		checkPortsInUse(ctx, stackManagers, dockerAvailable),
+		checkOrphanedVolumes(ctx, stackManagers, dockerAvailable),
+	)
+	return checks
+}
+
+func runDoctorCommand(ctx context.Context, name string, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+func checkDockerDaemon(ctx context.Context) *types.DoctorCheck {
+	if docker.Engine != docker.EngineDocker {
+		return checkContainerEngine(ctx, docker.Engine)
+	}
+	check := &types.DoctorCheck{Name: "Docker"}
+	clientVersion, err := runDoctorCommand(ctx, "docker", "version", "--format", "{{.Client.Version}}")
+	if err != nil || clientVersion == "" {
+		check.Result = types.DoctorResultFail
+		check.Message = "docker is not installed, or is not on the PATH"
+		check.Remediation = "Install Docker Desktop or Docker Engine - see https://docs.docker.com/get-docker/"
+		return check
+	}
+	serverVersion, err := runDoctorCommand(ctx, "docker", "version", "--format", "{{.Server.Version}}")
+	if err != nil || serverVersion == "" {
+		check.Result = types.DoctorResultFail
+		check.Message = fmt.Sprintf("docker client %s is installed, but the docker daemon is not running or cannot be reached", clientVersion)
+		check.Remediation = "Start Docker, and check that your user has permission to use the docker socket"
+		return check
+	}
+	if majorVersion(serverVersion) < minDockerMajorVersion {
+		check.Result = types.DoctorResultWarn
+		check.Message = fmt.Sprintf("docker %s is older than the minimum tested version %d.10", serverVersion, minDockerMajorVersion)
+		check.Remediation = "Upgrade Docker to a recent version"
+		return check
+	}
+	check.Result = types.DoctorResultPass
+	check.Message = fmt.Sprintf("docker %s is running", serverVersion)
+	return check
+}
+
+// checkContainerEngine checks an engine other than docker, which has no server version to check
+func checkContainerEngine(ctx context.Context, engine docker.ContainerEngine) *types.DoctorCheck {
+	check := &types.DoctorCheck{Name: "Container engine"}
+	version, err := runDoctorCommand(ctx, string(engine), "--version")
+	if err != nil || version == "" {
+		check.Result = types.DoctorResultFail
+		check.Message = fmt.Sprintf("%s is not installed, or is not on the PATH", engine)
+		check.Remediation = fmt.Sprintf("Install %s, or set %s to the container engine you use", engine, docker.EngineEnvVar)
+		return check
+	}
+	if _, err := runDoctorCommand(ctx, string(engine), "info"); err != nil {
+		check.Result = types.DoctorResultFail
+		check.Message = fmt.Sprintf("%s is installed, but is not able to run containers", version)
+		check.Remediation = fmt.Sprintf("Run '%s info' to find out what is wrong", engine)
+		return check
+	}
+	check.Result = types.DoctorResultPass
+	check.Message = fmt.Sprintf("%s is running", version)
+	return check
+}
+
+func checkDockerCompose(ctx context.Context) *types.DoctorCheck {
+	if docker.Engine != docker.EngineDocker {
+		return checkEngineCompose(ctx, docker.Engine)
+	}
+	check := &types.DoctorCheck{Name: "Docker Compose"}
+	if version, err := runDoctorCommand(ctx, "docker", "compose", "version", "--short"); err == nil {
+		check.Result = types.DoctorResultPass
+		check.Message = fmt.Sprintf("docker compose %s", version)
+		return check
+	}
+	if version, err := runDoctorCommand(ctx, "docker-compose", "version", "--short"); err == nil {
+		check.Result = types.DoctorResultWarn
+		check.Message = fmt.Sprintf("only the standalone docker-compose %s was found, which is no longer maintained", version)
+		check.Remediation = "Install the Docker Compose v2 plugin - see https://docs.docker.com/compose/install/"
+		return check
+	}
+	check.Result = types.DoctorResultFail
+	check.Message = "docker compose is not installed"
+	check.Remediation = "Install the Docker Compose v2 plugin - see https://docs.docker.com/compose/install/"
+	return check
+}
+
+func checkEngineCompose(ctx context.Context, engine docker.ContainerEngine) *types.DoctorCheck {
+	check := &types.DoctorCheck{Name: "Compose"}
+	if version, err := runDoctorCommand(ctx, string(engine), "compose", "version"); err == nil {
+		check.Result = types.DoctorResultPass
+		check.Message = fmt.Sprintf("%s compose: %s", engine, firstLine(version))
+		return check
+	}
+	if composeCommand := engine.ComposeCommand(); composeCommand != "" {
+		if version, err := runDoctorCommand(ctx, composeCommand, "version"); err == nil {
+			check.Result = types.DoctorResultPass
+			check.Message = fmt.Sprintf("%s: %s", composeCommand, firstLine(version))
+			return check
+		}
+	}
+	check.Result = types.DoctorResultFail
+	check.Message = fmt.Sprintf("no compose command was found for %s", engine)
+	check.Remediation = fmt.Sprintf("Install a compose provider for %s, such as podman-compose or docker-compose", engine)
+	return check
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+func checkFireFlyHome(stacksDir string) *types.DoctorCheck {
+	check := &types.DoctorCheck{Name: "FireFly home"}
+	dir, err := nearestExistingDir(stacksDir)
+	if err != nil {
+		check.Result = types.DoctorResultFail
+		check.Message = err.Error()
+		check.Remediation = "Set FIREFLY_HOME to a directory you can write to"
+		return check
+	}
+	f, err := os.CreateTemp(dir, ".ff-doctor-")
+	if err != nil {
+		check.Result = types.DoctorResultFail
+		check.Message = fmt.Sprintf("%s is not writable: %s", dir, err)
+		check.Remediation = fmt.Sprintf("Check the owner and permissions of %s, or set FIREFLY_HOME to a directory you can write to", dir)
+		return check
+	}
+	f.Close()
+	os.Remove(f.Name())
+	check.Result = types.DoctorResultPass
+	if dir == stacksDir {
+		check.Message = fmt.Sprintf("%s is writable", stacksDir)
+	} else {
+		check.Message = fmt.Sprintf("%s does not exist yet, and can be created in %s", stacksDir, dir)
+	}
+	return check
+}
+
+func checkDiskSpace(stacksDir string) *types.DoctorCheck {
+	check := &types.DoctorCheck{Name: "Disk space"}
+	dir, err := nearestExistingDir(stacksDir)
+	if err == nil {
+		var free uint64
+		if free, err = getFreeDiskSpace(dir); err == nil {
+			check.Message = fmt.Sprintf("%.1f GiB free in %s", float64(free)/(1<<30), dir)
+			switch {
+			case free < freeDiskSpaceFail:
+				check.Result = types.DoctorResultFail
+			case free < freeDiskSpaceWarn:
+				check.Result = types.DoctorResultWarn
+			default:
+				check.Result = types.DoctorResultPass
+				return check
+			}
+			check.Remediation = "Free up disk space - a stack needs several GiB for images and volumes. 'docker system prune' removes unused images and containers"
+			return check
+		}
+	}
+	check.Result = types.DoctorResultWarn
+	check.Message = fmt.Sprintf("unable to check free disk space: %s", err)
+	return check
+}
+
+// nearestExistingDir returns dir if it exists, or otherwise the closest parent of dir that does
+func nearestExistingDir(dir string) (string, error) {
+	for {
+		info, err := os.Stat(dir)
+		switch {
+		case err == nil && info.IsDir():
+			return dir, nil
+		case err == nil:
+			return "", fmt.Errorf("%s is not a directory", dir)
+		case !os.IsNotExist(err):
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no parent directory of %s exists", dir)
+		}
+		dir = parent
+	}
+}
+
+func loadAllStacks(ctx context.Context) ([]*StackManager, []*types.DoctorCheck) {
+	stackNames, err := ListStacks()
+	if errors.Is(err, os.ErrNotExist) {
+		return []*StackManager{}, nil
+	} else if err != nil {
+		return []*StackManager{}, []*types.DoctorCheck{{
+			Name:    "Stacks",
+			Result:  types.DoctorResultFail,
+			Message: fmt.Sprintf("unable to list stacks: %s", err),
+		}}
+	}
+	stackManagers := make([]*StackManager, 0, len(stackNames))
+	checks := []*types.DoctorCheck{}
+	for _, stackName := range stackNames {
+		s := NewStackManager(ctx)
+		if err := s.LoadStack(stackName); err != nil {
+			checks = append(checks, &types.DoctorCheck{
+				Name:        "Stacks",
+				Result:      types.DoctorResultFail,
+				Message:     fmt.Sprintf("stack '%s' could not be loaded: %s", stackName, err),
+				Remediation: fmt.Sprintf("Fix or remove %s", filepath.Join(constants.StacksDir, stackName, "stack.json")),
+			})
+			continue
+		}
+		stackManagers = append(stackManagers, s)
+	}
+	return stackManagers, checks
+}
+
+func checkArchitecture(goarch string, stackManagers []*StackManager) *types.DoctorCheck {
+	check := &types.DoctorCheck{Name: "Architecture"}
+	if goarch != "arm64" {
+		check.Result = types.DoctorResultPass
+		check.Message = goarch
+		return check
+	}
+	problems := []string{}
+	for _, s := range stackManagers {
+		compose, err := s.readDockerCompose()
+		if err != nil {
+			continue
+		}
+		for _, service := range compose.Services {
+			if unsupportedARM64Images[service.Image] {
+				problems = append(problems, fmt.Sprintf("stack '%s' uses %s", s.Stack.Name, service.Image))
+			}
+		}
+	}
+	if len(problems) == 0 {
+		check.Result = types.DoctorResultPass
+		check.Message = "arm64, and no stacks use images that are only available for amd64"
+		return check
+	}
+	sort.Strings(problems)
+	check.Result = types.DoctorResultWarn
+	check.Message = fmt.Sprintf("arm64, and some images are only available for amd64: %s", strings.Join(problems, "; "))
+	check.Remediation = "These images run under emulation - make sure emulation of amd64 (for example Rosetta) is enabled in Docker"
+	return check
+}
+
+// findPortConflicts returns a description of every port that is used by more than one stack
+func findPortConflicts(stackPorts map[string][]int) []string {
+	stacksByPort := make(map[int][]string)
+	for stackName, ports := range stackPorts {
+		for _, port := range ports {
+			stacksByPort[port] = append(stacksByPort[port], stackName)
+		}
+	}
+	conflictingPorts := []int{}
+	for port, stackNames := range stacksByPort {
+		if len(stackNames) > 1 {
+			conflictingPorts = append(conflictingPorts, port)
+		}
+	}
+	sort.Ints(conflictingPorts)
+	conflicts := make([]string, 0, len(conflictingPorts))
+	for _, port := range conflictingPorts {
+		stackNames := stacksByPort[port]
+		sort.Strings(stackNames)
+		conflicts = append(conflicts, fmt.Sprintf("%d (%s)", port, strings.Join(stackNames, ", ")))
+	}
+	return conflicts
+}
+
+func checkStackPortConflicts(stackManagers []*StackManager) *types.DoctorCheck {
+	check := &types.DoctorCheck{Name: "Stack ports"}
+	stackPorts := make(map[string][]int, len(stackManagers))
+	for _, s := range stackManagers {
+		stackPorts[s.Stack.Name] = getStackPorts(s.Stack)
+	}
+	conflicts := findPortConflicts(stackPorts)
+	if len(conflicts) == 0 {
+		check.Result = types.DoctorResultPass
+		check.Message = fmt.Sprintf("no ports are shared between the %d stacks", len(stackManagers))
+		return check
+	}
+	check.Result = types.DoctorResultWarn
+	check.Message = fmt.Sprintf("some ports are used by more than one stack: %s", strings.Join(conflicts, "; "))
+	check.Remediation = "Stacks that share ports cannot run at the same time. Stop one before starting another, or create stacks with different --firefly-base-port and --services-base-port values"
+	return check
+}
+
+func checkPortsInUse(ctx context.Context, stackManagers []*StackManager, dockerAvailable bool) *types.DoctorCheck {
+	check := &types.DoctorCheck{Name: "Ports in use"}
+	if !dockerAvailable {
+		check.Result = types.DoctorResultWarn
+		check.Message = fmt.Sprintf("skipped, as %s is not available to tell which stacks are running", docker.Engine)
+		return check
+	}
+	problems := []string{}
+	for _, s := range stackManagers {
+		running, err := s.isAnyServiceRunning(ctx)
+		if err != nil || running {
+			// The ports of a running stack are expected to be in use
+			continue
+		}
+		for _, port := range getStackPorts(s.Stack) {
+			if available, err := checkPortAvailable(port); err == nil && !available {
+				problems = append(problems, fmt.Sprintf("%d (%s)", port, s.Stack.Name))
+			}
+		}
+	}
+	if len(problems) == 0 {
+		check.Result = types.DoctorResultPass
+		check.Message = "the ports of every stopped stack are free"
+		return check
+	}
+	check.Result = types.DoctorResultFail
+	check.Message = fmt.Sprintf("ports needed by stopped stacks are in use by other processes: %s", strings.Join(problems, "; "))
+	check.Remediation = "Stop the processes listening on these ports before starting the stacks that need them"
+	return check
+}
+
+func (s *StackManager) isAnyServiceRunning(ctx context.Context) (bool, error) {
+	containers, err := docker.GetContainerStates(ctx, s.Stack.StackDir)
+	if err != nil {
+		return false, err
+	}
+	for _, container := range containers {
+		if container.State == "running" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// findOrphanedVolumes returns the volumes that belong to a FireFly stack, going by their name,
+// but are not used by any existing stack. expectedVolumes maps each stack name to the full names
+// of the volumes it uses.
+func findOrphanedVolumes(expectedVolumes map[string][]string, volumes []string) []string {
+	owners := make(map[string]bool)
+	expected := make(map[string]bool)
+	for stackName, stackVolumes := range expectedVolumes {
+		owners[stackName] = true
+		for _, volumeName := range stackVolumes {
+			expected[volumeName] = true
+		}
+	}
+	deletedStacks := make(map[string]bool)
+	for _, volumeName := range volumes {
+		if match := coreDataVolumeRegex.FindStringSubmatch(volumeName); match != nil && !owners[match[1]] {
+			deletedStacks[match[1]] = true
+		}
+	}
+	for stackName := range deletedStacks {
+		owners[stackName] = true
+	}
+
+	orphans := []string{}
+	for _, volumeName := range volumes {
+		// Stack names can contain underscores, so the longest matching stack name owns the volume
+		owner := ""
+		for stackName := range owners {
+			if strings.HasPrefix(volumeName, stackName+"_") && len(stackName) > len(owner) {
+				owner = stackName
+			}
+		}
+		if owner == "" {
+			continue
+		}
+		if deletedStacks[owner] || !expected[volumeName] {
+			orphans = append(orphans, volumeName)
+		}
+	}
+	sort.Strings(orphans)
+	return orphans
+}
+
+func checkOrphanedVolumes(ctx context.Context, stackManagers []*StackManager, dockerAvailable bool) *types.DoctorCheck {
+	check := &types.DoctorCheck{Name: "Volumes"}
+	if !dockerAvailable {
+		check.Result = types.DoctorResultWarn
+		check.Message = fmt.Sprintf("skipped, as %s is not available", docker.Engine)
+		return check
+	}
+	output, err := runDoctorCommand(ctx, string(docker.Engine), "volume", "ls", "--format", "{{.Name}}")
+	if err != nil {
+		check.Result = types.DoctorResultWarn
+		check.Message = fmt.Sprintf("unable to list docker volumes: %s", err)
+		return check
+	}
+	volumes := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			volumes = append(volumes, line)
+		}
+	}
+	expectedVolumes := make(map[string][]string, len(stackManagers))
+	for _, s := range stackManagers {
+		for _, volumeName := range s.getVolumeNames() {
+			expectedVolumes[s.Stack.Name] = append(expectedVolumes[s.Stack.Name], fmt.Sprintf("%s_%s", s.Stack.Name, volumeName))
+		}
+	}
+	orphans := findOrphanedVolumes(expectedVolumes, volumes)
+	if len(orphans) == 0 {
+		check.Result = types.DoctorResultPass
+		check.Message = "no orphaned stack volumes"
+		return check
+	}
+	check.Result = types.DoctorResultWarn
+	check.Message = fmt.Sprintf("%d volumes are not used by any stack: %s", len(orphans), strings.Join(orphans, ", "))
+	check.Remediation = fmt.Sprintf("If the data is no longer needed, run: docker volume rm %s", strings.Join(orphans, " "))
+	return check
+}
+
+func majorVersion(version string) int {
+	major, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(version, "v"), ".", 2)[0])
+	if err != nil {
+		return 0
+	}
+	return major
+}
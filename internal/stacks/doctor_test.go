@@ -0,0 +1,75 @@
+package stacks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindOrphanedVolumes(t *testing.T) {
+	expected := map[string][]string{
+		"dev":   {"dev_firefly_core_data_0", "dev_postgres_0"},
+		"dev_2": {"dev_2_firefly_core_data_0"},
+	}
+	volumes := []string{
+		"dev_firefly_core_data_0",
+		"dev_postgres_0",
+		"dev_firefly_core_data_1", // a member that has been removed
+		"dev_2_firefly_core_data_0",
+		"old_firefly_core_data_0", // a stack that has been removed
+		"old_ipfs_0",
+		"unrelated_volume",
+	}
+	assert.Equal(t, []string{
+		"dev_firefly_core_data_1",
+		"old_firefly_core_data_0",
+		"old_ipfs_0",
+	}, findOrphanedVolumes(expected, volumes))
+}
+
+func TestFindPortConflicts(t *testing.T) {
+	conflicts := findPortConflicts(map[string][]int{
+		"a": {5000, 5001, 5100},
+		"b": {5000, 5002},
+		"c": {6000, 5000, 5100},
+	})
+	assert.Equal(t, []string{"5000 (a, b, c)", "5100 (a, c)"}, conflicts)
+	assert.Empty(t, findPortConflicts(map[string][]int{"a": {5000}, "b": {6000}}))
+}
+
+func TestCheckArchitecture(t *testing.T) {
+	check := checkArchitecture("amd64", nil)
+	assert.Equal(t, types.DoctorResultPass, check.Result)
+	check = checkArchitecture("arm64", nil)
+	assert.Equal(t, types.DoctorResultPass, check.Result)
+}
+
+func TestCheckFireFlyHome(t *testing.T) {
+	dir := t.TempDir()
+	check := checkFireFlyHome(dir)
+	assert.Equal(t, types.DoctorResultPass, check.Result)
+
+	check = checkFireFlyHome(filepath.Join(dir, "missing", "stacks"))
+	assert.Equal(t, types.DoctorResultPass, check.Result)
+	assert.Contains(t, check.Message, "does not exist yet")
+
+	file := filepath.Join(dir, "file")
+	assert.NoError(t, os.WriteFile(file, []byte{}, 0755))
+	check = checkFireFlyHome(file)
+	assert.Equal(t, types.DoctorResultFail, check.Result)
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	check := checkDiskSpace(t.TempDir())
+	assert.NotEmpty(t, check.Result)
+	assert.Contains(t, check.Message, "GiB free")
+}
+
+func TestMajorVersion(t *testing.T) {
+	assert.Equal(t, 24, majorVersion("24.0.7"))
+	assert.Equal(t, 2, majorVersion("v2.24.6"))
+	assert.Equal(t, 0, majorVersion("unknown"))
+}
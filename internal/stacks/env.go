@@ -0,0 +1,106 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"fmt"
+)
+
+// EnvVar is one of the stack's endpoints, in the form of an environment variable
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// GetStackEnvironment returns every endpoint a client of the stack needs as environment
+// variables. If memberID is empty, the variables for every member are included with an
+// FF_MEMBER_<id>_ prefix, which does not change when other members are removed. Otherwise
+// only the member with that ID is included, with an FF_ prefix.
+func (s *StackManager) GetStackEnvironment(memberID string) ([]*EnvVar, error) {
+	if memberID != "" {
+		found := false
+		for _, member := range s.Stack.Members {
+			if member != nil && member.ID == memberID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("member '%s' does not exist in stack '%s'", memberID, s.Stack.Name)
+		}
+	}
+	env := []*EnvVar{
+		{"FF_STACK_NAME", s.Stack.Name},
+		{"FF_STACK_DIR", s.Stack.StackDir},
+		{"FF_MEMBER_COUNT", fmt.Sprint(len(s.Stack.Members))},
+	}
+	if rpcURL := s.getRPCURL(); rpcURL != "" {
+		env = append(env, &EnvVar{"FF_RPC_URL", rpcURL})
+	}
+	if s.Stack.PrometheusEnabled && s.Stack.ExposedPrometheusPort > 0 {
+		env = append(env, &EnvVar{"FF_PROMETHEUS_URL", localURL(s.Stack.ExposedPrometheusPort)})
+	}
+
+	for _, member := range s.Stack.Members {
+		if member == nil || (memberID != "" && member.ID != memberID) {
+			// A member is nil when this is called for a pre-init hook
+			continue
+		}
+		prefix := fmt.Sprintf("FF_MEMBER_%s_", member.ID)
+		if memberID != "" {
+			prefix = "FF_"
+		}
+		add := func(name, value string) {
+			env = append(env, &EnvVar{prefix + name, value})
+		}
+		add("ORG_NAME", member.OrgName)
+		add("NODE_NAME", member.NodeName)
+		if member.Account != nil && s.blockchainProvider != nil {
+			if orgConfig := s.blockchainProvider.GetOrgConfig(s.Stack, member); orgConfig != nil && orgConfig.Key != "" {
+				add("SIGNING_KEY", orgConfig.Key)
+			}
+		}
+		add("FIREFLY_URL", localURL(member.ExposedFireflyPort))
+		add("FIREFLY_UI_URL", localURL(member.ExposedFireflyPort)+"/ui")
+		if member.ExposedFireflyAdminSPIPort > 0 {
+			add("FIREFLY_SPI_URL", localURL(member.ExposedFireflyAdminSPIPort))
+		}
+		if member.ExposedFireflyMetricsPort > 0 {
+			add("FIREFLY_METRICS_URL", localURL(member.ExposedFireflyMetricsPort))
+		}
+		if s.blockchainProvider != nil {
+			add("CONNECTOR_URL", s.blockchainProvider.GetConnectorExternalURL(member))
+		}
+		add("IPFS_API_URL", localURL(member.ExposedIPFSApiPort))
+		add("IPFS_GATEWAY_URL", localURL(member.ExposedIPFSGWPort))
+		add("DATAEXCHANGE_URL", localURL(member.ExposedDataexchangePort))
+		for j, port := range member.ExposedTokensPorts {
+			add(fmt.Sprintf("TOKENS_%d_URL", j), localURL(port))
+			if j < len(s.Stack.TokenProviders) {
+				add(fmt.Sprintf("TOKENS_%d_PROVIDER", j), s.Stack.TokenProviders[j].String())
+			}
+		}
+		if s.Stack.SandboxEnabled && member.ExposedSandboxPort > 0 {
+			add("SANDBOX_URL", localURL(member.ExposedSandboxPort))
+		}
+	}
+	return env, nil
+}
+
+func localURL(port int) string {
+	return fmt.Sprintf("http://127.0.0.1:%d", port)
+}
@@ -0,0 +1,53 @@
+package stacks
+
+import (
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/internal/blockchain/ethereum"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func envToMap(env []*EnvVar) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, v := range env {
+		m[v.Name] = v.Value
+	}
+	return m
+}
+
+func TestGetStackEnvironment(t *testing.T) {
+	s := newHooksTestStackManager(t, nil)
+	s.Stack.TokenProviders = []fftypes.FFEnum{"erc20_erc721"}
+	s.Stack.SandboxEnabled = true
+	s.Stack.Members[0].Account = &ethereum.Account{Address: "0x1234"}
+	s.Stack.Members[0].ExposedTokensPorts = []int{5109}
+	s.Stack.Members[0].ExposedSandboxPort = 5110
+	// The member with ID 1 has been removed, so the IDs no longer match the positions of the members
+	s.Stack.Members = append(s.Stack.Members, &types.Organization{ID: "2", OrgName: "org_2", NodeName: "node_2", ExposedFireflyPort: 5002})
+
+	env, err := s.GetStackEnvironment("")
+	assert.NoError(t, err)
+	vars := envToMap(env)
+	assert.Equal(t, "2", vars["FF_MEMBER_COUNT"])
+	assert.Equal(t, "http://127.0.0.1:5100", vars["FF_RPC_URL"])
+	assert.Equal(t, "0x1234", vars["FF_MEMBER_0_SIGNING_KEY"])
+	assert.Equal(t, "http://127.0.0.1:5102", vars["FF_MEMBER_0_CONNECTOR_URL"])
+	assert.Equal(t, "http://127.0.0.1:5109", vars["FF_MEMBER_0_TOKENS_0_URL"])
+	assert.Equal(t, "erc20_erc721", vars["FF_MEMBER_0_TOKENS_0_PROVIDER"])
+	assert.Equal(t, "http://127.0.0.1:5110", vars["FF_MEMBER_0_SANDBOX_URL"])
+	assert.Equal(t, "http://127.0.0.1:5002", vars["FF_MEMBER_2_FIREFLY_URL"])
+	assert.NotContains(t, vars, "FF_MEMBER_2_SIGNING_KEY")
+	assert.NotContains(t, vars, "FF_MEMBER_1_FIREFLY_URL")
+
+	env, err = s.GetStackEnvironment("2")
+	assert.NoError(t, err)
+	vars = envToMap(env)
+	assert.Equal(t, "org_2", vars["FF_ORG_NAME"])
+	assert.Equal(t, "http://127.0.0.1:5002", vars["FF_FIREFLY_URL"])
+	assert.NotContains(t, vars, "FF_MEMBER_0_FIREFLY_URL")
+
+	_, err = s.GetStackEnvironment("1")
+	assert.Regexp(t, "member '1' does not exist", err)
+}
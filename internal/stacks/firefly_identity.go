@@ -21,26 +21,33 @@ import (
 	"net/http"
 
 	"github.com/hyperledger/firefly-cli/internal/core"
+	"github.com/hyperledger/firefly-cli/pkg/types"
 )
 
 func (s *StackManager) registerFireflyIdentities() error {
-	emptyObject := make(map[string]interface{})
-
 	for _, member := range s.Stack.Members {
-		ffURL := fmt.Sprintf("http://127.0.0.1:%d/api/v1", member.ExposedFireflyPort)
-		s.Log.Info(fmt.Sprintf("registering org and node for member %s", member.ID))
-
-		registerOrgURL := fmt.Sprintf("%s/network/organizations/self?confirm=true", ffURL)
-		err := core.RequestWithRetry(s.ctx, http.MethodPost, registerOrgURL, emptyObject, nil)
-		if err != nil {
+		if err := s.registerFireflyIdentity(member); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		registerNodeURL := fmt.Sprintf("%s/network/nodes/self?confirm=true", ffURL)
-		err = core.RequestWithRetry(s.ctx, http.MethodPost, registerNodeURL, emptyObject, nil)
-		if err != nil {
-			return nil
-		}
+func (s *StackManager) registerFireflyIdentity(member *types.Organization) error {
+	emptyObject := make(map[string]interface{})
+	ffURL := fmt.Sprintf("http://127.0.0.1:%d/api/v1", member.ExposedFireflyPort)
+	s.Log.Info(fmt.Sprintf("registering org and node for member %s", member.ID))
+
+	registerOrgURL := fmt.Sprintf("%s/network/organizations/self?confirm=true", ffURL)
+	err := core.RequestWithRetry(s.ctx, http.MethodPost, registerOrgURL, emptyObject, nil)
+	if err != nil {
+		return err
+	}
+
+	registerNodeURL := fmt.Sprintf("%s/network/nodes/self?confirm=true", ffURL)
+	err = core.RequestWithRetry(s.ctx, http.MethodPost, registerNodeURL, emptyObject, nil)
+	if err != nil {
+		return err
 	}
 	return nil
 }
@@ -0,0 +1,133 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+)
+
+// resolveHooks makes the path of the script that any hook runs absolute, so that it can
+// still be found when the stack is used from another directory. Relative paths are relative
+// to dir, or to the working directory if dir is empty.
+func resolveHooks(hooks map[string]string, dir string) map[string]string {
+	if len(hooks) == 0 {
+		return nil
+	}
+	resolved := make(map[string]string, len(hooks))
+	for name, command := range hooks {
+		resolved[name] = resolveHookCommand(command, dir)
+	}
+	return resolved
+}
+
+// resolveHookCommand returns the command with its script made absolute, if the command is
+// the path of a script, or starts with one that is followed by its arguments
+func resolveHookCommand(command, dir string) string {
+	if absPath, ok := resolveHookScript(command, dir); ok {
+		return absPath
+	}
+	trimmed := strings.TrimLeft(command, " \t")
+	i := strings.IndexAny(trimmed, " \t")
+	if i < 0 {
+		return command
+	}
+	if absPath, ok := resolveHookScript(trimmed[:i], dir); ok {
+		return absPath + trimmed[i:]
+	}
+	return command
+}
+
+func resolveHookScript(script, dir string) (string, bool) {
+	if dir != "" && !filepath.IsAbs(script) {
+		script = filepath.Join(dir, script)
+	}
+	if info, err := os.Stat(script); err != nil || info.IsDir() {
+		return "", false
+	}
+	absPath, err := filepath.Abs(script)
+	if err != nil {
+		return "", false
+	}
+	return absPath, true
+}
+
+// runHook runs the command the stack declares for a hook, if there is one. The command
+// is run by the shell, in the stack directory, with the endpoints of the stack in its
+// environment.
+func (s *StackManager) runHook(hook string) error {
+	command := s.Stack.Hooks[hook]
+	if command == "" {
+		return nil
+	}
+	s.Log.Info(fmt.Sprintf("running %s hook", hook))
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(s.ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(s.ctx, "sh", "-c", command)
+	}
+	if info, err := os.Stat(s.Stack.StackDir); err == nil && info.IsDir() {
+		cmd.Dir = s.Stack.StackDir
+	}
+	cmd.Env = append(os.Environ(), s.getHookEnvironment(hook)...)
+
+	output, err := cmd.CombinedOutput()
+	if log.VerbosityFromContext(s.ctx) {
+		fmt.Print(string(output))
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook '%s' failed: %s\n%s", hook, command, err, output)
+	}
+	return nil
+}
+
+// runTeardownHook runs a hook that is run before the stack is stopped or removed. A failing
+// hook is only reported, so that it can never leave a stack that cannot be torn down.
+func (s *StackManager) runTeardownHook(hook string) {
+	if err := s.runHook(hook); err != nil {
+		s.Log.Warn(fmt.Sprintf("continuing after error: %s", err))
+	}
+}
+
+func (s *StackManager) getHookEnvironment(hook string) []string {
+	env := []string{"FF_HOOK=" + hook}
+	stackEnv, _ := s.GetStackEnvironment("")
+	for _, v := range stackEnv {
+		env = append(env, v.Name+"="+v.Value)
+	}
+	return env
+}
+
+// getRPCURL returns the URL of the blockchain node's JSON-RPC API, if the stack has one
+func (s *StackManager) getRPCURL() string {
+	switch {
+	case s.Stack.RemoteNodeURL != "":
+		return s.Stack.RemoteNodeURL
+	case s.Stack.BlockchainProvider.Equals(types.BlockchainProviderEthereum) && s.Stack.ExposedBlockchainPort > 0:
+		return localURL(s.Stack.ExposedBlockchainPort)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,165 @@
+package stacks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/internal/constants"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHooksTestStackManager(t *testing.T, hooks map[string]string) *StackManager {
+	ctx := log.WithVerbosity(context.Background(), false)
+	s := &StackManager{
+		ctx: ctx,
+		Log: &log.StdoutLogger{},
+		Stack: &types.Stack{
+			Name:                   "hooks",
+			StackDir:               t.TempDir(),
+			BlockchainProvider:     fftypes.FFEnum("ethereum"),
+			BlockchainNodeProvider: fftypes.FFEnum("geth"),
+			BlockchainConnector:    fftypes.FFEnum("evmconnect"),
+			ExposedBlockchainPort:  5100,
+			Members: []*types.Organization{
+				{ID: "0", OrgName: "org_0", NodeName: "node_0", ExposedFireflyPort: 5000, ExposedConnectorPort: 5102, ExposedIPFSApiPort: 5106},
+			},
+			Hooks: hooks,
+		},
+	}
+	s.blockchainProvider = s.getBlockchainProvider()
+	return s
+}
+
+func TestGetHookEnvironment(t *testing.T) {
+	s := newHooksTestStackManager(t, nil)
+	env := s.getHookEnvironment(types.HookPostStart)
+	assert.Contains(t, env, "FF_HOOK=post-start")
+	assert.Contains(t, env, "FF_STACK_NAME=hooks")
+	assert.Contains(t, env, "FF_MEMBER_COUNT=1")
+	assert.Contains(t, env, "FF_RPC_URL=http://127.0.0.1:5100")
+	assert.Contains(t, env, "FF_MEMBER_0_FIREFLY_URL=http://127.0.0.1:5000")
+	assert.Contains(t, env, "FF_MEMBER_0_CONNECTOR_URL=http://127.0.0.1:5102")
+	assert.Contains(t, env, "FF_MEMBER_0_IPFS_API_URL=http://127.0.0.1:5106")
+
+	s.Stack.RemoteNodeURL = "https://rpc.example.com"
+	assert.Contains(t, s.getHookEnvironment(types.HookPostStart), "FF_RPC_URL=https://rpc.example.com")
+}
+
+func TestRunHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test use sh")
+	}
+	s := newHooksTestStackManager(t, map[string]string{
+		types.HookPostStart: `echo "$FF_MEMBER_0_FIREFLY_URL" > out.txt`,
+		types.HookPreStop:   "exit 3",
+	})
+
+	assert.NoError(t, s.runHook(types.HookPostStart))
+	b, err := os.ReadFile(filepath.Join(s.Stack.StackDir, "out.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:5000\n", string(b))
+
+	assert.Regexp(t, "pre-stop hook 'exit 3' failed", s.runHook(types.HookPreStop))
+	assert.NoError(t, s.runHook(types.HookPreRemove))
+}
+
+func TestResolveHooks(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "seed.sh")
+	assert.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\n"), 0755))
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	relative, err := filepath.Rel(wd, script)
+	assert.NoError(t, err)
+
+	hooks := resolveHooks(map[string]string{
+		types.HookPostFirstTimeSetup: relative,
+		types.HookPostStart:          relative + " --seed 42",
+		types.HookPreStop:            "make clean",
+	}, "")
+	assert.Equal(t, script, hooks[types.HookPostFirstTimeSetup])
+	assert.Equal(t, script+" --seed 42", hooks[types.HookPostStart])
+	assert.Equal(t, "make clean", hooks[types.HookPreStop])
+	assert.Nil(t, resolveHooks(nil, ""))
+
+	hooks = resolveHooks(map[string]string{
+		types.HookPostStart: "./seed.sh arg",
+		types.HookPreStop:   "missing.sh",
+	}, dir)
+	assert.Equal(t, script+" arg", hooks[types.HookPostStart])
+	assert.Equal(t, "missing.sh", hooks[types.HookPreStop])
+}
+
+func TestFailingTeardownHooksDoNotBlockTeardown(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test use sh")
+	}
+	s, fake := newLifecycleTestStackManager(t)
+	s.Stack = &types.Stack{
+		Name:                   "hooks",
+		StackDir:               filepath.Join(constants.StacksDir, "hooks"),
+		BlockchainProvider:     fftypes.FFEnum("ethereum"),
+		BlockchainNodeProvider: fftypes.FFEnum("geth"),
+		BlockchainConnector:    fftypes.FFEnum("evmconnect"),
+		Hooks: map[string]string{
+			types.HookPreStop:   "exit 3",
+			types.HookPreRemove: "exit 4",
+		},
+	}
+	assert.NoError(t, os.MkdirAll(s.Stack.StackDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(s.Stack.StackDir, "docker-compose.yml"), []byte{}, 0755))
+	s.blockchainProvider = s.getBlockchainProvider()
+
+	assert.NoError(t, s.StopStack())
+	assert.NoError(t, s.RemoveStack())
+	assert.Equal(t, [][]string{{"stop"}, {"down"}}, fake.ComposeCommands)
+	assert.NoDirExists(t, s.Stack.StackDir)
+}
+
+func TestFailedInitSkipsPreRemoveHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test use sh")
+	}
+	s, fake := newLifecycleTestStackManager(t)
+	fake.OnCommand = func(workingDir string, command []string) (string, error) { return "", nil }
+	marker := filepath.Join(t.TempDir(), "removed")
+	options := newLifecycleTestInitOptions(t, "failed")
+	options.Hooks = map[string]string{
+		types.HookPostInit:  "exit 1",
+		types.HookPreRemove: "touch " + marker,
+	}
+	assert.Regexp(t, "post-init hook 'exit 1' failed", s.InitStack(options))
+
+	assert.NoError(t, s.RemoveStack())
+	assert.NoFileExists(t, marker)
+	assert.NoDirExists(t, s.Stack.StackDir)
+}
+
+func TestFailingPostFirstTimeSetupHookDoesNotRollBack(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test use sh")
+	}
+	s, fake := newLifecycleTestStackManager(t)
+	rpc := &fakeBlockchainAPI{}
+	fake.OnCommand = func(workingDir string, command []string) (string, error) {
+		return rpc.onCommand(workingDir, command)
+	}
+	options := newLifecycleTestInitOptions(t, "seeded")
+	options.Hooks = map[string]string{types.HookPostFirstTimeSetup: "exit 2"}
+	assert.NoError(t, s.InitStack(options))
+	rpc.ports = []int{s.Stack.ExposedBlockchainPort}
+
+	_, err := s.StartStack(&types.StartOptions{})
+	assert.Regexp(t, "post-first-time-setup hook 'exit 2' failed", err)
+	assert.NotRegexp(t, "rolled back", err)
+	hasRunBefore, err := s.Stack.HasRunBefore()
+	assert.NoError(t, err)
+	assert.True(t, hasRunBefore)
+	assert.NotEmpty(t, fake.VolumeNames())
+}
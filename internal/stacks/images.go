@@ -0,0 +1,334 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/firefly-cli/internal/core"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+)
+
+const imageBundleVersion = 1
+
+const (
+	imageBundleManifestFile = "bundle.json"
+	imageBundleImagesFile   = "images.tar"
+)
+
+type ImageBundle struct {
+	Version   int                    `json:"version"`
+	StackName string                 `json:"stackName"`
+	Created   string                 `json:"created"`
+	Images    []string               `json:"images"`
+	Manifest  *types.VersionManifest `json:"manifest"`
+}
+
+// bundleImageRef returns the name an image is saved under in a bundle. docker does not keep
+// the digest of an image when it is saved and loaded again, so images that are referenced by
+// digest are given a tag made from the digest instead.
+func bundleImageRef(image string) string {
+	if i := strings.Index(image, "@sha256:"); i >= 0 {
+		return fmt.Sprintf("%s:sha256-%s", image[:i], image[i+len("@sha256:"):])
+	}
+	return image
+}
+
+// newBundleManifest returns a copy of a manifest that refers to every image by the name it is
+// saved under in a bundle
+func newBundleManifest(manifest *types.VersionManifest) (*types.VersionManifest, error) {
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	var bundleManifest *types.VersionManifest
+	if err := json.Unmarshal(b, &bundleManifest); err != nil {
+		return nil, err
+	}
+	for _, entry := range bundleManifest.Entries() {
+		if entry != nil && entry.SHA != "" {
+			entry.Tag = "sha256-" + entry.SHA
+			entry.SHA = ""
+		}
+	}
+	return bundleManifest, nil
+}
+
+func bundleManifestPath(stackName string) string {
+	return filepath.Join(filepath.Dir(core.ManifestCacheDir()), fmt.Sprintf("bundle-%s.json", stackName))
+}
+
+// SaveImageBundle writes every image the stack needs to a single tar file, pulling any that
+// are missing first, so that the stack can be created on a machine with no network access
+func (s *StackManager) SaveImageBundle(filename string) error {
+	if err := s.PullStack(&types.PullOptions{Retries: defaultPullRetries}); err != nil {
+		return err
+	}
+	images := s.getStackImages(true)
+	if err := checkImagesPresent(s.ctx, images); err != nil {
+		return err
+	}
+
+	workDir, err := os.MkdirTemp("", "ff-images-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	manifest, err := newBundleManifest(s.Stack.VersionManifest)
+	if err != nil {
+		return err
+	}
+	bundle := &ImageBundle{
+		Version:   imageBundleVersion,
+		StackName: s.Stack.Name,
+		Created:   time.Now().UTC().Format(time.RFC3339),
+		Images:    make([]string, 0, len(images)),
+		Manifest:  manifest,
+	}
+	for _, image := range images {
+		ref := bundleImageRef(image)
+		if ref != image {
+			if err := docker.TagImage(s.ctx, image, ref); err != nil {
+				return err
+			}
+			// The tag is only needed while the bundle is written
+			defer func(ref string) {
+				_ = docker.UntagImage(s.ctx, ref)
+			}(ref)
+		}
+		bundle.Images = append(bundle.Images, ref)
+	}
+
+	s.Log.Info(fmt.Sprintf("saving %d images", len(bundle.Images)))
+	if err := docker.SaveImages(s.ctx, filepath.Join(workDir, imageBundleImagesFile), bundle.Images...); err != nil {
+		return err
+	}
+	bundleBytes, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(workDir, imageBundleManifestFile), bundleBytes, 0644); err != nil {
+		return err
+	}
+
+	s.Log.Info(fmt.Sprintf("writing image bundle to '%s'", filename))
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	defer tw.Close()
+	if err := addFileToArchive(tw, filepath.Join(workDir, imageBundleManifestFile), imageBundleManifestFile); err != nil {
+		return err
+	}
+	if err := addFileToArchive(tw, filepath.Join(workDir, imageBundleImagesFile), imageBundleImagesFile); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// LoadImageBundle loads every image in a bundle written by SaveImageBundle, and writes the
+// manifest of the bundle to the FireFly home directory. It returns the path of the manifest.
+func (s *StackManager) LoadImageBundle(filename string) (*ImageBundle, string, error) {
+	workDir, err := os.MkdirTemp("", "ff-images-")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	s.Log.Info(fmt.Sprintf("extracting image bundle '%s'", filename))
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	if err := extractTar(f, workDir); err != nil {
+		return nil, "", err
+	}
+
+	bundleBytes, err := os.ReadFile(filepath.Join(workDir, imageBundleManifestFile))
+	if err != nil {
+		return nil, "", fmt.Errorf("'%s' is not a valid FireFly image bundle: %s", filename, err)
+	}
+	var bundle *ImageBundle
+	if err := json.Unmarshal(bundleBytes, &bundle); err != nil {
+		return nil, "", err
+	}
+	if bundle.Version > imageBundleVersion {
+		return nil, "", fmt.Errorf("image bundle version %d is not supported by this version of the CLI", bundle.Version)
+	}
+
+	s.Log.Info(fmt.Sprintf("loading %d images", len(bundle.Images)))
+	if err := docker.LoadImages(s.ctx, filepath.Join(workDir, imageBundleImagesFile)); err != nil {
+		return nil, "", err
+	}
+
+	manifestPath := bundleManifestPath(bundle.StackName)
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return nil, "", err
+	}
+	manifestBytes, err := json.MarshalIndent(bundle.Manifest, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	if err := os.WriteFile(manifestPath, manifestBytes, 0644); err != nil {
+		return nil, "", err
+	}
+	return bundle, manifestPath, nil
+}
+
+// manifestComponents maps the name of each component whose image can be set to its entry in the manifest
+var manifestComponents = map[string]func(m *types.VersionManifest) **types.ManifestEntry{
+	"firefly":             func(m *types.VersionManifest) **types.ManifestEntry { return &m.FireFly },
+	"ethconnect":          func(m *types.VersionManifest) **types.ManifestEntry { return &m.Ethconnect },
+	"evmconnect":          func(m *types.VersionManifest) **types.ManifestEntry { return &m.Evmconnect },
+	"tezosconnect":        func(m *types.VersionManifest) **types.ManifestEntry { return &m.Tezosconnect },
+	"fabconnect":          func(m *types.VersionManifest) **types.ManifestEntry { return &m.Fabconnect },
+	"dataexchange":        func(m *types.VersionManifest) **types.ManifestEntry { return &m.DataExchange },
+	"tokens-erc1155":      func(m *types.VersionManifest) **types.ManifestEntry { return &m.TokensERC1155 },
+	"tokens-erc20-erc721": func(m *types.VersionManifest) **types.ManifestEntry { return &m.TokensERC20ERC721 },
+	"signer":              func(m *types.VersionManifest) **types.ManifestEntry { return &m.Signer },
+}
+
+// ManifestComponentNames returns the components that can be passed to SetComponentImage
+func ManifestComponentNames() []string {
+	names := []string{"tokens"}
+	for name := range manifestComponents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// manifestComponentNames returns the components that can be passed to SetComponentImage for
+// this stack, which only include "tokens" if the stack has exactly one token provider
+func (s *StackManager) manifestComponentNames() []string {
+	names := ManifestComponentNames()
+	if len(s.Stack.TokenProviders) == 1 {
+		return names
+	}
+	filtered := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != "tokens" {
+			filtered = append(filtered, name)
+		}
+	}
+	return filtered
+}
+
+// resolveManifestComponent returns the manifest component a name refers to. "tokens" refers to
+// the token provider of the stack, as long as it only has one.
+func (s *StackManager) resolveManifestComponent(component string) (string, error) {
+	component = strings.ToLower(component)
+	if component == "dataexchange-https" {
+		component = "dataexchange"
+	}
+	if component == "tokens" {
+		if len(s.Stack.TokenProviders) == 0 {
+			return "", fmt.Errorf("stack '%s' has no token providers, so it has no tokens component", s.Stack.Name)
+		}
+		if len(s.Stack.TokenProviders) > 1 {
+			return "", fmt.Errorf("stack '%s' has %d token providers - use tokens-erc1155 or tokens-erc20-erc721 instead of tokens", s.Stack.Name, len(s.Stack.TokenProviders))
+		}
+		switch {
+		case s.Stack.TokenProviders[0].Equals(types.TokenProviderERC1155):
+			return "tokens-erc1155", nil
+		case s.Stack.TokenProviders[0].Equals(types.TokenProviderERC20ERC721):
+			return "tokens-erc20-erc721", nil
+		}
+	}
+	if _, ok := manifestComponents[component]; !ok {
+		return "", fmt.Errorf("unknown component '%s'. Valid components are: %s", component, strings.Join(s.manifestComponentNames(), ", "))
+	}
+	return component, nil
+}
+
+// SetComponentImage changes the image of one component of the stack, and recreates the services
+// that use it if the stack is running. Images marked as local are never pulled. It returns the
+// services whose config changed, and whether they were recreated.
+func (s *StackManager) SetComponentImage(component, image string, local bool) (services []string, recreated bool, err error) {
+	if s.IsOldFileStructure {
+		return nil, false, fmt.Errorf("the FireFly stack '%s' was created with an older version of the CLI and its images cannot be changed", s.Stack.Name)
+	}
+	component, err = s.resolveManifestComponent(component)
+	if err != nil {
+		return nil, false, err
+	}
+	entry, err := types.ParseManifestEntry(image)
+	if err != nil {
+		return nil, false, err
+	}
+	entry.Local = local
+
+	imageString := entry.GetDockerImageString()
+	if local || s.Stack.Offline {
+		if err := checkImagesPresent(s.ctx, []string{imageString}); err != nil {
+			return nil, false, err
+		}
+	} else if err := s.pullImages([]string{imageString}, &types.PullOptions{Retries: defaultPullRetries}); err != nil {
+		return nil, false, err
+	}
+
+	oldCompose, err := s.readDockerCompose()
+	if err != nil {
+		return nil, false, err
+	}
+	if s.Stack.VersionManifest == nil {
+		s.Stack.VersionManifest = &types.VersionManifest{}
+	}
+	*manifestComponents[component](s.Stack.VersionManifest) = entry
+	newCompose := s.buildDockerCompose()
+	changes, err := diffDockerCompose(oldCompose, newCompose)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.writeDockerCompose(newCompose); err != nil {
+		return nil, false, err
+	}
+	if err := s.writeStackConfig(); err != nil {
+		return nil, false, err
+	}
+
+	services = []string{}
+	for _, change := range changes {
+		if change.Change != ServiceRemoved {
+			services = append(services, change.Service)
+		}
+	}
+	if len(services) == 0 {
+		return services, false, nil
+	}
+	running, err := s.isAnyServiceRunning(s.ctx)
+	if err != nil || !running {
+		return services, false, err
+	}
+	s.Log.Info(fmt.Sprintf("recreating %s", strings.Join(services, ", ")))
+	if err := s.runDockerComposeCommand(append([]string{"up", "-d", "--no-deps"}, services...)...); err != nil {
+		return services, false, err
+	}
+	return services, true, nil
+}
@@ -0,0 +1,81 @@
+package stacks
+
+import (
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundleImageRef(t *testing.T) {
+	assert.Equal(t, "ghcr.io/hyperledger/firefly:sha256-abcd", bundleImageRef("ghcr.io/hyperledger/firefly@sha256:abcd"))
+	assert.Equal(t, "ipfs/go-ipfs:v0.10.0", bundleImageRef("ipfs/go-ipfs:v0.10.0"))
+	assert.Equal(t, "postgres", bundleImageRef("postgres"))
+}
+
+func TestNewBundleManifest(t *testing.T) {
+	manifest := &types.VersionManifest{
+		FireFly:      &types.ManifestEntry{Image: "ghcr.io/hyperledger/firefly", Tag: "v1.3.0", SHA: "abcd"},
+		DataExchange: &types.ManifestEntry{Image: "ghcr.io/hyperledger/firefly-dataexchange-https", Tag: "v1.3.0"},
+		Evmconnect:   &types.ManifestEntry{Image: "firefly-evmconnect", Local: true},
+	}
+	bundleManifest, err := newBundleManifest(manifest)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "ghcr.io/hyperledger/firefly:sha256-abcd", bundleManifest.FireFly.GetDockerImageString())
+	assert.Equal(t, bundleImageRef(manifest.FireFly.GetDockerImageString()), bundleManifest.FireFly.GetDockerImageString())
+	assert.Equal(t, "ghcr.io/hyperledger/firefly-dataexchange-https:v1.3.0", bundleManifest.DataExchange.GetDockerImageString())
+	assert.Equal(t, "firefly-evmconnect", bundleManifest.Evmconnect.GetDockerImageString())
+	assert.Nil(t, bundleManifest.Ethconnect)
+
+	// The original manifest is left untouched
+	assert.Equal(t, "ghcr.io/hyperledger/firefly@sha256:abcd", manifest.FireFly.GetDockerImageString())
+}
+
+func TestParseManifestEntry(t *testing.T) {
+	entry, err := types.ParseManifestEntry("ghcr.io/hyperledger/firefly-evmconnect@sha256:abcd")
+	assert.NoError(t, err)
+	assert.Equal(t, &types.ManifestEntry{Image: "ghcr.io/hyperledger/firefly-evmconnect", SHA: "abcd"}, entry)
+
+	entry, err = types.ParseManifestEntry("localhost:5000/evmconnect:dev")
+	assert.NoError(t, err)
+	assert.Equal(t, &types.ManifestEntry{Image: "localhost:5000/evmconnect", Tag: "dev"}, entry)
+
+	entry, err = types.ParseManifestEntry("localhost:5000/evmconnect")
+	assert.NoError(t, err)
+	assert.Equal(t, &types.ManifestEntry{Image: "localhost:5000/evmconnect"}, entry)
+
+	_, err = types.ParseManifestEntry("evmconnect@md5:abcd")
+	assert.Regexp(t, "only sha256 digests are supported", err)
+}
+
+func TestResolveManifestComponent(t *testing.T) {
+	s := &StackManager{Stack: &types.Stack{
+		Name:           "test",
+		TokenProviders: []fftypes.FFEnum{types.TokenProviderERC20ERC721},
+	}}
+	component, err := s.resolveManifestComponent("tokens")
+	assert.NoError(t, err)
+	assert.Equal(t, "tokens-erc20-erc721", component)
+	component, err = s.resolveManifestComponent("dataexchange-https")
+	assert.NoError(t, err)
+	assert.Equal(t, "dataexchange", component)
+	component, err = s.resolveManifestComponent("EVMConnect")
+	assert.NoError(t, err)
+	assert.Equal(t, "evmconnect", component)
+
+	_, err = s.resolveManifestComponent("besu")
+	assert.Regexp(t, "unknown component 'besu'", err)
+
+	s.Stack.TokenProviders = append(s.Stack.TokenProviders, types.TokenProviderERC1155)
+	_, err = s.resolveManifestComponent("tokens")
+	assert.Regexp(t, "has 2 token providers", err)
+
+	s.Stack.TokenProviders = nil
+	_, err = s.resolveManifestComponent("tokens")
+	assert.Regexp(t, "has no token providers", err)
+	_, err = s.resolveManifestComponent("besu")
+	assert.Regexp(t, "unknown component 'besu'", err)
+	assert.NotContains(t, err.Error(), "tokens,")
+}
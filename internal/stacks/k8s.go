@@ -0,0 +1,662 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hyperledger/firefly-cli/internal/constants"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	k8sVolumeSize    = "1Gi"
+	k8sSeedMountPath = "/seed"
+	k8sDataMountPath = "/data"
+)
+
+type k8sMetadata struct {
+	Name   string            `yaml:"name,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type k8sObject struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Spec       interface{}       `yaml:"spec,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"`
+	StringData map[string]string `yaml:"stringData,omitempty"`
+	BinaryData map[string]string `yaml:"binaryData,omitempty"`
+}
+
+type k8sLabelSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+type k8sWorkloadSpec struct {
+	Replicas    int                `yaml:"replicas"`
+	ServiceName string             `yaml:"serviceName,omitempty"`
+	Selector    k8sLabelSelector   `yaml:"selector"`
+	Template    k8sPodTemplateSpec `yaml:"template"`
+}
+
+type k8sPodTemplateSpec struct {
+	Metadata k8sMetadata `yaml:"metadata"`
+	Spec     k8sPodSpec  `yaml:"spec"`
+}
+
+type k8sPodSpec struct {
+	InitContainers []*k8sContainer `yaml:"initContainers,omitempty"`
+	Containers     []*k8sContainer `yaml:"containers"`
+	Volumes        []*k8sVolume    `yaml:"volumes,omitempty"`
+}
+
+type k8sContainer struct {
+	Name         string              `yaml:"name"`
+	Image        string              `yaml:"image"`
+	Command      []string            `yaml:"command,omitempty"`
+	Args         []string            `yaml:"args,omitempty"`
+	WorkingDir   string              `yaml:"workingDir,omitempty"`
+	Env          []*k8sEnvVar        `yaml:"env,omitempty"`
+	Ports        []*k8sContainerPort `yaml:"ports,omitempty"`
+	VolumeMounts []*k8sVolumeMount   `yaml:"volumeMounts,omitempty"`
+}
+
+type k8sEnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type k8sContainerPort struct {
+	ContainerPort int `yaml:"containerPort"`
+}
+
+type k8sVolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	SubPath   string `yaml:"subPath,omitempty"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+type k8sVolume struct {
+	Name                  string                    `yaml:"name"`
+	PersistentVolumeClaim *k8sPVCVolumeSource       `yaml:"persistentVolumeClaim,omitempty"`
+	ConfigMap             *k8sConfigMapVolumeSource `yaml:"configMap,omitempty"`
+	Secret                *k8sSecretVolumeSource    `yaml:"secret,omitempty"`
+}
+
+type k8sPVCVolumeSource struct {
+	ClaimName string `yaml:"claimName"`
+}
+
+type k8sKeyToPath struct {
+	Key  string `yaml:"key"`
+	Path string `yaml:"path"`
+}
+
+type k8sConfigMapVolumeSource struct {
+	Name  string          `yaml:"name"`
+	Items []*k8sKeyToPath `yaml:"items,omitempty"`
+}
+
+type k8sSecretVolumeSource struct {
+	SecretName string          `yaml:"secretName"`
+	Items      []*k8sKeyToPath `yaml:"items,omitempty"`
+}
+
+type k8sServiceSpec struct {
+	Selector map[string]string `yaml:"selector"`
+	Ports    []*k8sServicePort `yaml:"ports"`
+}
+
+type k8sServicePort struct {
+	Name       string `yaml:"name"`
+	Port       int    `yaml:"port"`
+	TargetPort int    `yaml:"targetPort"`
+}
+
+type k8sPVCSpec struct {
+	AccessModes []string            `yaml:"accessModes"`
+	Resources   k8sResourceRequests `yaml:"resources"`
+}
+
+type k8sResourceRequests struct {
+	Requests map[string]string `yaml:"requests"`
+}
+
+type k8sKustomization struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Namespace  string   `yaml:"namespace,omitempty"`
+	Resources  []string `yaml:"resources"`
+}
+
+// k8sFile is a file from the stack directory that is put into a ConfigMap or Secret
+type k8sFile struct {
+	// Path is where the file goes, relative to the directory it is mounted in
+	Path   string
+	Source string
+}
+
+// k8sExporter turns a docker compose file into Kubernetes objects. Every object is built
+// from sorted input, so that the same stack always gives the same output.
+type k8sExporter struct {
+	stackName string
+	stackDir  string
+	compose   *docker.DockerComposeConfig
+	// seeds are the files the CLI copies into each named volume on first start
+	seeds    map[string][]*k8sFile
+	objects  map[string]*k8sObject
+	warnings []string
+	refs     []*regexp.Regexp
+	refNames []string
+}
+
+// ExportKubernetes writes Kubernetes manifests for the stack to dir. The stack must have
+// been started, so that its runtime config has been generated.
+func (s *StackManager) ExportKubernetes(dir, namespace string) error {
+	hasRunBefore, err := s.Stack.HasRunBefore()
+	if err != nil {
+		return err
+	}
+	if !hasRunBefore || s.IsOldFileStructure {
+		return fmt.Errorf("the FireFly stack '%s' must be started once before it can be exported, so that its runtime config is generated", s.Stack.Name)
+	}
+	exporter := newK8sExporter(s.Stack.Name, s.Stack.StackDir, s.buildDockerCompose(), s.getVolumeSeeds())
+	objects, err := exporter.build()
+	if err != nil {
+		return err
+	}
+	for _, warning := range exporter.warnings {
+		s.Log.Warn(warning)
+	}
+	return writeK8sObjects(dir, namespace, objects)
+}
+
+// getVolumeSeeds returns the files that are copied into the stack's volumes during first
+// time setup, as they cannot be mounted from the stack directory in Kubernetes
+func (s *StackManager) getVolumeSeeds() map[string][]*k8sFile {
+	seeds := map[string][]*k8sFile{}
+	addFile := func(volume, source, target string) {
+		if info, err := os.Stat(source); err == nil && info.Mode().IsRegular() {
+			seeds[volume] = append(seeds[volume], &k8sFile{Path: target, Source: source})
+		}
+	}
+	addDir := func(volume, dir, target string) {
+		_ = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+			relativePath, err := filepath.Rel(dir, p)
+			if err == nil {
+				addFile(volume, p, filepath.ToSlash(filepath.Join(target, relativePath)))
+			}
+			return nil
+		})
+	}
+
+	configDir := filepath.Join(s.Stack.RuntimeDir, "config")
+	blockchainDir := filepath.Join(s.Stack.RuntimeDir, "blockchain")
+	for _, member := range s.Stack.Members {
+		dxVolume := "dataexchange_" + member.ID
+		memberDXDir := filepath.Join(configDir, dxVolume)
+		for _, file := range []string{"config.json", dxCertFile, dxKeyFile} {
+			addFile(dxVolume, filepath.Join(memberDXDir, file), file)
+		}
+		for _, peer := range s.Stack.Members {
+			if peer.ID != member.ID {
+				addFile(dxVolume, filepath.Join(configDir, "dataexchange_"+peer.ID, dxCertFile), path.Join(dxPeerCertsDir, dataExchangePeerID(peer)+".pem"))
+			}
+		}
+
+		connector := s.Stack.BlockchainConnector.String()
+		addFile(fmt.Sprintf("%s_config_%s", connector, member.ID), filepath.Join(configDir, fmt.Sprintf("%s_%s.yaml", connector, member.ID)), "config.yaml")
+	}
+	addDir("geth", filepath.Join(blockchainDir, "keystore"), "keystore")
+	addFile("geth", filepath.Join(blockchainDir, "genesis.json"), "genesis.json")
+	addFile("geth", filepath.Join(blockchainDir, "password"), "password")
+	addFile("prometheus_config", filepath.Join(configDir, "prometheus.yml"), "prometheus.yml")
+	addFile("ethsigner_config", filepath.Join(configDir, "ethsigner.yaml"), "firefly.ffsigner")
+	addDir("ethsigner", filepath.Join(blockchainDir, "keystore"), "keystore")
+	addFile("ethsigner", filepath.Join(blockchainDir, "password"), "password")
+	return seeds
+}
+
+func newK8sExporter(stackName, stackDir string, compose *docker.DockerComposeConfig, seeds map[string][]*k8sFile) *k8sExporter {
+	e := &k8sExporter{
+		stackName: stackName,
+		stackDir:  stackDir,
+		compose:   compose,
+		seeds:     seeds,
+		objects:   map[string]*k8sObject{},
+	}
+	for _, name := range sortedServiceNames(compose) {
+		e.refs = append(e.refs, serviceRefRegexp(name))
+		e.refNames = append(e.refNames, k8sName(name))
+	}
+	return e
+}
+
+// k8sName converts a docker compose name into a valid Kubernetes object name
+func k8sName(name string) string {
+	name = strings.ToLower(name)
+	name = regexp.MustCompile(`[^a-z0-9-]+`).ReplaceAllString(name, "-")
+	return strings.Trim(name, "-")
+}
+
+// k8sKey converts a relative path into a valid ConfigMap or Secret key
+func k8sKey(p string) string {
+	return regexp.MustCompile(`[^-._a-zA-Z0-9]+`).ReplaceAllString(p, "_")
+}
+
+func isK8sSecretPath(p string) bool {
+	p = strings.ToLower(p)
+	for _, s := range []string{"key", "password", "secret", ".pem", "ffsigner"} {
+		if strings.Contains(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedServiceNames(compose *docker.DockerComposeConfig) []string {
+	names := make([]string, 0, len(compose.Services))
+	for name := range compose.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rewriteRefs changes each "service:port" to use the name of the Kubernetes service
+func (e *k8sExporter) rewriteRefs(text string) string {
+	for i, re := range e.refs {
+		text = re.ReplaceAllString(text, "${1}"+e.refNames[i]+":${2}")
+	}
+	return text
+}
+
+func (e *k8sExporter) labels(name string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       name,
+		"app.kubernetes.io/instance":   e.stackName,
+		"app.kubernetes.io/managed-by": "firefly-cli",
+	}
+}
+
+func (e *k8sExporter) selector(name string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":     name,
+		"app.kubernetes.io/instance": e.stackName,
+	}
+}
+
+func (e *k8sExporter) add(object *k8sObject) {
+	e.objects[fmt.Sprintf("%s-%s", object.Metadata.Name, strings.ToLower(object.Kind))] = object
+}
+
+func (e *k8sExporter) build() (map[string]*k8sObject, error) {
+	for _, serviceName := range sortedServiceNames(e.compose) {
+		if err := e.addService(serviceName, e.compose.Services[serviceName]); err != nil {
+			return nil, err
+		}
+	}
+	return e.objects, nil
+}
+
+func (e *k8sExporter) addService(serviceName string, service *docker.Service) error {
+	name := k8sName(serviceName)
+	container := &k8sContainer{
+		Name:       name,
+		Image:      service.Image,
+		Command:    service.EntryPoint,
+		WorkingDir: service.WorkingDir,
+	}
+	if service.Command != "" {
+		args, err := splitCommand(service.Command)
+		if err != nil {
+			return fmt.Errorf("invalid command for service '%s': %s", serviceName, err)
+		}
+		container.Args = args
+	}
+
+	envNames := make([]string, 0, len(service.Environment))
+	for k := range service.Environment {
+		envNames = append(envNames, k)
+	}
+	sort.Strings(envNames)
+	for _, k := range envNames {
+		container.Env = append(container.Env, &k8sEnvVar{Name: k, Value: e.rewriteRefs(fmt.Sprint(service.Environment[k]))})
+	}
+
+	portSet := map[int]bool{}
+	for containerPort := range parsePortMappings(service.Ports) {
+		portSet[containerPort] = true
+	}
+	for _, port := range service.Expose {
+		portSet[port] = true
+	}
+	ports := make([]int, 0, len(portSet))
+	for port := range portSet {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	for _, port := range ports {
+		container.Ports = append(container.Ports, &k8sContainerPort{ContainerPort: port})
+	}
+
+	pod := k8sPodSpec{Containers: []*k8sContainer{container}}
+	usesClaims := false
+	for _, volume := range service.Volumes {
+		readOnly := strings.HasSuffix(volume, ":ro")
+		source, target := parseVolumeMount(volume)
+		switch {
+		case source == "":
+			// An anonymous volume
+			continue
+		case !filepath.IsAbs(source) && !strings.Contains(source, "/"):
+			usesClaims = true
+			e.addVolumeClaim(source, &pod, container, target, readOnly)
+		case strings.HasPrefix(source, e.stackDir+string(os.PathSeparator)):
+			if err := e.addFileMount(source, &pod, container, target, readOnly); err != nil {
+				return err
+			}
+		default:
+			e.warnings = append(e.warnings, fmt.Sprintf("'%s' is mounted into '%s' from outside the stack directory and is left out of the Kubernetes manifests", source, serviceName))
+		}
+	}
+
+	workload := &k8sWorkloadSpec{
+		Replicas: 1,
+		Selector: k8sLabelSelector{MatchLabels: e.selector(name)},
+		Template: k8sPodTemplateSpec{
+			Metadata: k8sMetadata{Labels: e.selector(name)},
+			Spec:     pod,
+		},
+	}
+	kind := "Deployment"
+	if usesClaims {
+		kind = "StatefulSet"
+		workload.ServiceName = name
+	}
+	e.add(&k8sObject{
+		APIVersion: "apps/v1",
+		Kind:       kind,
+		Metadata:   k8sMetadata{Name: name, Labels: e.labels(name)},
+		Spec:       workload,
+	})
+
+	if len(ports) > 0 {
+		serviceSpec := &k8sServiceSpec{Selector: e.selector(name)}
+		for _, port := range ports {
+			serviceSpec.Ports = append(serviceSpec.Ports, &k8sServicePort{Name: fmt.Sprintf("port-%d", port), Port: port, TargetPort: port})
+		}
+		e.add(&k8sObject{
+			APIVersion: "v1",
+			Kind:       "Service",
+			Metadata:   k8sMetadata{Name: name, Labels: e.labels(name)},
+			Spec:       serviceSpec,
+		})
+	}
+	return nil
+}
+
+func (e *k8sExporter) addVolumeClaim(volumeName string, pod *k8sPodSpec, container *k8sContainer, target string, readOnly bool) {
+	claimName := k8sName(volumeName)
+	e.add(&k8sObject{
+		APIVersion: "v1",
+		Kind:       "PersistentVolumeClaim",
+		Metadata:   k8sMetadata{Name: claimName, Labels: e.labels(claimName)},
+		Spec: &k8sPVCSpec{
+			AccessModes: []string{"ReadWriteOnce"},
+			Resources:   k8sResourceRequests{Requests: map[string]string{"storage": k8sVolumeSize}},
+		},
+	})
+	pod.Volumes = append(pod.Volumes, &k8sVolume{Name: claimName, PersistentVolumeClaim: &k8sPVCVolumeSource{ClaimName: claimName}})
+	container.VolumeMounts = append(container.VolumeMounts, &k8sVolumeMount{Name: claimName, MountPath: target, ReadOnly: readOnly})
+
+	seeds := e.seeds[volumeName]
+	if len(seeds) == 0 {
+		return
+	}
+	// Copy the files the CLI would have put in the volume, without overwriting anything already there
+	seedName := claimName + "-seed"
+	items, err := e.addFileSet(seedName, true, seeds)
+	if err != nil {
+		e.warnings = append(e.warnings, fmt.Sprintf("unable to read the files for volume '%s': %s", volumeName, err))
+		return
+	}
+	pod.Volumes = append(pod.Volumes, &k8sVolume{Name: seedName, Secret: &k8sSecretVolumeSource{SecretName: seedName, Items: items}})
+	pod.InitContainers = append(pod.InitContainers, &k8sContainer{
+		Name:    seedName,
+		Image:   constants.AlpineImageName,
+		Command: []string{"sh", "-c", fmt.Sprintf("cp -rnL %s/* %s/", k8sSeedMountPath, k8sDataMountPath)},
+		VolumeMounts: []*k8sVolumeMount{
+			{Name: claimName, MountPath: k8sDataMountPath},
+			{Name: seedName, MountPath: k8sSeedMountPath, ReadOnly: true},
+		},
+	})
+}
+
+func (e *k8sExporter) addFileMount(source string, pod *k8sPodSpec, container *k8sContainer, target string, readOnly bool) error {
+	relativePath, err := filepath.Rel(e.stackDir, source)
+	if err != nil {
+		return err
+	}
+	relativePath = filepath.ToSlash(relativePath)
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+
+	var files []*k8sFile
+	if info.IsDir() {
+		err = filepath.Walk(source, func(p string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(source, p)
+			if err == nil {
+				files = append(files, &k8sFile{Path: filepath.ToSlash(rel), Source: p})
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		files = []*k8sFile{{Path: filepath.Base(source), Source: source}}
+	}
+
+	name := k8sName(relativePath)
+	secret := isK8sSecretPath(relativePath)
+	items, err := e.addFileSet(name, secret, files)
+	if err != nil {
+		return err
+	}
+	volume := &k8sVolume{Name: name}
+	if secret {
+		volume.Secret = &k8sSecretVolumeSource{SecretName: name, Items: items}
+	} else {
+		volume.ConfigMap = &k8sConfigMapVolumeSource{Name: name, Items: items}
+	}
+	if !containsK8sVolume(pod.Volumes, name) {
+		pod.Volumes = append(pod.Volumes, volume)
+	}
+	mount := &k8sVolumeMount{Name: name, MountPath: target, ReadOnly: readOnly}
+	if !info.IsDir() {
+		mount.SubPath = files[0].Path
+	}
+	container.VolumeMounts = append(container.VolumeMounts, mount)
+	return nil
+}
+
+// addFileSet adds a ConfigMap or Secret holding the files, and returns the items that
+// put each file at its path when it is mounted
+func (e *k8sExporter) addFileSet(name string, secret bool, files []*k8sFile) ([]*k8sKeyToPath, error) {
+	object := &k8sObject{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   k8sMetadata{Name: name, Labels: e.labels(name)},
+	}
+	if secret {
+		object.Kind = "Secret"
+		object.Type = "Opaque"
+	}
+	sorted := append([]*k8sFile{}, files...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+	items := make([]*k8sKeyToPath, 0, len(sorted))
+	for _, file := range sorted {
+		b, err := os.ReadFile(file.Source)
+		if err != nil {
+			return nil, err
+		}
+		key := k8sKey(file.Path)
+		items = append(items, &k8sKeyToPath{Key: key, Path: file.Path})
+		switch {
+		case utf8.Valid(b) && secret:
+			setK8sData(&object.StringData, key, e.rewriteRefs(string(b)))
+		case utf8.Valid(b):
+			setK8sData(&object.Data, key, e.rewriteRefs(string(b)))
+		case secret:
+			setK8sData(&object.Data, key, base64.StdEncoding.EncodeToString(b))
+		default:
+			setK8sData(&object.BinaryData, key, base64.StdEncoding.EncodeToString(b))
+		}
+	}
+	e.add(object)
+	if len(items) == 1 && items[0].Key == items[0].Path {
+		return nil, nil
+	}
+	return items, nil
+}
+
+func setK8sData(data *map[string]string, key, value string) {
+	if *data == nil {
+		*data = map[string]string{}
+	}
+	(*data)[key] = value
+}
+
+func containsK8sVolume(volumes []*k8sVolume, name string) bool {
+	for _, v := range volumes {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCommand splits a docker compose command into arguments, in the same way as a shell
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in '%s'", command)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// writeK8sObjects writes each object to its own file, along with a kustomization.yaml
+// that lists them all, so that the directory can be applied with kubectl apply -k
+func writeK8sObjects(dir, namespace string, objects map[string]*k8sObject) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	kustomization := &k8sKustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Namespace:  namespace,
+	}
+	for _, name := range sortedK8sObjectNames(objects) {
+		filename := name + ".yaml"
+		b, err := marshalK8sYAML(objects[name])
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, filename), b, 0755); err != nil {
+			return err
+		}
+		kustomization.Resources = append(kustomization.Resources, filename)
+	}
+	b, err := marshalK8sYAML(kustomization)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "kustomization.yaml"), b, 0755)
+}
+
+func sortedK8sObjectNames(objects map[string]*k8sObject) []string {
+	names := make([]string, 0, len(objects))
+	for name := range objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func marshalK8sYAML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,178 @@
+package stacks
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+func newK8sTestCompose(stackDir string) *docker.DockerComposeConfig {
+	configDir := filepath.Join(stackDir, "runtime", "config")
+	return &docker.DockerComposeConfig{
+		Services: map[string]*docker.Service{
+			"firefly_core_0": {
+				Image:   "ghcr.io/hyperledger/firefly:v1.3.0",
+				Ports:   []string{"5000:5000", "5101:5101"},
+				Volumes: []string{filepath.Join(configDir, "firefly_core_0.yml") + ":/etc/firefly/firefly.core.yml:ro"},
+			},
+			"evmconnect_0": {
+				Image:   "ghcr.io/hyperledger/firefly-evmconnect:v1.3.0",
+				Command: "-f /evmconnect/config.yaml",
+				Ports:   []string{"5102:5008"},
+				Volumes: []string{"evmconnect_config_0:/evmconnect"},
+			},
+			"dataexchange_0": {
+				Image:   "ghcr.io/hyperledger/firefly-dataexchange-https:v1.3.0",
+				Ports:   []string{"5104:3000"},
+				Volumes: []string{"dataexchange_0:/data"},
+			},
+			"geth": {
+				Image:   "ethereum/client-go:release-1.13",
+				Command: `--datadir /data --password "/data/password" --http --http.addr '0.0.0.0'`,
+				Ports:   []string{"5100:8545"},
+				Volumes: []string{"geth:/data", "/var/run/docker.sock:/var/run/docker.sock"},
+			},
+			"postgres_0": {
+				Image:       "postgres",
+				Environment: map[string]interface{}{"POSTGRES_PASSWORD": "f1refly", "PGDATA": "/var/lib/postgresql/data/pgdata"},
+				Ports:       []string{"5432:5432"},
+				Volumes:     []string{"postgres_0:/var/lib/postgresql/data"},
+			},
+			"tokens_0_0": {
+				Image:       "ghcr.io/hyperledger/firefly-tokens-erc1155:v1.3.0",
+				Environment: map[string]interface{}{"ETHCONNECT_URL": "http://evmconnect_0:5008", "AUTO_INIT": "false"},
+				Expose:      []int{3000},
+			},
+		},
+	}
+}
+
+func TestExportKubernetesGolden(t *testing.T) {
+	stackDir, err := filepath.Abs(filepath.Join("testdata", "k8s", "stack"))
+	assert.NoError(t, err)
+	configDir := filepath.Join(stackDir, "runtime", "config")
+	blockchainDir := filepath.Join(stackDir, "runtime", "blockchain")
+	seeds := map[string][]*k8sFile{
+		"evmconnect_config_0": {{Path: "config.yaml", Source: filepath.Join(configDir, "evmconnect_0.yaml")}},
+		"dataexchange_0": {
+			{Path: "key.pem", Source: filepath.Join(configDir, "dataexchange_0", "key.pem")},
+			{Path: "cert.pem", Source: filepath.Join(configDir, "dataexchange_0", "cert.pem")},
+			{Path: "config.json", Source: filepath.Join(configDir, "dataexchange_0", "config.json")},
+			{Path: "peer-certs/org_1.pem", Source: filepath.Join(configDir, "dataexchange_0", "peer-certs", "org_1.pem")},
+		},
+		"geth": {
+			{Path: "keystore/UTC--2024-01-01T00-00-00.000000000Z--1f2a", Source: filepath.Join(blockchainDir, "keystore", "UTC--2024-01-01T00-00-00.000000000Z--1f2a")},
+			{Path: "genesis.json", Source: filepath.Join(blockchainDir, "genesis.json")},
+			{Path: "password", Source: filepath.Join(blockchainDir, "password")},
+		},
+	}
+
+	exporter := newK8sExporter("dev", stackDir, newK8sTestCompose(stackDir), seeds)
+	objects, err := exporter.build()
+	assert.NoError(t, err)
+	assert.Len(t, exporter.warnings, 1)
+	assert.Contains(t, exporter.warnings[0], "/var/run/docker.sock")
+
+	outputDir := t.TempDir()
+	assert.NoError(t, writeK8sObjects(outputDir, "firefly", objects))
+
+	goldenDir := filepath.Join("testdata", "k8s", "expected")
+	if *updateGolden {
+		assert.NoError(t, os.RemoveAll(goldenDir))
+		assert.NoError(t, os.MkdirAll(goldenDir, 0755))
+	}
+	files, err := os.ReadDir(outputDir)
+	assert.NoError(t, err)
+	expectedFiles, err := os.ReadDir(goldenDir)
+	assert.NoError(t, err)
+	if !*updateGolden {
+		assert.Equal(t, len(expectedFiles), len(files))
+	}
+	for _, file := range files {
+		actual, err := os.ReadFile(filepath.Join(outputDir, file.Name()))
+		assert.NoError(t, err)
+		if *updateGolden {
+			assert.NoError(t, os.WriteFile(filepath.Join(goldenDir, file.Name()), actual, 0644))
+			continue
+		}
+		expected, err := os.ReadFile(filepath.Join(goldenDir, file.Name()))
+		assert.NoError(t, err)
+		assert.Equal(t, string(expected), string(actual), file.Name())
+	}
+}
+
+func TestExportKubernetesDeterministic(t *testing.T) {
+	stackDir, err := filepath.Abs(filepath.Join("testdata", "k8s", "stack"))
+	assert.NoError(t, err)
+	var previous map[string][]byte
+	for i := 0; i < 5; i++ {
+		objects, err := newK8sExporter("dev", stackDir, newK8sTestCompose(stackDir), nil).build()
+		assert.NoError(t, err)
+		output := map[string][]byte{}
+		for name, object := range objects {
+			output[name], err = marshalK8sYAML(object)
+			assert.NoError(t, err)
+		}
+		if previous != nil {
+			assert.Equal(t, previous, output)
+		}
+		previous = output
+	}
+}
+
+func TestK8sName(t *testing.T) {
+	assert.Equal(t, "firefly-core-0", k8sName("firefly_core_0"))
+	assert.Equal(t, "runtime-config-firefly-core-0-yml", k8sName("runtime/config/firefly_core_0.yml"))
+	assert.Equal(t, "tokens-0-0", k8sName("Tokens_0_0"))
+}
+
+func TestSplitCommand(t *testing.T) {
+	args, err := splitCommand(`--datadir /data  --password "/data/my password" --http.api 'eth,net'`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--datadir", "/data", "--password", "/data/my password", "--http.api", "eth,net"}, args)
+
+	_, err = splitCommand(`--password "/data`)
+	assert.Regexp(t, "unterminated quote", err)
+}
+
+func TestExportKubernetesNotRun(t *testing.T) {
+	s := newHooksTestStackManager(t, nil)
+	err := s.ExportKubernetes(t.TempDir(), "")
+	assert.Regexp(t, "must be started once", err)
+}
+
+func TestGetVolumeSeeds(t *testing.T) {
+	s := newHooksTestStackManager(t, nil)
+	s.Stack.RuntimeDir = filepath.Join(s.Stack.StackDir, "runtime")
+	// The member with ID 1 has been removed, so the IDs no longer match the positions of the members
+	s.Stack.Members = append(s.Stack.Members, &types.Organization{ID: "2", OrgName: "org_2", NodeName: "node_2"})
+	configDir := filepath.Join(s.Stack.RuntimeDir, "config")
+	for _, id := range []string{"0", "2"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(configDir, "dataexchange_"+id), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(configDir, "dataexchange_"+id, dxCertFile), []byte("cert"), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(configDir, "evmconnect_"+id+".yaml"), []byte("connector: {}"), 0755))
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Join(s.Stack.RuntimeDir, "blockchain", "keystore"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(s.Stack.RuntimeDir, "blockchain", "keystore", "key1"), []byte("{}"), 0755))
+
+	seeds := s.getVolumeSeeds()
+	paths := func(volume string) []string {
+		result := []string{}
+		for _, f := range seeds[volume] {
+			result = append(result, f.Path)
+		}
+		return result
+	}
+	assert.Equal(t, []string{dxCertFile, "peer-certs/" + dataExchangePeerID(s.Stack.Members[1]) + ".pem"}, paths("dataexchange_0"))
+	assert.Equal(t, []string{"config.yaml"}, paths("evmconnect_config_2"))
+	assert.NotContains(t, seeds, "evmconnect_config_1")
+	assert.Equal(t, []string{"keystore/key1"}, paths("geth"))
+	assert.NotContains(t, seeds, "prometheus_config")
+}
@@ -0,0 +1,507 @@
+package stacks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/internal/blockchain/ethereum/ethsigner"
+	"github.com/hyperledger/firefly-cli/internal/blockchain/ethereum/quorum"
+	"github.com/hyperledger/firefly-cli/internal/blockchain/fabric"
+	"github.com/hyperledger/firefly-cli/internal/constants"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/docker/mocks"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// fakeBlockchainAPI answers every request with success while the stack is up, as geth and quorum
+// are asked to unlock the accounts of the members each time they start, fabconnect is asked
+// to register their identities, and the token connectors are initialized
+type fakeBlockchainAPI struct {
+	mu        sync.Mutex
+	ports     []int
+	servers   []*http.Server
+	listeners []net.Listener
+	requests  []string
+	paths     []string
+}
+
+func (r *fakeBlockchainAPI) onCommand(workingDir string, command []string) (string, error) {
+	if len(command) < 2 || command[0] != "compose" {
+		return "", nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch command[1] {
+	case "up":
+		if r.servers != nil {
+			return "", nil
+		}
+		for _, port := range r.ports {
+			listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+			if err != nil {
+				return "", err
+			}
+			server := &http.Server{Handler: http.HandlerFunc(r.handle)}
+			go func() { _ = server.Serve(listener) }()
+			r.servers = append(r.servers, server)
+			r.listeners = append(r.listeners, listener)
+		}
+	case "stop", "down":
+		for _, server := range r.servers {
+			_ = server.Close()
+		}
+		// The listeners are closed here as well, as a server that has not started serving yet
+		// only closes its listener when it does, and the ports may be reused straight away
+		for _, listener := range r.listeners {
+			_ = listener.Close()
+		}
+		r.servers = nil
+		r.listeners = nil
+	}
+	return "", nil
+}
+
+func (r *fakeBlockchainAPI) handle(w http.ResponseWriter, req *http.Request) {
+	var body map[string]interface{}
+	_ = json.NewDecoder(req.Body).Decode(&body)
+	r.mu.Lock()
+	r.requests = append(r.requests, fmt.Sprint(body["method"]))
+	r.paths = append(r.paths, req.URL.Path)
+	r.mu.Unlock()
+	_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":0,"result":true}`))
+}
+
+// fakeTesseraKeygen writes the key pair that the tessera image would have written to the
+// directory mounted into it
+func fakeTesseraKeygen(workingDir string, command []string) error {
+	for i, arg := range command {
+		if arg == "-filename" && i+1 < len(command) {
+			path := filepath.Join(workingDir, strings.TrimPrefix(command[i+1], "/keystore/"))
+			if err := os.WriteFile(path+".pub", []byte("dGVzc2VyYQ=="), 0755); err != nil {
+				return err
+			}
+			return os.WriteFile(path+".key", []byte(`{"type":"unlocked","data":{"bytes":"a2V5"}}`), 0755)
+		}
+	}
+	return nil
+}
+
+func newLifecycleTestStackManager(t *testing.T) (*StackManager, *mocks.FakeDockerManager) {
+	stacksDir := constants.StacksDir
+	constants.StacksDir = t.TempDir()
+	t.Cleanup(func() { constants.StacksDir = stacksDir })
+
+	fake := mocks.NewFakeDockerManager()
+	ctx := log.WithVerbosity(context.Background(), false)
+	ctx = log.WithLogger(ctx, &log.StdoutLogger{})
+	ctx = context.WithValue(ctx, docker.CtxComposeVersionKey{}, docker.ComposeV2)
+	ctx = docker.WithDockerManager(ctx, fake)
+	return NewStackManager(ctx), fake
+}
+
+func newLifecycleTestInitOptions(t *testing.T, name string) *types.InitOptions {
+	entry := func(image string) *types.ManifestEntry {
+		return &types.ManifestEntry{Image: image, Tag: "v1.0.0"}
+	}
+	manifest := &types.VersionManifest{
+		FireFly:           entry("ghcr.io/hyperledger/firefly"),
+		Ethconnect:        entry("ghcr.io/hyperledger/firefly-ethconnect"),
+		Evmconnect:        entry("ghcr.io/hyperledger/firefly-evmconnect"),
+		Tezosconnect:      entry("ghcr.io/hyperledger/firefly-tezosconnect"),
+		Fabconnect:        entry("ghcr.io/hyperledger/firefly-fabconnect"),
+		DataExchange:      entry("ghcr.io/hyperledger/firefly-dataexchange-https"),
+		TokensERC1155:     entry("ghcr.io/hyperledger/firefly-tokens-erc1155"),
+		TokensERC20ERC721: entry("ghcr.io/hyperledger/firefly-tokens-erc20-erc721"),
+		Signer:            entry("ghcr.io/hyperledger/firefly-signer"),
+	}
+	b, err := json.Marshal(manifest)
+	assert.NoError(t, err)
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+	assert.NoError(t, os.WriteFile(manifestPath, b, 0755))
+
+	return &types.InitOptions{
+		StackName:                 name,
+		MemberCount:               2,
+		FireFlyBasePort:           5000,
+		ServicesBasePort:          5100,
+		PtmBasePort:               4100,
+		AutoPorts:                 true,
+		DatabaseProvider:          "sqlite3",
+		BlockchainProvider:        "ethereum",
+		BlockchainNodeProvider:    "geth",
+		BlockchainConnector:       "evmconnect",
+		PrivateTransactionManager: "none",
+		Consensus:                 "clique",
+		TokenProviders:            []string{},
+		ManifestPath:              manifestPath,
+		PrometheusPort:            9090,
+		BlockPeriod:               -1,
+		ChainID:                   2021,
+		IPFSMode:                  "private",
+		EnvironmentVars:           map[string]string{},
+		OrgNames:                  []string{"org_0", "org_1"},
+		NodeNames:                 []string{"node_0", "node_1"},
+	}
+}
+
+func TestStackLifecycle(t *testing.T) {
+	combinations := []struct {
+		provider   string
+		node       string
+		connector  string
+		ptm        string
+		prometheus bool
+		database   string
+		tokens     []string
+	}{
+		{provider: "ethereum", node: "geth", connector: "evmconnect"},
+		{provider: "ethereum", node: "geth", connector: "ethconnect", prometheus: true},
+		{provider: "ethereum", node: "besu", connector: "evmconnect"},
+		{provider: "ethereum", node: "besu", connector: "ethconnect"},
+		{provider: "ethereum", node: "quorum", connector: "evmconnect"},
+		{provider: "ethereum", node: "quorum", connector: "ethconnect"},
+		{provider: "ethereum", node: "quorum", connector: "evmconnect", ptm: "tessera"},
+		{provider: "ethereum", node: "remote-rpc", connector: "evmconnect"},
+		{provider: "ethereum", node: "remote-rpc", connector: "ethconnect"},
+		{provider: "ethereum", node: "geth", connector: "evmconnect", database: "postgres"},
+		{provider: "ethereum", node: "remote-rpc", connector: "evmconnect", database: "postgres", tokens: []string{"erc20_erc721"}},
+		{provider: "ethereum", node: "remote-rpc", connector: "ethconnect", tokens: []string{"erc1155", "erc20_erc721"}},
+		{provider: "tezos", node: "remote-rpc", connector: "tezosconnect"},
+		{provider: "fabric", node: "fabric", connector: "fabconnect"},
+	}
+	for _, c := range combinations {
+		name := fmt.Sprintf("%s-%s-%s", c.provider, c.node, c.connector)
+		if c.ptm != "" {
+			name += "-" + c.ptm
+		}
+		if c.database != "" {
+			name += "-" + c.database
+		}
+		for _, tp := range c.tokens {
+			name += "-" + tp
+		}
+		t.Run(name, func(t *testing.T) {
+			s, fake := newLifecycleTestStackManager(t)
+			rpc := &fakeBlockchainAPI{}
+			fake.OnCommand = func(workingDir string, command []string) (string, error) {
+				if len(command) > 0 && command[0] == "run" {
+					return "", fakeTesseraKeygen(workingDir, command)
+				}
+				return rpc.onCommand(workingDir, command)
+			}
+
+			options := newLifecycleTestInitOptions(t, "lifecycle")
+			options.BlockchainProvider = c.provider
+			options.BlockchainNodeProvider = c.node
+			options.BlockchainConnector = c.connector
+			options.PrometheusEnabled = c.prometheus
+			if c.ptm != "" {
+				options.PrivateTransactionManager = c.ptm
+			}
+			if c.database != "" {
+				options.DatabaseProvider = c.database
+			}
+			if c.tokens != nil {
+				options.TokenProviders = c.tokens
+			}
+			assert.NoError(t, s.InitStack(options))
+			switch c.node {
+			case "geth":
+				rpc.ports = []int{s.Stack.ExposedBlockchainPort}
+			case "quorum":
+				for i := range s.Stack.Members {
+					rpc.ports = append(rpc.ports, s.Stack.ExposedBlockchainPort+i*quorum.ExposedBlockchainPortMultiplier)
+				}
+			case "fabric":
+				for _, member := range s.Stack.Members {
+					rpc.ports = append(rpc.ports, member.ExposedConnectorPort)
+				}
+			}
+			// Each token connector is initialized during the first start
+			for _, member := range s.Stack.Members {
+				rpc.ports = append(rpc.ports, member.ExposedTokensPorts...)
+			}
+
+			// Starting the stack for the first time fills its volumes and starts every service
+			_, err := s.StartStack(&types.StartOptions{})
+			assert.NoError(t, err)
+			assertLifecycleStackRunning(t, s, fake)
+			assertVolumeContains(t, fake, "lifecycle_dataexchange_0", "/cert.pem")
+			assertVolumeContains(t, fake, "lifecycle_dataexchange_1", "/key.pem")
+			if c.database == "postgres" {
+				assert.Contains(t, fake.VolumeNames(), "lifecycle_postgres_0")
+			} else {
+				assertVolumeContains(t, fake, "lifecycle_firefly_core_data_0", "/db")
+			}
+			if len(c.tokens) > 0 {
+				assert.Contains(t, rpc.paths, "/api/v1/init")
+			}
+			if c.provider != "fabric" {
+				assertVolumeContains(t, fake, fmt.Sprintf("lifecycle_%s_config_1", c.connector), "/config.yaml")
+			}
+			if c.node == "geth" {
+				assertVolumeContains(t, fake, "lifecycle_geth", "/genesis.json")
+			}
+			if c.node == "geth" || c.node == "quorum" {
+				assert.Contains(t, rpc.requests, "personal_unlockAccount")
+			}
+			if c.prometheus {
+				assertVolumeContains(t, fake, "lifecycle_prometheus_config", "/prometheus.yml")
+			}
+			hasRunBefore, err := s.Stack.HasRunBefore()
+			assert.NoError(t, err)
+			assert.True(t, hasRunBefore)
+
+			// Starting it again only brings the containers back up
+			assert.NoError(t, s.StopStack())
+			composeCommands := len(fake.ComposeCommands)
+			_, err = s.StartStack(&types.StartOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, [][]string{{"up", "-d"}}, fake.ComposeCommands[composeCommands:])
+			assertLifecycleStackRunning(t, s, fake)
+
+			// Reset removes all of the data, and the next start sets it up again
+			assert.NoError(t, s.ResetStack())
+			assert.Empty(t, fake.VolumeNames())
+			states, err := docker.GetContainerStates(s.ctx, s.Stack.StackDir)
+			assert.NoError(t, err)
+			assert.Empty(t, states)
+			hasRunBefore, err = s.Stack.HasRunBefore()
+			assert.NoError(t, err)
+			assert.False(t, hasRunBefore)
+			_, err = s.StartStack(&types.StartOptions{})
+			assert.NoError(t, err)
+			assertLifecycleStackRunning(t, s, fake)
+
+			assert.NoError(t, s.RemoveStack())
+			assert.Empty(t, fake.VolumeNames())
+			assert.NoDirExists(t, s.Stack.StackDir)
+		})
+	}
+}
+
+func assertVolumeContains(t *testing.T, fake *mocks.FakeDockerManager, volumeName, path string) {
+	volume := fake.Volume(volumeName)
+	if assert.NotNil(t, volume, volumeName) {
+		_, isFile := volume.Files[path]
+		assert.True(t, isFile || volume.Dirs[path], "%s:%s", volumeName, path)
+	}
+}
+
+func assertLifecycleStackRunning(t *testing.T, s *StackManager, fake *mocks.FakeDockerManager) {
+	compose := s.buildDockerCompose()
+	states, err := docker.GetContainerStates(s.ctx, s.Stack.StackDir)
+	assert.NoError(t, err)
+	assert.Len(t, states, len(compose.Services))
+	for _, state := range states {
+		assert.Equal(t, "running", state.State, state.Service)
+	}
+	for _, image := range s.getStackImages(false) {
+		_, present := fake.GetLocalImageDigests(s.ctx, image)
+		assert.True(t, present, image)
+	}
+}
+
+// newShortRetryInitOptions returns options for a stack that gives up on a node that does not
+// answer straight away, as there is none in the tests that roll back a setup
+func newShortRetryInitOptions(t *testing.T, name string) *types.InitOptions {
+	options := newLifecycleTestInitOptions(t, name)
+	options.RetryPolicy = &types.RetryPolicy{Retries: 1, InitialDelay: "10ms"}
+	return options
+}
+
+func TestStartStackRollsBackFailedSetup(t *testing.T) {
+	s, fake := newLifecycleTestStackManager(t)
+	assert.NoError(t, s.InitStack(newShortRetryInitOptions(t, "rollback")))
+	fake.OnCommand = func(workingDir string, command []string) (string, error) {
+		if strings.HasPrefix(strings.Join(command, " "), "cp") && strings.Contains(command[2], "dataexchange_1") {
+			return "", fmt.Errorf("disk full")
+		}
+		return "", nil
+	}
+
+	_, err := s.StartStack(&types.StartOptions{})
+	assert.Regexp(t, "disk full - all changes rolled back", err)
+	assert.Empty(t, fake.VolumeNames())
+	hasRunBefore, err := s.Stack.HasRunBefore()
+	assert.NoError(t, err)
+	assert.False(t, hasRunBefore)
+}
+
+func TestStartStackRollsBackInterruptedSetup(t *testing.T) {
+	s, fake := newLifecycleTestStackManager(t)
+	// The providers are created with the context of the stack manager, so it has to be
+	// cancelable before the stack is initialized
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	s = NewStackManager(ctx)
+	assert.NoError(t, s.InitStack(newShortRetryInitOptions(t, "interrupted")))
+	fake.OnCommand = func(workingDir string, command []string) (string, error) {
+		if command[0] == "cp" && strings.Contains(command[2], "dataexchange_0") {
+			// The user presses Ctrl-C part of the way through the setup
+			cancel()
+		}
+		return "", nil
+	}
+
+	_, err := s.StartStack(&types.StartOptions{})
+	assert.Regexp(t, "setup was interrupted - all changes rolled back", err)
+	assert.Empty(t, fake.VolumeNames())
+	assert.NoDirExists(t, s.Stack.RuntimeDir)
+	hasRunBefore, err := s.Stack.HasRunBefore()
+	assert.NoError(t, err)
+	assert.False(t, hasRunBefore)
+}
+
+func TestRemoteNodeAuthIsKeptOutOfStackJSON(t *testing.T) {
+	s, fake := newLifecycleTestStackManager(t)
+	fake.OnCommand = func(workingDir string, command []string) (string, error) { return "", nil }
+	options := newLifecycleTestInitOptions(t, "gateway")
+	options.BlockchainNodeProvider = "remote-rpc"
+	options.RemoteNodeURL = "https://rpc.example.com/testnet"
+	options.RemoteNodeAuth = &types.RemoteNodeAuth{
+		Username: "firefly",
+		Password: "s3cret",
+		Headers:  map[string]string{"X-Api-Key": "abc123"},
+		CACert:   "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+	}
+	assert.NoError(t, s.InitStack(options))
+
+	stackJSON, err := os.ReadFile(filepath.Join(s.Stack.StackDir, "stack.json"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(stackJSON), "s3cret")
+	info, err := os.Stat(filepath.Join(s.Stack.StackDir, types.RemoteNodeAuthFile))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	var signerConfig *ethsigner.Config
+	signerConfigBytes, err := os.ReadFile(filepath.Join(s.Stack.InitDir, "config", "ethsigner.yaml"))
+	assert.NoError(t, err)
+	assert.NoError(t, yaml.Unmarshal(signerConfigBytes, &signerConfig))
+	assert.Equal(t, "https://rpc.example.com/testnet", signerConfig.Backend.URL)
+	assert.Equal(t, &ethsigner.BasicAuthConfig{Username: "firefly", Password: "s3cret"}, signerConfig.Backend.Auth)
+	assert.Equal(t, map[string]string{"X-Api-Key": "abc123"}, signerConfig.Backend.Headers)
+	assert.Equal(t, options.RemoteNodeAuth.CACert, signerConfig.Backend.TLS.CA)
+
+	loaded := NewStackManager(s.ctx)
+	assert.NoError(t, loaded.LoadStack("gateway"))
+	assert.Equal(t, options.RemoteNodeAuth, loaded.Stack.RemoteNodeAuth)
+}
+
+func TestAddedMemberIsSetUpAgainAfterReset(t *testing.T) {
+	s, fake := newLifecycleTestStackManager(t)
+	rpc := &fakeBlockchainAPI{}
+	fake.OnCommand = func(workingDir string, command []string) (string, error) {
+		return rpc.onCommand(workingDir, command)
+	}
+	assert.NoError(t, s.InitStack(newLifecycleTestInitOptions(t, "added")))
+	rpc.ports = []int{s.Stack.ExposedBlockchainPort}
+	_, err := s.StartStack(&types.StartOptions{})
+	assert.NoError(t, err)
+
+	member, err := s.AddMember("org_2", "node_2")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", member.ID)
+	assertLifecycleStackRunning(t, s, fake)
+
+	// The runtime directory is recreated from the init directory when the stack is started after a reset
+	assert.NoError(t, s.ResetStack())
+	_, err = s.StartStack(&types.StartOptions{})
+	assert.NoError(t, err)
+	assertLifecycleStackRunning(t, s, fake)
+	assertVolumeContains(t, fake, "added_dataexchange_2", "/cert.pem")
+	assertVolumeContains(t, fake, "added_evmconnect_config_2", "/config.yaml")
+	assert.FileExists(t, filepath.Join(s.Stack.RuntimeDir, "config", "firefly_core_2.yml"))
+}
+
+func TestRemovedMemberLeavesGapAfterReset(t *testing.T) {
+	s, fake := newLifecycleTestStackManager(t)
+	rpc := &fakeBlockchainAPI{}
+	fake.OnCommand = func(workingDir string, command []string) (string, error) {
+		return rpc.onCommand(workingDir, command)
+	}
+	options := newLifecycleTestInitOptions(t, "gap")
+	options.MemberCount = 3
+	options.OrgNames = append(options.OrgNames, "org_2")
+	options.NodeNames = append(options.NodeNames, "node_2")
+	assert.NoError(t, s.InitStack(options))
+	rpc.ports = []int{s.Stack.ExposedBlockchainPort}
+	_, err := s.StartStack(&types.StartOptions{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.RemoveMember("1"))
+	assert.NoError(t, s.ResetStack())
+	_, err = s.StartStack(&types.StartOptions{})
+	assert.NoError(t, err)
+	assertLifecycleStackRunning(t, s, fake)
+	// The config of each member goes to the volume named after its ID, which compose mounts
+	assertVolumeContains(t, fake, "gap_evmconnect_config_2", "/config.yaml")
+	assert.NotContains(t, fake.VolumeNames(), "gap_evmconnect_config_1")
+}
+
+func TestRemoveMemberOfFabricStack(t *testing.T) {
+	s, fake := newLifecycleTestStackManager(t)
+	fake.OnCommand = func(workingDir string, command []string) (string, error) { return "", nil }
+	options := newLifecycleTestInitOptions(t, "fabric")
+	options.BlockchainProvider = "fabric"
+	options.BlockchainNodeProvider = "fabric"
+	options.BlockchainConnector = "fabconnect"
+	assert.NoError(t, s.InitStack(options))
+	assert.NotContains(t, s.getVolumeNames(), "fabric_config_1")
+
+	// None of the member's volumes exist, as the stack has not been started
+	assert.NoError(t, s.RemoveMember("1"))
+	assert.Len(t, s.Stack.Members, 1)
+}
+
+func TestCloneStackRemovesFailedClone(t *testing.T) {
+	s, fake := newLifecycleTestStackManager(t)
+	fake.OnCommand = func(workingDir string, command []string) (string, error) {
+		return "", fakeTesseraKeygen(workingDir, command)
+	}
+	options := newLifecycleTestInitOptions(t, "source")
+	options.BlockchainNodeProvider = "quorum"
+	options.PrivateTransactionManager = "tessera"
+	assert.NoError(t, s.InitStack(options))
+
+	// The tessera keys are generated by running a container while the clone is created
+	fake.OnCommand = func(workingDir string, command []string) (string, error) {
+		if command[0] == "run" {
+			return "", fmt.Errorf("keygen failed")
+		}
+		return "", nil
+	}
+	clone := NewStackManager(s.ctx)
+	assert.Regexp(t, "keygen failed", clone.CloneStack("source", "clone"))
+	assert.NoDirExists(t, filepath.Join(constants.StacksDir, "clone"))
+	assert.DirExists(t, filepath.Join(constants.StacksDir, "source"))
+}
+
+func TestStackImagesIncludeSetupImages(t *testing.T) {
+	s, fake := newLifecycleTestStackManager(t)
+	fake.OnCommand = func(workingDir string, command []string) (string, error) { return "", nil }
+	options := newLifecycleTestInitOptions(t, "fabric")
+	options.BlockchainProvider = "fabric"
+	options.BlockchainNodeProvider = "fabric"
+	options.BlockchainConnector = "fabconnect"
+	assert.NoError(t, s.InitStack(options))
+
+	// The tools image is never a service of the stack, but is run to set up the network
+	images := s.getStackImages(false)
+	assert.Contains(t, images, fabric.FabricToolsImageName)
+	assert.Contains(t, images, fabric.FabricPeerImageName)
+	assert.NoError(t, s.PullStack(&types.PullOptions{}))
+	_, present := fake.GetLocalImageDigests(s.ctx, fabric.FabricToolsImageName)
+	assert.True(t, present)
+}
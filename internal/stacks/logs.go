@@ -0,0 +1,394 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+)
+
+type LogsOptions struct {
+	// Services selects services by name, or by the name without the member suffix,
+	// such as "evmconnect" for every member's evmconnect. "core" is short for firefly_core.
+	Services []string
+	// Member selects the services of one member, by index, org name or node name
+	Member string
+	Follow bool
+	Since  string
+	Tail   string
+	// Grep is a regular expression that lines must match
+	Grep string
+	// Level hides lines below the given log level
+	Level string
+	Color bool
+}
+
+func (o *LogsOptions) isFiltered() bool {
+	return o.Grep != "" || o.Level != ""
+}
+
+var logLevels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+var ansiRegexp = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// logLevelRegexp finds the level of a FireFly log line, in either the text format
+// "[2024-01-01T00:00:00.000Z]  WARN ..." or the JSON format {"level":"warn",...}
+var logLevelRegexp = regexp.MustCompile(`(?i)(?:"level"\s*:\s*"|\]\s+|\|\s+)(trace|debug|info|warn|warning|error|fatal|panic)\b`)
+
+// PrintLogs writes the logs of the selected services to out, filtered by the options
+func (s *StackManager) PrintLogs(options *LogsOptions, out io.Writer) error {
+	if options.Tail != "" && options.Tail != "all" {
+		if _, err := strconv.ParseUint(options.Tail, 10, 32); err != nil {
+			return fmt.Errorf("invalid tail '%s': must be a number of lines or 'all'", options.Tail)
+		}
+	}
+	// The tail of filtered logs is only known once the logs end, which they do not when followed
+	if options.isFiltered() && options.Tail != "" && options.Tail != "all" && options.Follow {
+		return fmt.Errorf("--tail cannot be used with --follow when the logs are filtered by --level or --grep")
+	}
+	services, err := s.selectLogServices(options.Services, options.Member)
+	if err != nil {
+		return err
+	}
+	filter, err := newLogFilter(options, out)
+	if err != nil {
+		return err
+	}
+
+	commandLine := []string{}
+	if options.Color {
+		// The output is piped through the filter, so compose has to be told to keep its colours
+		commandLine = append(commandLine, "--ansi", "always")
+	}
+	commandLine = append(commandLine, "-p", s.Stack.Name, "logs")
+	if !options.Color {
+		commandLine = append(commandLine, "--no-color")
+	}
+	if options.Follow {
+		commandLine = append(commandLine, "-f")
+	}
+	if options.Since != "" {
+		commandLine = append(commandLine, "--since", options.Since)
+	}
+	if options.Tail != "" && !options.isFiltered() {
+		// Filtered logs are tailed by the filter, so that the last matching lines are shown
+		commandLine = append(commandLine, "--tail", options.Tail)
+	}
+	commandLine = append(commandLine, services...)
+
+	ctx := context.WithValue(s.ctx, docker.CtxIsLogCmdKey{}, true)
+	ctx = context.WithValue(ctx, docker.CtxLogWriterKey{}, filter)
+	err = docker.RunDockerComposeCommand(ctx, s.Stack.RuntimeDir, commandLine...)
+	filter.Flush()
+	return err
+}
+
+// selectLogServices returns the names of the services that match the selectors and member.
+// If nothing is selected, an empty list is returned, which is every service in the stack.
+func (s *StackManager) selectLogServices(selectors []string, memberSelector string) ([]string, error) {
+	if len(selectors) == 0 && memberSelector == "" {
+		return []string{}, nil
+	}
+	var member *types.Organization
+	if memberSelector != "" {
+		for _, m := range s.Stack.Members {
+			if m.ID == memberSelector || m.OrgName == memberSelector || m.NodeName == memberSelector ||
+				(m.Index != nil && strconv.Itoa(*m.Index) == memberSelector) {
+				member = m
+				break
+			}
+		}
+		if member == nil {
+			return nil, fmt.Errorf("stack '%s' does not have a member '%s'", s.Stack.Name, memberSelector)
+		}
+	}
+
+	allServices := sortedServiceNames(s.buildDockerCompose())
+	selected := []string{}
+	for _, serviceName := range allServices {
+		if member != nil && !isMemberService(serviceName, member) {
+			continue
+		}
+		if len(selectors) == 0 {
+			selected = append(selected, serviceName)
+			continue
+		}
+		for _, selector := range selectors {
+			if s.logSelectorMatches(selector, serviceName) {
+				selected = append(selected, serviceName)
+				break
+			}
+		}
+	}
+
+	for _, selector := range selectors {
+		found := false
+		for _, serviceName := range allServices {
+			if s.logSelectorMatches(selector, serviceName) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("stack '%s' does not have a service '%s'. Services are: %s", s.Stack.Name, selector, strings.Join(allServices, ", "))
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("member '%s' does not have any of the services %s", memberSelector, strings.Join(selectors, ", "))
+	}
+	return selected, nil
+}
+
+func (s *StackManager) logSelectorMatches(selector, serviceName string) bool {
+	switch selector {
+	case "core":
+		selector = "firefly_core"
+	case "connector":
+		selector = s.blockchainProvider.GetConnectorName()
+	}
+	return serviceName == selector || strings.HasPrefix(serviceName, selector+"_")
+}
+
+// isMemberService returns true if the service belongs to the member, rather than being
+// shared by the whole stack like the blockchain node
+func isMemberService(serviceName string, member *types.Organization) bool {
+	return strings.HasSuffix(serviceName, "_"+member.ID) || strings.HasPrefix(serviceName, "tokens_"+member.ID+"_")
+}
+
+// logFilter is an io.Writer that only passes on the complete lines that match the filters
+type logFilter struct {
+	mu       sync.Mutex
+	out      io.Writer
+	grep     *regexp.Regexp
+	minLevel int
+	color    bool
+	partial  []byte
+	// tail is the number of lines to keep from the end of the logs of each service, or -1
+	// to show every line as soon as it is read. Kept lines are written out by Flush.
+	tail   int
+	tailed map[string]*tailBuffer
+	// read counts the lines that have been kept, so that Flush can write them out in the
+	// order they were read
+	read int
+	// lastShown records whether the last line from each service was shown, so that
+	// lines without a level, such as stack traces, follow the line they belong to
+	lastShown map[string]bool
+}
+
+type tailedLine struct {
+	read int
+	line string
+}
+
+// tailBuffer is a ring buffer of the last lines of the logs of one service
+type tailBuffer struct {
+	lines []tailedLine
+	next  int
+}
+
+func (b *tailBuffer) add(line tailedLine, size int) {
+	if len(b.lines) < size {
+		b.lines = append(b.lines, line)
+		return
+	}
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % size
+}
+
+func newLogFilter(options *LogsOptions, out io.Writer) (*logFilter, error) {
+	f := &logFilter{
+		out:       out,
+		minLevel:  -1,
+		tail:      -1,
+		tailed:    map[string]*tailBuffer{},
+		color:     options.Color,
+		lastShown: map[string]bool{},
+	}
+	if options.isFiltered() && options.Tail != "" && options.Tail != "all" {
+		tail, err := strconv.ParseUint(options.Tail, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tail '%s': must be a number of lines or 'all'", options.Tail)
+		}
+		f.tail = int(tail)
+	}
+	if options.Grep != "" {
+		grep, err := regexp.Compile(options.Grep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grep expression '%s': %s", options.Grep, err)
+		}
+		f.grep = grep
+	}
+	if options.Level != "" {
+		f.minLevel = logLevelIndex(options.Level)
+		if f.minLevel < 0 {
+			return nil, fmt.Errorf("invalid log level '%s'. Options are: %s", options.Level, strings.Join(logLevels, ", "))
+		}
+	}
+	return f, nil
+}
+
+func (f *logFilter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.partial = append(f.partial, p...)
+	for {
+		i := bytes.IndexByte(f.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(f.partial[:i])
+		f.partial = f.partial[i+1:]
+		if err := f.writeLine(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes out anything left over that did not end with a new line, and the last lines
+// of each service when the logs are tailed
+func (f *logFilter) Flush() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.partial) > 0 {
+		_ = f.writeLine(string(f.partial))
+		f.partial = nil
+	}
+	lines := []tailedLine{}
+	for _, buffer := range f.tailed {
+		lines = append(lines, buffer.lines...)
+	}
+	// The lines are written in the order they were read, which interleaves the services
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].read < lines[j].read
+	})
+	for _, l := range lines {
+		_, _ = fmt.Fprintln(f.out, l.line)
+	}
+	f.tailed = map[string]*tailBuffer{}
+}
+
+func (f *logFilter) writeLine(line string) error {
+	plain := ansiRegexp.ReplaceAllString(line, "")
+	service, message, hasService := strings.Cut(plain, "|")
+	if !hasService {
+		message = plain
+	}
+	service = strings.TrimSpace(service)
+
+	level := lineLevel(message)
+	show := true
+	if f.minLevel >= 0 {
+		if level >= 0 {
+			show = level >= f.minLevel
+		} else {
+			show = f.lastShown[service]
+		}
+	}
+	if show && f.grep != nil {
+		show = f.grep.MatchString(plain)
+	}
+	f.lastShown[service] = show
+	if !show {
+		return nil
+	}
+	if f.color {
+		line = colorLevel(line)
+	}
+	if f.tail >= 0 {
+		if f.tail > 0 {
+			if f.tailed[service] == nil {
+				f.tailed[service] = &tailBuffer{}
+			}
+			f.tailed[service].add(tailedLine{read: f.read, line: line}, f.tail)
+			f.read++
+		}
+		return nil
+	}
+	_, err := fmt.Fprintln(f.out, line)
+	return err
+}
+
+// lineLevel returns the index of the level of the line in logLevels, or -1 if it has none
+func lineLevel(message string) int {
+	if match := logLevelRegexp.FindStringSubmatch(message); match != nil {
+		return logLevelIndex(match[1])
+	}
+	// Some services log JSON with numeric levels, as pino does
+	var entry struct {
+		Level int `json:"level"`
+	}
+	start := strings.Index(message, "{")
+	if start >= 0 && json.Unmarshal([]byte(message[start:]), &entry) == nil && entry.Level > 0 {
+		// 10 is trace, up to 60 for fatal
+		level := entry.Level/10 - 1
+		if level < 0 {
+			level = 0
+		} else if level >= len(logLevels) {
+			level = len(logLevels) - 1
+		}
+		return level
+	}
+	return -1
+}
+
+func logLevelIndex(level string) int {
+	switch strings.ToLower(level) {
+	case "warning":
+		level = "warn"
+	case "panic":
+		level = "fatal"
+	}
+	for i, l := range logLevels {
+		if strings.EqualFold(l, level) {
+			return i
+		}
+	}
+	return -1
+}
+
+var levelColors = map[string]string{
+	"warn":  "\x1b[33m",
+	"error": "\x1b[31m",
+	"fatal": "\x1b[1;31m",
+}
+
+// colorLevel highlights the level of warnings and errors, as it is what the eye looks for
+// when scanning the interleaved output of many containers
+func colorLevel(line string) string {
+	match := logLevelRegexp.FindStringSubmatchIndex(line)
+	if match == nil {
+		return line
+	}
+	level := logLevelIndex(line[match[2]:match[3]])
+	color, ok := levelColors[logLevels[level]]
+	if !ok {
+		return line
+	}
+	return line[:match[2]] + color + line[match[2]:match[3]] + "\x1b[0m" + line[match[3]:]
+}
@@ -0,0 +1,151 @@
+package stacks
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectLogServices(t *testing.T) {
+	s, _ := newLifecycleTestStackManager(t)
+	options := newLifecycleTestInitOptions(t, "logs")
+	options.TokenProviders = []string{"erc20_erc721"}
+	assert.NoError(t, s.InitStack(options))
+
+	testCases := []struct {
+		name      string
+		selectors []string
+		member    string
+		expected  []string
+		err       string
+	}{
+		{name: "Everything", expected: []string{}},
+		{name: "CoreOfOneMember", selectors: []string{"core"}, member: "1", expected: []string{"firefly_core_1"}},
+		{name: "ConnectorOfEveryMember", selectors: []string{"evmconnect"}, expected: []string{"evmconnect_0", "evmconnect_1"}},
+		{name: "ConnectorAlias", selectors: []string{"connector"}, member: "org_0", expected: []string{"evmconnect_0"}},
+		{name: "FullName", selectors: []string{"geth", "ipfs_1"}, expected: []string{"geth", "ipfs_1"}},
+		{name: "Member", member: "node_1", expected: []string{"dataexchange_1", "evmconnect_1", "firefly_core_1", "ipfs_1", "tokens_1_0"}},
+		{name: "UnknownService", selectors: []string{"besu"}, err: "stack 'logs' does not have a service 'besu'"},
+		{name: "UnknownMember", member: "7", err: "stack 'logs' does not have a member '7'"},
+		{name: "SharedServiceOfMember", selectors: []string{"geth"}, member: "0", err: "member '0' does not have any of the services geth"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			services, err := s.selectLogServices(tc.selectors, tc.member)
+			if tc.err != "" {
+				assert.Regexp(t, tc.err, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, services)
+			}
+		})
+	}
+}
+
+func TestPrintLogsCommandLine(t *testing.T) {
+	s, fake := newLifecycleTestStackManager(t)
+	assert.NoError(t, s.InitStack(newLifecycleTestInitOptions(t, "logs")))
+
+	out := &strings.Builder{}
+	assert.NoError(t, s.PrintLogs(&LogsOptions{Services: []string{"core"}, Member: "0", Follow: true, Since: "10m", Tail: "50"}, out))
+	assert.NoError(t, s.PrintLogs(&LogsOptions{Color: true}, out))
+	assert.Equal(t, [][]string{
+		{"-p", "logs", "logs", "--no-color", "-f", "--since", "10m", "--tail", "50", "firefly_core_0"},
+		{"--ansi", "always", "-p", "logs", "logs"},
+	}, fake.ComposeCommands)
+
+	// The tail of filtered logs is kept by the filter rather than compose
+	fake.ComposeCommands = nil
+	assert.NoError(t, s.PrintLogs(&LogsOptions{Tail: "5", Level: "warn"}, out))
+	assert.Equal(t, [][]string{{"-p", "logs", "logs", "--no-color"}}, fake.ComposeCommands)
+	assert.Regexp(t, "--tail cannot be used with --follow", s.PrintLogs(&LogsOptions{Tail: "5", Grep: "tx_", Follow: true}, out))
+	assert.NoError(t, s.PrintLogs(&LogsOptions{Tail: "all", Grep: "tx_", Follow: true}, out))
+
+	assert.Regexp(t, "invalid tail 'lots'", s.PrintLogs(&LogsOptions{Tail: "lots"}, out))
+	assert.Regexp(t, "invalid log level 'loud'", s.PrintLogs(&LogsOptions{Level: "loud"}, out))
+	assert.Regexp(t, "invalid grep expression", s.PrintLogs(&LogsOptions{Grep: "tx_("}, out))
+}
+
+func TestLogFilter(t *testing.T) {
+	logs := strings.Join([]string{
+		`firefly_core_0  | [2024-01-02T13:23:37.000Z]  INFO Starting up pid=1`,
+		`firefly_core_0  | [2024-01-02T13:23:38.000Z]  WARN Retrying request tx_123 pid=1`,
+		`evmconnect_0    | [2024-01-02T13:23:39.000Z] ERROR Failed to submit tx_123`,
+		`evmconnect_0    |   at stack frame`,
+		`firefly_core_0  | [2024-01-02T13:23:40.000Z] DEBUG Polling`,
+		`firefly_core_0  |   continuation of debug`,
+		`tokens_0_0      | {"level":50,"msg":"token error"}`,
+		`tokens_0_0      | {"level":30,"msg":"token info"}`,
+		`ethsigner       | {"level":"warn","msg":"signer warning"}`,
+	}, "\n") + "\n"
+
+	testCases := []struct {
+		name     string
+		options  *LogsOptions
+		expected []int
+	}{
+		{name: "NoFilter", options: &LogsOptions{}, expected: []int{0, 1, 2, 3, 4, 5, 6, 7, 8}},
+		{name: "Warn", options: &LogsOptions{Level: "warn"}, expected: []int{1, 2, 3, 6, 8}},
+		{name: "Error", options: &LogsOptions{Level: "ERROR"}, expected: []int{2, 3, 6}},
+		{name: "Grep", options: &LogsOptions{Grep: "tx_[0-9]+"}, expected: []int{1, 2}},
+		{name: "GrepAndLevel", options: &LogsOptions{Grep: "tx_", Level: "error"}, expected: []int{2}},
+		{name: "TailAfterLevel", options: &LogsOptions{Level: "warn", Tail: "1"}, expected: []int{1, 3, 6, 8}},
+		{name: "TailAfterGrep", options: &LogsOptions{Grep: "tx_", Tail: "5"}, expected: []int{1, 2}},
+		{name: "TailOfNothing", options: &LogsOptions{Level: "info", Tail: "0"}, expected: []int{}},
+		{name: "TailWithoutFilter", options: &LogsOptions{Tail: "1"}, expected: []int{0, 1, 2, 3, 4, 5, 6, 7, 8}},
+	}
+	lines := strings.Split(strings.TrimSuffix(logs, "\n"), "\n")
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := &strings.Builder{}
+			f, err := newLogFilter(tc.options, out)
+			assert.NoError(t, err)
+			// Output arrives in chunks that do not line up with the lines
+			for i := 0; i < len(logs); i += 7 {
+				end := i + 7
+				if end > len(logs) {
+					end = len(logs)
+				}
+				_, err := f.Write([]byte(logs[i:end]))
+				assert.NoError(t, err)
+			}
+			f.Flush()
+			expected := ""
+			for _, i := range tc.expected {
+				expected += lines[i] + "\n"
+			}
+			assert.Equal(t, expected, out.String())
+		})
+	}
+}
+
+func TestLogFilterKeepsOnlyTail(t *testing.T) {
+	out := &strings.Builder{}
+	f, err := newLogFilter(&LogsOptions{Grep: "tx_", Tail: "2"}, out)
+	assert.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		_, err := fmt.Fprintf(f, "firefly_core_0  | tx_%d\nevmconnect_0    | tx_%d\n", i, i)
+		assert.NoError(t, err)
+	}
+	assert.Len(t, f.tailed["firefly_core_0"].lines, 2)
+	assert.Len(t, f.tailed["evmconnect_0"].lines, 2)
+
+	f.Flush()
+	assert.Equal(t, "firefly_core_0  | tx_998\nevmconnect_0    | tx_998\nfirefly_core_0  | tx_999\nevmconnect_0    | tx_999\n", out.String())
+}
+
+func TestLogFilterColor(t *testing.T) {
+	out := &strings.Builder{}
+	f, err := newLogFilter(&LogsOptions{Color: true}, out)
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("\x1b[36mfirefly_core_0  |\x1b[0m [2024-01-02T13:23:37.000Z] ERROR Failed\n" +
+		"\x1b[36mfirefly_core_0  |\x1b[0m [2024-01-02T13:23:37.000Z]  INFO Fine\n" +
+		"\x1b[36mfirefly_core_0  |\x1b[0m no level"))
+	assert.NoError(t, err)
+	f.Flush()
+	assert.Equal(t, "\x1b[36mfirefly_core_0  |\x1b[0m [2024-01-02T13:23:37.000Z] \x1b[31mERROR\x1b[0m Failed\n"+
+		"\x1b[36mfirefly_core_0  |\x1b[0m [2024-01-02T13:23:37.000Z]  INFO Fine\n"+
+		"\x1b[36mfirefly_core_0  |\x1b[0m no level\n", out.String())
+}
@@ -0,0 +1,409 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/hyperledger/firefly-cli/internal/core"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/otiai10/copy"
+	"gopkg.in/yaml.v3"
+)
+
+// AddMember onboards a new member to a stack that has already been started, and starts
+// the new member's containers alongside the existing ones
+func (s *StackManager) AddMember(orgName, nodeName string) (*types.Organization, error) {
+	if s.IsOldFileStructure {
+		return nil, fmt.Errorf("the FireFly stack '%s' was created with an older version of the CLI and adding members is not supported", s.Stack.Name)
+	}
+	hasRunBefore, err := s.Stack.HasRunBefore()
+	if err != nil {
+		return nil, err
+	}
+	if !hasRunBefore {
+		return nil, fmt.Errorf("stack '%s' has not been started yet - members can only be added to a stack that has been started", s.Stack.Name)
+	}
+	if len(s.Stack.Members) == 0 {
+		return nil, fmt.Errorf("stack '%s' has no members", s.Stack.Name)
+	}
+	for _, m := range s.Stack.Members {
+		if m.OrgName == orgName {
+			return nil, fmt.Errorf("org name '%s' is already used by member %s", orgName, m.ID)
+		}
+		if m.NodeName == nodeName {
+			return nil, fmt.Errorf("node name '%s' is already used by member %s", nodeName, m.ID)
+		}
+	}
+
+	index := s.nextMemberIndex()
+	member := newMember(fmt.Sprint(index), index, s.memberInitOptions(index, orgName, nodeName), false)
+	if err := checkMemberPortsAvailable(member); err != nil {
+		return nil, err
+	}
+
+	configDir := filepath.Join(s.Stack.RuntimeDir, "config")
+	if err := os.MkdirAll(filepath.Join(configDir, "dataexchange_"+member.ID, "peer-certs"), 0755); err != nil {
+		return nil, err
+	}
+
+	s.Log.Info(fmt.Sprintf("creating blockchain account for member %s", member.ID))
+	account, err := s.blockchainProvider.AddMember(member)
+	if err != nil {
+		return nil, err
+	}
+	member.Account = account
+	s.Stack.Members = append(s.Stack.Members, member)
+	if account != nil {
+		s.Stack.State.Accounts = append(s.Stack.State.Accounts, account)
+	}
+
+	s.Log.Info(fmt.Sprintf("writing config for member %s", member.ID))
+	if err := s.writeMemberDataExchangeCerts(configDir, member); err != nil {
+		return nil, err
+	}
+	if err := s.copyMemberDataExchangeConfigToVolume(configDir, member); err != nil {
+		return nil, err
+	}
+	if err := s.writeFireflyCoreConfig(configDir, member, ""); err != nil {
+		return nil, err
+	}
+	if err := s.patchNewMemberConfig(configDir, member); err != nil {
+		return nil, err
+	}
+
+	// Create data directory with correct permissions inside volume
+	dataVolumeName := fmt.Sprintf("%s_firefly_core_data_%s", s.Stack.Name, member.ID)
+	if err := docker.CreateVolume(s.ctx, dataVolumeName); err != nil {
+		return nil, err
+	}
+	if err := docker.MkdirInVolume(s.ctx, dataVolumeName, "db"); err != nil {
+		return nil, err
+	}
+
+	if s.Stack.PrometheusEnabled {
+		if err := s.writePrometheusConfig(configDir); err != nil {
+			return nil, err
+		}
+		volumeName := fmt.Sprintf("%s_prometheus_config", s.Stack.Name)
+		if err := docker.CopyFileToVolume(s.ctx, volumeName, path.Join(configDir, "prometheus.yml"), "/prometheus.yml"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.copyMemberConfigToInitDir(member); err != nil {
+		return nil, err
+	}
+	if err := s.writeDockerCompose(s.buildDockerCompose()); err != nil {
+		return nil, err
+	}
+	if err := s.writeStackConfig(); err != nil {
+		return nil, err
+	}
+	if err := s.writeStackStateJSON(s.Stack.RuntimeDir); err != nil {
+		return nil, err
+	}
+
+	s.Log.Info(fmt.Sprintf("starting containers for member %s", member.ID))
+	if err := s.runDockerComposeCommand("up", "-d"); err != nil {
+		return nil, err
+	}
+	if s.Stack.PrometheusEnabled {
+		if err := s.runDockerComposeCommand("restart", "prometheus"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.blockchainProvider.PostAddMember(member); err != nil {
+		return nil, err
+	}
+
+	if s.Stack.MultipartyEnabled && s.Stack.ContractAddress == "" {
+		if err := s.registerFireflyIdentity(member); err != nil {
+			return nil, err
+		}
+	}
+
+	for iTok := range s.tokenProviders {
+		s.Log.Info(fmt.Sprintf("initializing tokens on member %s", member.ID))
+		tokenInitURL := fmt.Sprintf("http://localhost:%d/api/v1/init", member.ExposedTokensPorts[iTok])
+		if err := core.RequestWithRetry(s.ctx, http.MethodPost, tokenInitURL, nil, nil); err != nil {
+			return nil, err
+		}
+	}
+	return member, nil
+}
+
+// RemoveMember stops and removes the containers and volumes that belong to a single member of the
+// stack, and drops the member from the stack config. The containers of the other members are left
+// running. The member's blockchain account is kept in the stack state, as it still exists on chain.
+func (s *StackManager) RemoveMember(memberID string) error {
+	member, err := s.findMemberToRemove(memberID)
+	if err != nil {
+		return err
+	}
+	if s.Stack.BlockchainNodeProvider.Equals(types.BlockchainNodeProviderQuorum) {
+		return fmt.Errorf("removing members is not supported for stacks using quorum")
+	}
+
+	services := s.getMemberServiceNames(member)
+	volumes := s.getMemberVolumeNames(member)
+
+	s.Log.Info(fmt.Sprintf("removing containers for member %s", member.ID))
+	if err := s.runDockerComposeCommand(append([]string{"rm", "--stop", "--force"}, services...)...); err != nil {
+		return err
+	}
+	for _, volumeName := range volumes {
+		fullVolumeName := fmt.Sprintf("%s_%s", s.Stack.Name, volumeName)
+		s.Log.Info(fmt.Sprintf("removing volume '%s'", fullVolumeName))
+		// Some volumes, such as that of a member whose stack was never started, do not exist
+		if err := docker.RemoveVolume(s.ctx, fullVolumeName); err != nil && !docker.IsNoSuchVolumeError(err) {
+			return err
+		}
+	}
+
+	s.Stack.Members = removeMember(s.Stack.Members, member)
+	if err := s.writeDockerCompose(s.buildDockerCompose()); err != nil {
+		return err
+	}
+	if err := s.writeStackConfig(); err != nil {
+		return err
+	}
+
+	if s.Stack.PrometheusEnabled {
+		configDir := filepath.Join(s.Stack.RuntimeDir, "config")
+		if err := s.writePrometheusConfig(configDir); err != nil {
+			return err
+		}
+		volumeName := fmt.Sprintf("%s_prometheus_config", s.Stack.Name)
+		if err := docker.CopyFileToVolume(s.ctx, volumeName, path.Join(configDir, "prometheus.yml"), "/prometheus.yml"); err != nil {
+			return err
+		}
+		if err := s.runDockerComposeCommand("restart", "prometheus"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisableMember stops the containers that belong to a single member of the stack, without removing
+// any of its data. The member is started again the next time the whole stack is started.
+func (s *StackManager) DisableMember(memberID string) error {
+	member, err := s.findMemberToRemove(memberID)
+	if err != nil {
+		return err
+	}
+	s.Log.Info(fmt.Sprintf("stopping containers for member %s", member.ID))
+	return s.runDockerComposeCommand(append([]string{"stop"}, s.getMemberServiceNames(member)...)...)
+}
+
+func (s *StackManager) findMemberToRemove(memberID string) (*types.Organization, error) {
+	if s.IsOldFileStructure {
+		return nil, fmt.Errorf("the FireFly stack '%s' was created with an older version of the CLI and removing members is not supported", s.Stack.Name)
+	}
+	for _, m := range s.Stack.Members {
+		if m.ID == memberID {
+			if len(s.Stack.Members) == 1 {
+				return nil, fmt.Errorf("member %s is the only member of stack '%s' and cannot be removed", memberID, s.Stack.Name)
+			}
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("stack '%s' does not have a member with ID '%s'", s.Stack.Name, memberID)
+}
+
+// getMemberServiceNames returns the names of the docker compose services that only exist because
+// the given member is part of the stack
+func (s *StackManager) getMemberServiceNames(member *types.Organization) []string {
+	allServices := s.buildDockerCompose().Services
+	members := s.Stack.Members
+	s.Stack.Members = removeMember(members, member)
+	otherServices := s.buildDockerCompose().Services
+	s.Stack.Members = members
+
+	serviceNames := []string{}
+	for serviceName := range allServices {
+		if _, ok := otherServices[serviceName]; !ok {
+			serviceNames = append(serviceNames, serviceName)
+		}
+	}
+	sort.Strings(serviceNames)
+	return serviceNames
+}
+
+// getMemberVolumeNames returns the names of the volumes that only exist because the given member
+// is part of the stack, without the stack name prefix
+func (s *StackManager) getMemberVolumeNames(member *types.Organization) []string {
+	allVolumes := s.getVolumeNames()
+	members := s.Stack.Members
+	s.Stack.Members = removeMember(members, member)
+	otherVolumes := make(map[string]bool)
+	for _, volumeName := range s.getVolumeNames() {
+		otherVolumes[volumeName] = true
+	}
+	s.Stack.Members = members
+
+	volumeNames := []string{}
+	for _, volumeName := range allVolumes {
+		if !otherVolumes[volumeName] {
+			volumeNames = append(volumeNames, volumeName)
+		}
+	}
+	return volumeNames
+}
+
+func removeMember(members []*types.Organization, member *types.Organization) []*types.Organization {
+	result := make([]*types.Organization, 0, len(members))
+	for _, m := range members {
+		if m != member {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// nextMemberIndex returns an index that has not been used by any member of the stack, which is
+// not necessarily the number of members if any have been removed
+func (s *StackManager) nextMemberIndex() int {
+	index := 0
+	for i, m := range s.Stack.Members {
+		if memberIndex(m, i) >= index {
+			index = memberIndex(m, i) + 1
+		}
+	}
+	return index
+}
+
+// memberIndex returns the index of a member. Stacks from older versions of the CLI may not
+// have recorded it, in which case it is the member's position in the list.
+func memberIndex(member *types.Organization, position int) int {
+	if member.Index != nil {
+		return *member.Index
+	}
+	return position
+}
+
+// memberInitOptions rebuilds the parts of the original init options that are needed to allocate
+// ports for a new member
+func (s *StackManager) memberInitOptions(index int, orgName, nodeName string) *types.InitOptions {
+	first := s.Stack.Members[0]
+	options := &types.InitOptions{
+		FireFlyBasePort:   first.ExposedFireflyPort - memberIndex(first, 0),
+		ServicesBasePort:  s.Stack.ExposedBlockchainPort,
+		PtmBasePort:       s.Stack.ExposedPtmPort,
+		PrometheusEnabled: s.Stack.PrometheusEnabled,
+		SandboxEnabled:    s.Stack.SandboxEnabled,
+		TokenProviders:    make([]string, len(s.Stack.TokenProviders)),
+		OrgNames:          make([]string, index+1),
+		NodeNames:         make([]string, index+1),
+	}
+	options.OrgNames[index] = orgName
+	options.NodeNames[index] = nodeName
+	return options
+}
+
+// patchNewMemberConfig copies the namespace config of an existing member into the config of a new
+// member, so that the new member joins the same multiparty network
+func (s *StackManager) patchNewMemberConfig(configDir string, member *types.Organization) error {
+	existingConfigBytes, err := os.ReadFile(filepath.Join(configDir, fmt.Sprintf("firefly_core_%s.yml", s.Stack.Members[0].ID)))
+	if err != nil {
+		return err
+	}
+	var existingConfig *types.FireflyConfig
+	if err := yaml.Unmarshal(existingConfigBytes, &existingConfig); err != nil {
+		return err
+	}
+	if existingConfig.Namespaces == nil {
+		return nil
+	}
+
+	orgConfig := s.blockchainProvider.GetOrgConfig(s.Stack, member)
+	for _, namespace := range existingConfig.Namespaces.Predefined {
+		namespace.DefaultKey = orgConfig.Key
+		if namespace.Multiparty != nil && namespace.Multiparty.Enabled {
+			namespace.Multiparty.Org = orgConfig
+			namespace.Multiparty.Node = &types.NodeConfig{
+				Name: member.NodeName,
+			}
+		}
+	}
+	return s.patchFireFlyCoreConfigs(configDir, member, &types.FireflyConfig{
+		Namespaces: existingConfig.Namespaces,
+	})
+}
+
+// copyMemberConfigToInitDir copies the files that were written for a new member to the init
+// directory, as that is what the runtime directory is created from when a reset stack is started
+func (s *StackManager) copyMemberConfigToInitDir(member *types.Organization) error {
+	paths := []string{
+		filepath.Join("config", fmt.Sprintf("firefly_core_%s.yml", member.ID)),
+		filepath.Join("config", "dataexchange_"+member.ID),
+		filepath.Join("config", fmt.Sprintf("%s_%s.yaml", s.blockchainProvider.GetConnectorName(), member.ID)),
+		filepath.Join("config", "prometheus.yml"),
+		// The wallet of the member's account, for the providers that keep one
+		filepath.Join("blockchain", "keystore"),
+	}
+	for _, p := range paths {
+		source := filepath.Join(s.Stack.RuntimeDir, p)
+		if _, err := os.Stat(source); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := copy.Copy(source, filepath.Join(s.Stack.InitDir, p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkMemberPortsAvailable(member *types.Organization) error {
+	ports := []int{
+		member.ExposedFireflyPort,
+		member.ExposedFireflyAdminSPIPort,
+		member.ExposedConnectorPort,
+		member.ExposedUIPort,
+		member.ExposedDatabasePort,
+		member.ExposedDataexchangePort,
+		member.ExposedIPFSApiPort,
+		member.ExposedIPFSGWPort,
+		member.ExposedFireflyMetricsPort,
+		member.ExposedConnectorMetricsPort,
+		member.ExposedSandboxPort,
+		member.ExposePtmTpPort,
+	}
+	ports = append(ports, member.ExposedTokensPorts...)
+	for _, port := range ports {
+		if port == 0 {
+			continue
+		}
+		available, err := checkPortAvailable(port)
+		if err != nil {
+			return err
+		}
+		if !available {
+			return fmt.Errorf("port %d is unavailable. please check to see if another process is listening on that port", port)
+		}
+	}
+	return nil
+}
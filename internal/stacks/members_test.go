@@ -0,0 +1,146 @@
+package stacks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMemberMatchesInitPorts(t *testing.T) {
+	initOptions := &types.InitOptions{
+		FireFlyBasePort:   5000,
+		ServicesBasePort:  5100,
+		PtmBasePort:       4100,
+		PrometheusEnabled: true,
+		SandboxEnabled:    true,
+		TokenProviders:    []string{"erc20_erc721"},
+		OrgNames:          []string{"org_0", "org_1", "org_2"},
+		NodeNames:         []string{"node_0", "node_1", "node_2"},
+	}
+	members := []*types.Organization{
+		newMember("0", 0, initOptions, false),
+		newMember("1", 1, initOptions, false),
+	}
+	expected := newMember("2", 2, initOptions, false)
+
+	s := &StackManager{
+		Stack: &types.Stack{
+			Members:               members,
+			ExposedBlockchainPort: 5100,
+			ExposedPtmPort:        4100,
+			PrometheusEnabled:     true,
+			SandboxEnabled:        true,
+			TokenProviders:        []fftypes.FFEnum{types.TokenProviderERC20ERC721},
+		},
+	}
+	index := s.nextMemberIndex()
+	assert.Equal(t, 2, index)
+	added := newMember("2", index, s.memberInitOptions(index, "org_2", "node_2"), false)
+	assert.Equal(t, expected, added)
+}
+
+func TestNextMemberIndexAfterRemoval(t *testing.T) {
+	index0, index2 := 0, 2
+	s := &StackManager{
+		Stack: &types.Stack{
+			Members: []*types.Organization{
+				{ID: "0", Index: &index0},
+				{ID: "2", Index: &index2},
+			},
+		},
+	}
+	assert.Equal(t, 3, s.nextMemberIndex())
+}
+
+func TestAddMemberToStackWithoutIndexes(t *testing.T) {
+	initOptions := &types.InitOptions{
+		FireFlyBasePort:  5000,
+		ServicesBasePort: 5100,
+		OrgNames:         []string{"org_0", "org_1", "org_2"},
+		NodeNames:        []string{"node_0", "node_1", "node_2"},
+	}
+	members := []*types.Organization{
+		newMember("0", 0, initOptions, false),
+		newMember("1", 1, initOptions, false),
+	}
+	for _, m := range members {
+		m.Index = nil
+	}
+	expected := newMember("2", 2, initOptions, false)
+
+	s := &StackManager{
+		Stack: &types.Stack{
+			Members:               members,
+			ExposedBlockchainPort: 5100,
+		},
+	}
+	index := s.nextMemberIndex()
+	assert.Equal(t, 2, index)
+	added := newMember("2", index, s.memberInitOptions(index, "org_2", "node_2"), false)
+	assert.Equal(t, expected, added)
+}
+
+func TestGetMemberServiceAndVolumeNames(t *testing.T) {
+	initOptions := &types.InitOptions{
+		FireFlyBasePort:  5000,
+		ServicesBasePort: 5100,
+		SandboxEnabled:   true,
+		TokenProviders:   []string{"erc20_erc721"},
+		OrgNames:         []string{"org_0", "org_1"},
+		NodeNames:        []string{"node_0", "node_1"},
+	}
+	entry := &types.ManifestEntry{Image: "image", Tag: "latest"}
+	s := &StackManager{
+		ctx: context.Background(),
+		Stack: &types.Stack{
+			Name:                   "test",
+			Database:               types.DatabaseSelectionPostgres,
+			BlockchainProvider:     types.BlockchainProviderEthereum,
+			BlockchainNodeProvider: types.BlockchainNodeProviderGeth,
+			BlockchainConnector:    types.BlockchainConnectorEvmconnect,
+			TokenProviders:         []fftypes.FFEnum{types.TokenProviderERC20ERC721},
+			SandboxEnabled:         true,
+			State:                  &types.StackState{},
+			Members: []*types.Organization{
+				newMember("0", 0, initOptions, false),
+				newMember("1", 1, initOptions, false),
+			},
+			VersionManifest: &types.VersionManifest{
+				FireFly:           entry,
+				Evmconnect:        entry,
+				DataExchange:      entry,
+				TokensERC20ERC721: entry,
+			},
+		},
+	}
+	s.blockchainProvider = s.getBlockchainProvider()
+	s.tokenProviders = s.getITokenProviders()
+	member := s.Stack.Members[1]
+
+	assert.Equal(t, []string{
+		"dataexchange_1",
+		"evmconnect_1",
+		"firefly_core_1",
+		"ipfs_1",
+		"postgres_1",
+		"sandbox_1",
+		"tokens_1_0",
+	}, s.getMemberServiceNames(member))
+	assert.ElementsMatch(t, []string{
+		"evmconnect_data_1",
+		"evmconnect_config_1",
+		"dataexchange_1",
+		"firefly_core_data_1",
+		"ipfs_data_1",
+		"ipfs_staging_1",
+		"postgres_1",
+	}, s.getMemberVolumeNames(member))
+	assert.Len(t, s.Stack.Members, 2)
+
+	s.Stack.Members = removeMember(s.Stack.Members, member)
+	assert.Len(t, s.Stack.Members, 1)
+	assert.Equal(t, "0", s.Stack.Members[0].ID)
+}
@@ -0,0 +1,229 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/firefly-cli/internal/blockchain/ethereum/quorum"
+	"github.com/hyperledger/firefly-cli/internal/constants"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+)
+
+const maxPort = 65535
+
+// portGroup is a set of ports that are all offset from the same base port, so that they
+// move together when a free block of ports is being searched for
+type portGroup struct {
+	name  string
+	base  *int
+	step  int
+	ports []int
+}
+
+// getStackPorts returns every port a stack exposes on the host
+func getStackPorts(stack *types.Stack) []int {
+	ports := []int{stack.ExposedBlockchainPort}
+	if stack.BlockchainNodeProvider.Equals(types.BlockchainNodeProviderQuorum) {
+		for i := 1; i < len(stack.Members); i++ {
+			ports = append(ports, stack.ExposedBlockchainPort+(i*quorum.ExposedBlockchainPortMultiplier))
+		}
+	}
+	if stack.PrometheusEnabled {
+		ports = append(ports, stack.ExposedPrometheusPort)
+	}
+	usesPtm := stack.PrivateTransactionManager != "" && !stack.PrivateTransactionManager.Equals(types.PrivateTransactionManagerNone)
+	for _, member := range stack.Members {
+		ports = append(ports,
+			member.ExposedFireflyPort,
+			member.ExposedFireflyAdminSPIPort,
+			member.ExposedFireflyMetricsPort,
+			member.ExposedUIPort,
+			member.ExposedConnectorPort,
+			member.ExposedConnectorMetricsPort,
+			member.ExposedDatabasePort,
+			member.ExposedDataexchangePort,
+			member.ExposedIPFSApiPort,
+			member.ExposedIPFSGWPort,
+			member.ExposedSandboxPort,
+		)
+		ports = append(ports, member.ExposedTokensPorts...)
+		if usesPtm {
+			ports = append(ports, member.ExposePtmTpPort)
+		}
+	}
+	uniquePorts := make([]int, 0, len(ports))
+	seen := make(map[int]bool)
+	for _, port := range ports {
+		if port != 0 && !seen[port] {
+			seen[port] = true
+			uniquePorts = append(uniquePorts, port)
+		}
+	}
+	sort.Ints(uniquePorts)
+	return uniquePorts
+}
+
+// getReservedPorts returns the ports used by every stack in the stacks directory, other than the
+// stack being created, mapped to the name of the stack that uses them. A stack that cannot be
+// read is skipped, so that it does not stop a new stack from being created.
+func (s *StackManager) getReservedPorts(excludeStackName string) (map[int]string, error) {
+	reserved := make(map[int]string)
+	stackNames, err := ListStacks()
+	if errors.Is(err, os.ErrNotExist) {
+		return reserved, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, stackName := range stackNames {
+		if stackName == excludeStackName {
+			continue
+		}
+		d, err := os.ReadFile(filepath.Join(constants.StacksDir, stackName, "stack.json"))
+		if err != nil {
+			s.Log.Warn(fmt.Sprintf("not checking the ports of stack '%s': %s", stackName, err))
+			continue
+		}
+		var stack *types.Stack
+		if err := json.Unmarshal(d, &stack); err != nil {
+			s.Log.Warn(fmt.Sprintf("not checking the ports of stack '%s': failed to parse stack.json: %s", stackName, err))
+			continue
+		}
+		if stack == nil {
+			continue
+		}
+		for _, port := range getStackPorts(stack) {
+			reserved[port] = stackName
+		}
+	}
+	return reserved, nil
+}
+
+// getInitPortGroups splits the ports a new stack will use into groups that share a base port
+func getInitPortGroups(options *types.InitOptions) []*portGroup {
+	stack := &types.Stack{
+		ExposedBlockchainPort:     options.ServicesBasePort,
+		ExposedPrometheusPort:     options.PrometheusPort,
+		PrometheusEnabled:         options.PrometheusEnabled,
+		BlockchainNodeProvider:    fftypes.FFEnum(options.BlockchainNodeProvider),
+		PrivateTransactionManager: fftypes.FFEnum(options.PrivateTransactionManager),
+	}
+	for i := 0; i < options.MemberCount; i++ {
+		stack.Members = append(stack.Members, newMember(fmt.Sprint(i), i, options, i < options.ExternalProcesses))
+	}
+
+	fireflyGroup := &portGroup{name: "FireFly", base: &options.FireFlyBasePort, step: 10, ports: []int{}}
+	servicesGroup := &portGroup{name: "services", base: &options.ServicesBasePort, step: 100, ports: []int{}}
+	ptmGroup := &portGroup{name: "private transaction manager", base: &options.PtmBasePort, step: 10, ports: []int{}}
+	prometheusGroup := &portGroup{name: "Prometheus", base: &options.PrometheusPort, step: 1, ports: []int{}}
+	usesPtm := stack.PrivateTransactionManager != "" && !stack.PrivateTransactionManager.Equals(types.PrivateTransactionManagerNone)
+	for _, member := range stack.Members {
+		fireflyGroup.ports = append(fireflyGroup.ports, member.ExposedFireflyPort)
+		if usesPtm {
+			ptmGroup.ports = append(ptmGroup.ports, member.ExposePtmTpPort)
+		}
+		// Everything else that is left is offset from the services base port
+		member.ExposedFireflyPort = 0
+		member.ExposePtmTpPort = 0
+	}
+	if stack.PrometheusEnabled {
+		prometheusGroup.ports = append(prometheusGroup.ports, stack.ExposedPrometheusPort)
+		stack.PrometheusEnabled = false
+	}
+	servicesGroup.ports = getStackPorts(stack)
+	return []*portGroup{fireflyGroup, servicesGroup, ptmGroup, prometheusGroup}
+}
+
+// allocatePorts moves the base ports in the options up until every port the new stack will use
+// is neither reserved by another stack nor in use on the host
+func allocatePorts(options *types.InitOptions, reserved map[int]string, isAvailable func(port int) bool) error {
+	allocated := make(map[int]bool)
+	for i := range getInitPortGroups(options) {
+		for {
+			// The groups are rebuilt each time, as moving a base port moves all of the ports in its group
+			group := getInitPortGroups(options)[i]
+			free := true
+			for _, port := range group.ports {
+				if _, ok := reserved[port]; ok || allocated[port] || port > maxPort || !isAvailable(port) {
+					free = false
+					break
+				}
+			}
+			if free {
+				for _, port := range group.ports {
+					allocated[port] = true
+				}
+				break
+			}
+			*group.base += group.step
+			if *group.base > maxPort {
+				return fmt.Errorf("unable to find a free block of ports for %s", group.name)
+			}
+		}
+	}
+	return nil
+}
+
+// findPortProblems describes each of the given ports that is reserved by another stack or in use
+// on the host
+func findPortProblems(ports []int, reserved map[int]string, isAvailable func(port int) bool) []string {
+	problems := []string{}
+	for _, port := range ports {
+		if stackName, ok := reserved[port]; ok {
+			problems = append(problems, fmt.Sprintf("port %d is also used by stack '%s'", port, stackName))
+		} else if !isAvailable(port) {
+			problems = append(problems, fmt.Sprintf("port %d is in use on this host", port))
+		}
+	}
+	return problems
+}
+
+// checkInitPorts either moves the base ports of a new stack to a free block of ports, or warns
+// about any ports that will clash with other stacks or processes when the stack is started
+func (s *StackManager) checkInitPorts(options *types.InitOptions) error {
+	reserved, err := s.getReservedPorts(options.StackName)
+	if err != nil {
+		return err
+	}
+	if options.AutoPorts {
+		if err := allocatePorts(options, reserved, isPortAvailable); err != nil {
+			return err
+		}
+		s.Log.Info(fmt.Sprintf("using FireFly base port %d and services base port %d", options.FireFlyBasePort, options.ServicesBasePort))
+		return nil
+	}
+	ports := []int{}
+	for _, group := range getInitPortGroups(options) {
+		ports = append(ports, group.ports...)
+	}
+	if problems := findPortProblems(ports, reserved, isPortAvailable); len(problems) > 0 {
+		s.Log.Warn(fmt.Sprintf("%s. Use --auto-ports to choose ports that are free", strings.Join(problems, "; ")))
+	}
+	return nil
+}
+
+func isPortAvailable(port int) bool {
+	available, err := checkPortAvailable(port)
+	return err == nil && available
+}
@@ -0,0 +1,179 @@
+package stacks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/internal/constants"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPortsTestOptions() *types.InitOptions {
+	return &types.InitOptions{
+		MemberCount:       2,
+		FireFlyBasePort:   5000,
+		ServicesBasePort:  5100,
+		PtmBasePort:       4100,
+		PrometheusPort:    9090,
+		TokenProviders:    []string{"erc20_erc721"},
+		OrgNames:          []string{"org_0", "org_1"},
+		NodeNames:         []string{"node_0", "node_1"},
+		PrometheusEnabled: true,
+	}
+}
+
+func allPortsAvailable(port int) bool {
+	return true
+}
+
+func TestGetStackPorts(t *testing.T) {
+	stack := &types.Stack{
+		ExposedBlockchainPort: 5100,
+		ExposedPrometheusPort: 9090,
+		Members: []*types.Organization{
+			{ExposedFireflyPort: 5000, ExposedUIPort: 5000, ExposedTokensPorts: []int{5108}},
+		},
+	}
+	assert.Equal(t, []int{5000, 5100, 5108}, getStackPorts(stack))
+	stack.PrometheusEnabled = true
+	assert.Equal(t, []int{5000, 5100, 5108, 9090}, getStackPorts(stack))
+}
+
+func TestGetStackPortsQuorum(t *testing.T) {
+	stack := &types.Stack{
+		ExposedBlockchainPort:     5100,
+		BlockchainNodeProvider:    types.BlockchainNodeProviderQuorum,
+		PrivateTransactionManager: types.PrivateTransactionManagerTessera,
+		Members: []*types.Organization{
+			{ExposedFireflyPort: 5000, ExposePtmTpPort: 4100},
+			{ExposedFireflyPort: 5001, ExposePtmTpPort: 4110},
+		},
+	}
+	assert.Equal(t, []int{4100, 4110, 5000, 5001, 5100, 5110}, getStackPorts(stack))
+}
+
+func TestNewMemberServicePorts(t *testing.T) {
+	member := newMember("1", 1, newPortsTestOptions(), false)
+	assert.Equal(t, 5201, member.ExposedFireflyAdminSPIPort)
+	assert.Equal(t, 5205, member.ExposedDataexchangePort)
+	assert.Equal(t, 5206, member.ExposedIPFSApiPort)
+	assert.Equal(t, 5207, member.ExposedIPFSGWPort)
+	assert.Equal(t, 5208, member.ExposedFireflyMetricsPort)
+	assert.Equal(t, 5209, member.ExposedConnectorMetricsPort)
+	assert.Equal(t, []int{5210}, member.ExposedTokensPorts)
+}
+
+func TestAllocatePortsNoConflicts(t *testing.T) {
+	options := newPortsTestOptions()
+	assert.NoError(t, allocatePorts(options, map[int]string{}, allPortsAvailable))
+	assert.Equal(t, 5000, options.FireFlyBasePort)
+	assert.Equal(t, 5100, options.ServicesBasePort)
+	assert.Equal(t, 4100, options.PtmBasePort)
+	assert.Equal(t, 9090, options.PrometheusPort)
+}
+
+func TestAllocatePortsSkipsReservedPorts(t *testing.T) {
+	options := newPortsTestOptions()
+	reserved := map[int]string{5001: "other", 5205: "other", 9090: "other"}
+	assert.NoError(t, allocatePorts(options, reserved, allPortsAvailable))
+	assert.Equal(t, 5010, options.FireFlyBasePort)
+	assert.Equal(t, 5300, options.ServicesBasePort)
+	// The private transaction manager is not used, so its port does not move
+	assert.Equal(t, 4100, options.PtmBasePort)
+	assert.Equal(t, 9091, options.PrometheusPort)
+}
+
+func TestAllocatePortsSkipsPortsInUse(t *testing.T) {
+	options := newPortsTestOptions()
+	assert.NoError(t, allocatePorts(options, map[int]string{}, func(port int) bool {
+		return port != 5100
+	}))
+	assert.Equal(t, 5200, options.ServicesBasePort)
+}
+
+func TestAllocatePortsAvoidsOverlapBetweenGroups(t *testing.T) {
+	options := newPortsTestOptions()
+	options.FireFlyBasePort = 5105
+	assert.NoError(t, allocatePorts(options, map[int]string{}, allPortsAvailable))
+	assert.Equal(t, 5105, options.FireFlyBasePort)
+	assert.Equal(t, 5200, options.ServicesBasePort)
+}
+
+func TestAllocatePortsExhausted(t *testing.T) {
+	options := newPortsTestOptions()
+	err := allocatePorts(options, map[int]string{}, func(port int) bool {
+		return false
+	})
+	assert.Regexp(t, "unable to find a free block of ports for FireFly", err)
+}
+
+func TestFindPortProblems(t *testing.T) {
+	problems := findPortProblems([]int{5000, 5100, 5200}, map[int]string{5000: "other"}, func(port int) bool {
+		return port != 5100
+	})
+	assert.Equal(t, []string{
+		"port 5000 is also used by stack 'other'",
+		"port 5100 is in use on this host",
+	}, problems)
+}
+
+func TestGetReservedPorts(t *testing.T) {
+	stacksDir := constants.StacksDir
+	defer func() { constants.StacksDir = stacksDir }()
+	constants.StacksDir = t.TempDir()
+
+	writeStack := func(stack *types.Stack) {
+		assert.NoError(t, os.MkdirAll(filepath.Join(constants.StacksDir, stack.Name), 0755))
+		b, err := json.Marshal(stack)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(constants.StacksDir, stack.Name, "stack.json"), b, 0755))
+	}
+	writeStack(&types.Stack{
+		Name:                  "stack1",
+		ExposedBlockchainPort: 5100,
+		Members:               []*types.Organization{{ExposedFireflyPort: 5000}},
+	})
+	writeStack(&types.Stack{
+		Name:                  "stack2",
+		ExposedBlockchainPort: 5200,
+		Members:               []*types.Organization{{ExposedFireflyPort: 5010}},
+	})
+
+	s := &StackManager{Log: &log.StdoutLogger{}}
+	reserved, err := s.getReservedPorts("stack2")
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]string{5000: "stack1", 5100: "stack1"}, reserved)
+}
+
+func TestGetReservedPortsNoStacksDir(t *testing.T) {
+	stacksDir := constants.StacksDir
+	defer func() { constants.StacksDir = stacksDir }()
+	constants.StacksDir = filepath.Join(t.TempDir(), "missing")
+
+	s := &StackManager{Log: &log.StdoutLogger{}}
+	reserved, err := s.getReservedPorts("stack1")
+	assert.NoError(t, err)
+	assert.Empty(t, reserved)
+}
+
+func TestGetReservedPortsSkipsUnreadableStacks(t *testing.T) {
+	stacksDir := constants.StacksDir
+	defer func() { constants.StacksDir = stacksDir }()
+	constants.StacksDir = t.TempDir()
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(constants.StacksDir, "broken"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(constants.StacksDir, "broken", "stack.json"), []byte("{"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(constants.StacksDir, "stack1"), 0755))
+	b, err := json.Marshal(&types.Stack{Name: "stack1", Members: []*types.Organization{{ExposedFireflyPort: 5000}}})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(constants.StacksDir, "stack1", "stack.json"), b, 0755))
+
+	s := &StackManager{Log: &log.StdoutLogger{}}
+	reserved, err := s.getReservedPorts("stack2")
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]string{5000: "stack1"}, reserved)
+}
@@ -57,11 +57,11 @@ func (s *StackManager) GeneratePrometheusConfig() *PrometheusConfig {
 		},
 	}
 
-	for i, member := range s.Stack.Members {
-		config.ScrapeConfigs[0].StaticConfigs[0].Targets = append(config.ScrapeConfigs[0].StaticConfigs[0].Targets, fmt.Sprintf("firefly_core_%d:%d", i, member.ExposedFireflyMetricsPort))
+	for _, member := range s.Stack.Members {
+		config.ScrapeConfigs[0].StaticConfigs[0].Targets = append(config.ScrapeConfigs[0].StaticConfigs[0].Targets, fmt.Sprintf("firefly_core_%s:%d", member.ID, member.ExposedFireflyMetricsPort))
 
 		if s.blockchainProvider.GetConnectorName() == "evmconnect" {
-			config.ScrapeConfigs[0].StaticConfigs[0].Targets = append(config.ScrapeConfigs[0].StaticConfigs[0].Targets, fmt.Sprintf("evmconnect_%d:%d", i, member.ExposedConnectorMetricsPort))
+			config.ScrapeConfigs[0].StaticConfigs[0].Targets = append(config.ScrapeConfigs[0].StaticConfigs[0].Targets, fmt.Sprintf("evmconnect_%s:%d", member.ID, member.ExposedConnectorMetricsPort))
 		}
 	}
 
@@ -0,0 +1,193 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+)
+
+const defaultPullConcurrency = 4
+
+// defaultPullRetries is how many times a failed pull is retried when the CLI pulls images
+// itself, rather than because of ff pull, where it is set by --retries
+const defaultPullRetries = 2
+
+// imagePuller pulls a set of images concurrently, skipping any that are already up to date
+type imagePuller struct {
+	log         log.Logger
+	concurrency int
+	isPresent   func(image string) bool
+	pull        func(image string) error
+}
+
+// pullProgress keeps track of every image being pulled, so that a single line describing
+// all of them can be logged each time one of them changes state
+type pullProgress struct {
+	mu      sync.Mutex
+	log     log.Logger
+	total   int
+	done    int
+	skipped int
+	pulling []string
+}
+
+func (s *StackManager) pullImages(images []string, options *types.PullOptions) error {
+	puller := &imagePuller{
+		log:         s.Log,
+		concurrency: options.Concurrency,
+		isPresent: func(image string) bool {
+			return isImageUpToDate(s.ctx, image)
+		},
+		pull: func(image string) error {
+			args := []string{"pull", image}
+			if unsupportedARM64Images[image] && runtime.GOARCH == "arm64" {
+				args = append(args, "--platform=linux/amd64")
+			}
+			return docker.RunDockerCommandRetry(s.ctx, s.Stack.InitDir, options.Retries, args...)
+		},
+	}
+	return puller.run(images)
+}
+
+// isImageUpToDate returns true if the image has already been pulled and its digest matches the
+// one in the registry. If the registry cannot be reached the image is pulled anyway, so that
+// docker reports the real problem.
+func isImageUpToDate(ctx context.Context, image string) bool {
+	localDigests, exists := docker.GetLocalImageDigests(ctx, image)
+	if !exists {
+		return false
+	}
+	if strings.Contains(image, "@sha256:") {
+		// Images referenced by digest cannot change
+		return true
+	}
+	digest, err := docker.DockerManagerFromContext(ctx).GetImageDigest(image)
+	if err != nil {
+		return false
+	}
+	for _, localDigest := range localDigests {
+		if strings.HasSuffix(localDigest, "@"+digest) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkImagesPresent returns an error listing every image that has not been pulled or loaded
+func checkImagesPresent(ctx context.Context, images []string) error {
+	missing := []string{}
+	for _, image := range images {
+		if _, exists := docker.GetLocalImageDigests(ctx, image); !exists {
+			missing = append(missing, image)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the following images are not present locally: %s. Load them from an image bundle with 'ff images load'", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (p *imagePuller) run(images []string) error {
+	concurrency := p.concurrency
+	if concurrency <= 0 {
+		concurrency = defaultPullConcurrency
+	}
+	progress := &pullProgress{log: p.log, total: len(images)}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var pullErr error
+	failed := func() bool {
+		errMu.Lock()
+		defer errMu.Unlock()
+		return pullErr != nil
+	}
+
+	for _, image := range images {
+		sem <- struct{}{}
+		// Once one image has failed there is no point starting any more
+		if failed() {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(image string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if p.isPresent(image) {
+				progress.skip(image)
+				return
+			}
+			progress.start(image)
+			err := p.pull(image)
+			progress.finish(image)
+			if err != nil {
+				errMu.Lock()
+				if pullErr == nil {
+					pullErr = fmt.Errorf("failed to pull image '%s': %s", image, err)
+				}
+				errMu.Unlock()
+			}
+		}(image)
+	}
+	wg.Wait()
+	return pullErr
+}
+
+func (p *pullProgress) skip(image string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.skipped++
+	p.report(fmt.Sprintf("'%s' is up to date", image))
+}
+
+func (p *pullProgress) start(image string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pulling = append(p.pulling, image)
+	p.report(fmt.Sprintf("pulling '%s'", image))
+}
+
+func (p *pullProgress) finish(image string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	for i, pulling := range p.pulling {
+		if pulling == image {
+			p.pulling = append(p.pulling[:i], p.pulling[i+1:]...)
+			break
+		}
+	}
+	p.report(fmt.Sprintf("finished pulling '%s'", image))
+}
+
+func (p *pullProgress) report(event string) {
+	msg := fmt.Sprintf("[%d/%d images] %s", p.done, p.total, event)
+	if len(p.pulling) > 0 {
+		msg = fmt.Sprintf("%s (still pulling %s)", msg, strings.Join(p.pulling, ", "))
+	}
+	p.log.Info(msg)
+}
@@ -0,0 +1,83 @@
+package stacks
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-cli/internal/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImagePullerSkipsPresentImages(t *testing.T) {
+	var mu sync.Mutex
+	pulled := []string{}
+	puller := &imagePuller{
+		log: &log.StdoutLogger{},
+		isPresent: func(image string) bool {
+			return image == "postgres"
+		},
+		pull: func(image string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			pulled = append(pulled, image)
+			return nil
+		},
+	}
+	assert.NoError(t, puller.run([]string{"firefly", "postgres", "ipfs"}))
+	sort.Strings(pulled)
+	assert.Equal(t, []string{"firefly", "ipfs"}, pulled)
+}
+
+func TestImagePullerLimitsConcurrency(t *testing.T) {
+	var running, maxRunning int32
+	puller := &imagePuller{
+		log:         &log.StdoutLogger{},
+		concurrency: 2,
+		isPresent: func(image string) bool {
+			return false
+		},
+		pull: func(image string) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		},
+	}
+	images := []string{}
+	for i := 0; i < 8; i++ {
+		images = append(images, fmt.Sprintf("image%d", i))
+	}
+	assert.NoError(t, puller.run(images))
+	assert.Equal(t, int32(2), maxRunning)
+}
+
+func TestImagePullerStopsOnError(t *testing.T) {
+	var pulls int32
+	puller := &imagePuller{
+		log:         &log.StdoutLogger{},
+		concurrency: 1,
+		isPresent: func(image string) bool {
+			return false
+		},
+		pull: func(image string) error {
+			atomic.AddInt32(&pulls, 1)
+			if image == "bad" {
+				return fmt.Errorf("pop")
+			}
+			return nil
+		},
+	}
+	err := puller.run([]string{"good", "bad", "other", "another"})
+	assert.Regexp(t, "failed to pull image 'bad': pop", err)
+	assert.LessOrEqual(t, pulls, int32(3))
+}
@@ -0,0 +1,68 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"strings"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+)
+
+// resourceGroupPrefixes maps the start of each docker compose service name to the group
+// of resources it uses. Services that are not in the list, such as the sandbox, are never limited.
+var resourceGroupPrefixes = []struct {
+	prefix string
+	group  string
+}{
+	{"firefly_core_", types.ResourceGroupCore},
+	{"ethconnect_", types.ResourceGroupConnector},
+	{"evmconnect_", types.ResourceGroupConnector},
+	{"fabconnect_", types.ResourceGroupConnector},
+	{"tezosconnect_", types.ResourceGroupConnector},
+	{"geth", types.ResourceGroupNode},
+	{"besu", types.ResourceGroupNode},
+	{"quorum_", types.ResourceGroupNode},
+	{"tessera_", types.ResourceGroupNode},
+	{"ethsigner", types.ResourceGroupNode},
+	{"tezossigner", types.ResourceGroupNode},
+	{"fabric_", types.ResourceGroupNode},
+	{"ipfs_", types.ResourceGroupIPFS},
+	{"postgres_", types.ResourceGroupPostgres},
+	{"tokens_", types.ResourceGroupTokens},
+	{"dataexchange_", types.ResourceGroupDataExchange},
+}
+
+func resourceGroup(serviceName string) string {
+	for _, p := range resourceGroupPrefixes {
+		if strings.HasPrefix(serviceName, p.prefix) {
+			return p.group
+		}
+	}
+	return ""
+}
+
+// applyResources sets the CPU and memory limits and reservations of each service
+func (s *StackManager) applyResources(compose *docker.DockerComposeConfig) {
+	for serviceName, service := range compose.Services {
+		if resources, ok := s.Stack.Resources[resourceGroup(serviceName)]; ok {
+			service.Deploy = map[string]interface{}{
+				"resources": resources,
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package stacks
+
+import (
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceProfileLimitsServices(t *testing.T) {
+	s, _ := newLifecycleTestStackManager(t)
+	options := newLifecycleTestInitOptions(t, "limited")
+	options.DatabaseProvider = "postgres"
+	options.TokenProviders = []string{"erc20_erc721"}
+	options.SandboxEnabled = true
+	options.ResourceProfile = "small"
+	options.Resources = map[string]*types.ServiceResources{
+		types.ResourceGroupNode: {Limits: &types.ResourceLimits{Memory: "2G"}},
+	}
+	assert.NoError(t, s.InitStack(options))
+
+	compose := s.buildDockerCompose()
+	deployResources := func(serviceName string) interface{} {
+		service := compose.Services[serviceName]
+		if assert.NotNil(t, service, serviceName) && assert.NotNil(t, service.Deploy, serviceName) {
+			return service.Deploy["resources"]
+		}
+		return nil
+	}
+	assert.Equal(t, &types.ServiceResources{
+		Limits:       &types.ResourceLimits{CPUs: "0.5", Memory: "512M"},
+		Reservations: &types.ResourceLimits{CPUs: "0.1", Memory: "128M"},
+	}, deployResources("firefly_core_1"))
+	assert.Equal(t, &types.ServiceResources{
+		Limits:       &types.ResourceLimits{CPUs: "1", Memory: "2G"},
+		Reservations: &types.ResourceLimits{CPUs: "0.25", Memory: "256M"},
+	}, deployResources("geth"))
+	for _, serviceName := range []string{"evmconnect_0", "ipfs_0", "postgres_0", "tokens_0_0", "dataexchange_0"} {
+		assert.NotNil(t, deployResources(serviceName))
+	}
+	assert.Nil(t, compose.Services["sandbox_0"].Deploy)
+
+	// The resolved resources are kept with the stack
+	loaded := NewStackManager(s.ctx)
+	assert.NoError(t, loaded.LoadStack("limited"))
+	assert.Equal(t, "small", loaded.Stack.ResourceProfile)
+	assert.Equal(t, s.Stack.Resources, loaded.Stack.Resources)
+}
+
+func TestDefaultResourceProfileSetsNoLimits(t *testing.T) {
+	s, _ := newLifecycleTestStackManager(t)
+	assert.NoError(t, s.InitStack(newLifecycleTestInitOptions(t, "unlimited")))
+	assert.Nil(t, s.Stack.Resources)
+	for serviceName, service := range s.buildDockerCompose().Services {
+		assert.Nil(t, service.Deploy, serviceName)
+	}
+}
+
+func TestResolveResourcesErrors(t *testing.T) {
+	_, err := types.ResolveResources("huge", nil)
+	assert.Regexp(t, "not a valid enum value", err)
+	_, err = types.ResolveResources("", map[string]*types.ServiceResources{"sandbox": {}})
+	assert.Regexp(t, "unknown resource group 'sandbox'", err)
+	_, err = types.ResolveResources("", map[string]*types.ServiceResources{"core": {Limits: &types.ResourceLimits{CPUs: "-1"}}})
+	assert.Regexp(t, "invalid resources for 'core': cpus must be a positive number", err)
+	_, err = types.ResolveResources("large", map[string]*types.ServiceResources{"core": {Reservations: &types.ResourceLimits{Memory: "lots"}}})
+	assert.Regexp(t, "memory must be a size", err)
+	// The large profile reserves 1G for the node, which is more than the new limit
+	_, err = types.ResolveResources("large", map[string]*types.ServiceResources{"node": {Limits: &types.ResourceLimits{Memory: "512M"}}})
+	assert.Regexp(t, `invalid resources for 'node': the reserved memory \(1G\) cannot be more than the limit \(512M\)`, err)
+	_, err = types.ResolveResources("small", map[string]*types.ServiceResources{"core": {Reservations: &types.ResourceLimits{CPUs: "0.75"}}})
+	assert.Regexp(t, `the reserved cpus \(0.75\) cannot be more than the limit \(0.5\)`, err)
+	resolved, err := types.ResolveResources("large", map[string]*types.ServiceResources{"node": {Limits: &types.ResourceLimits{Memory: "1024m"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "1024m", resolved["node"].Limits.Memory)
+}
@@ -0,0 +1,318 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/firefly-cli/internal/constants"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/otiai10/copy"
+)
+
+const snapshotVersion = 1
+
+const (
+	snapshotManifestFile = "snapshot.json"
+	snapshotStackDir     = "stack"
+	snapshotVolumesDir   = "volumes"
+)
+
+type SnapshotManifest struct {
+	Version   int      `json:"version"`
+	StackName string   `json:"stackName"`
+	Created   string   `json:"created"`
+	Volumes   []string `json:"volumes"`
+}
+
+// SnapshotStack writes the stack configuration, along with the contents of every
+// volume the stack owns, to a single gzipped tar archive. The stack should be
+// stopped before calling this, so that the volume contents are consistent.
+func (s *StackManager) SnapshotStack(filename string) (err error) {
+	if s.IsOldFileStructure {
+		return fmt.Errorf("the FireFly stack '%s' was created with an older version of the CLI and snapshots are not supported", s.Stack.Name)
+	}
+
+	workDir, err := os.MkdirTemp("", "ff-snapshot-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	manifest := &SnapshotManifest{
+		Version:   snapshotVersion,
+		StackName: s.Stack.Name,
+		Created:   time.Now().UTC().Format(time.RFC3339),
+		Volumes:   []string{},
+	}
+	for _, volumeName := range s.getVolumeNames() {
+		fullVolumeName := fmt.Sprintf("%s_%s", s.Stack.Name, volumeName)
+		exists, err := docker.VolumeExists(s.ctx, fullVolumeName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// Volumes are only created the first time the stack is started
+			continue
+		}
+		s.Log.Info(fmt.Sprintf("exporting volume '%s'", fullVolumeName))
+		if err := docker.ExportVolume(s.ctx, fullVolumeName, workDir, volumeName+".tar"); err != nil {
+			return err
+		}
+		manifest.Volumes = append(manifest.Volumes, volumeName)
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(workDir, snapshotManifestFile), manifestBytes, 0644); err != nil {
+		return err
+	}
+
+	s.Log.Info(fmt.Sprintf("writing snapshot to '%s'", filename))
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Do not leave a truncated archive behind. This runs after the file has been closed.
+		if err != nil {
+			_ = os.Remove(filename)
+		}
+	}()
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	defer gw.Close()
+	tw := tar.NewWriter(gw)
+	defer tw.Close()
+
+	if err := addFileToArchive(tw, filepath.Join(workDir, snapshotManifestFile), snapshotManifestFile); err != nil {
+		return err
+	}
+	if err := addDirToArchive(tw, s.Stack.StackDir, snapshotStackDir); err != nil {
+		return err
+	}
+	for _, volumeName := range manifest.Volumes {
+		if err := addFileToArchive(tw, filepath.Join(workDir, volumeName+".tar"), filepath.Join(snapshotVolumesDir, volumeName+".tar")); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// RestoreStack creates a new stack from an archive written by SnapshotStack. If stackName
+// is empty, the name of the stack the snapshot was taken from is used.
+func (s *StackManager) RestoreStack(filename, stackName string) (err error) {
+	workDir, err := os.MkdirTemp("", "ff-restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	s.Log.Info(fmt.Sprintf("extracting snapshot '%s'", filename))
+	if err := extractArchive(filename, workDir); err != nil {
+		return err
+	}
+
+	manifestBytes, err := os.ReadFile(filepath.Join(workDir, snapshotManifestFile))
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid FireFly stack snapshot: %s", filename, err)
+	}
+	var manifest *SnapshotManifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return err
+	}
+	if manifest.Version > snapshotVersion {
+		return fmt.Errorf("snapshot version %d is not supported by this version of the CLI", manifest.Version)
+	}
+
+	if stackName == "" {
+		stackName = manifest.StackName
+	}
+	exists, err := CheckExists(stackName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("stack '%s' already exists", stackName)
+	}
+
+	var stack *types.Stack
+	stackBytes, err := os.ReadFile(filepath.Join(workDir, snapshotStackDir, "stack.json"))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(stackBytes, &stack); err != nil {
+		return err
+	}
+	if stackName != manifest.StackName && stack.PrivateTransactionManager != "" && !stack.PrivateTransactionManager.Equals(types.PrivateTransactionManagerNone) {
+		// The tessera and quorum entrypoint scripts have the original stack name baked in
+		return fmt.Errorf("stacks with a private transaction manager can only be restored with their original name '%s'", manifest.StackName)
+	}
+
+	stackDir := filepath.Join(constants.StacksDir, stackName)
+	createdVolumes := []string{}
+	defer func() {
+		// Clean up anything that was partially restored, without touching the stack the snapshot came from
+		if err != nil {
+			for _, volumeName := range createdVolumes {
+				_ = docker.RemoveVolume(s.ctx, volumeName)
+			}
+			_ = os.RemoveAll(stackDir)
+		}
+	}()
+	if err = copy.Copy(filepath.Join(workDir, snapshotStackDir), stackDir); err != nil {
+		return err
+	}
+
+	if err = s.LoadStack(stackName); err != nil {
+		return err
+	}
+
+	// The docker-compose.yml contains absolute paths and container names, so it is always
+	// regenerated to match the name and location of the restored stack
+	s.Stack.Name = stackName
+	if err = s.writeStackConfig(); err != nil {
+		return err
+	}
+	if err = s.writeDockerCompose(s.buildDockerCompose()); err != nil {
+		return err
+	}
+
+	for _, volumeName := range manifest.Volumes {
+		fullVolumeName := fmt.Sprintf("%s_%s", stackName, volumeName)
+		s.Log.Info(fmt.Sprintf("importing volume '%s'", fullVolumeName))
+		if err = docker.CreateVolume(s.ctx, fullVolumeName); err != nil {
+			return err
+		}
+		createdVolumes = append(createdVolumes, fullVolumeName)
+		if err = docker.ImportVolume(s.ctx, fullVolumeName, filepath.Join(workDir, snapshotVolumesDir, volumeName+".tar")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addFileToArchive(tw *tar.Writer, sourcePath, archivePath string) error {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(archivePath)
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	f, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func addDirToArchive(tw *tar.Writer, sourceDir, archiveDir string) error {
+	return filepath.Walk(sourceDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(sourceDir, p)
+		if err != nil {
+			return err
+		}
+		archivePath := filepath.Join(archiveDir, relativePath)
+		if info.IsDir() {
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(archivePath) + "/"
+			return tw.WriteHeader(header)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return addFileToArchive(tw, p, archivePath)
+	})
+}
+
+func extractArchive(filename, destDir string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+	return extractTar(gr, destDir)
+}
+
+func extractTar(r io.Reader, destDir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		target := filepath.Join(destDir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(destDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path '%s' in archive", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			//nolint:gosec // archives are written by SnapshotStack
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			out.Close()
+		}
+	}
+}
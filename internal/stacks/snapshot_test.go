@@ -0,0 +1,55 @@
+package stacks
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotArchiveRoundTrip(t *testing.T) {
+	sourceDir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "init", "config"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(sourceDir, "stack.json"), []byte(`{"name":"test"}`), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(sourceDir, "init", "config", "firefly_core_0.yml"), []byte("log:\n  level: debug\n"), 0755))
+
+	archive := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	f, err := os.Create(archive)
+	assert.NoError(t, err)
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	assert.NoError(t, addDirToArchive(tw, sourceDir, snapshotStackDir))
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+	assert.NoError(t, f.Close())
+
+	destDir := t.TempDir()
+	assert.NoError(t, extractArchive(archive, destDir))
+
+	b, err := os.ReadFile(filepath.Join(destDir, snapshotStackDir, "stack.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"test"}`, string(b))
+	b, err = os.ReadFile(filepath.Join(destDir, snapshotStackDir, "init", "config", "firefly_core_0.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "log:\n  level: debug\n", string(b))
+}
+
+func TestExtractArchiveRejectsPathTraversal(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "bad.tar.gz")
+	f, err := os.Create(archive)
+	assert.NoError(t, err)
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "../evil", Mode: 0644, Size: 1, Typeflag: tar.TypeReg}))
+	_, err = tw.Write([]byte("x"))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+	assert.NoError(t, f.Close())
+
+	err = extractArchive(archive, t.TempDir())
+	assert.Regexp(t, "invalid path", err)
+}
@@ -0,0 +1,132 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// GetStackInfo describes the loaded stack. If includeServices is set, the state of each of
+// the stack's containers is also queried from docker.
+func (s *StackManager) GetStackInfo(includeServices bool) (*types.StackInfo, error) {
+	info := &types.StackInfo{
+		Name:                   s.Stack.Name,
+		BlockchainProvider:     s.Stack.BlockchainProvider.String(),
+		BlockchainNodeProvider: s.Stack.BlockchainNodeProvider.String(),
+		BlockchainConnector:    s.Stack.BlockchainConnector.String(),
+		Database:               s.Stack.Database.String(),
+		TokenProviders:         []string{},
+		BlockchainPort:         s.Stack.ExposedBlockchainPort,
+		PrometheusPort:         s.Stack.ExposedPrometheusPort,
+		Members:                []*types.MemberInfo{},
+	}
+	for _, tp := range s.Stack.TokenProviders {
+		info.TokenProviders = append(info.TokenProviders, tp.String())
+	}
+	for _, member := range s.Stack.Members {
+		info.Members = append(info.Members, types.NewMemberInfo(member))
+	}
+	if s.Stack.State != nil {
+		info.DeployedContracts = s.Stack.State.DeployedContracts
+	}
+	if !includeServices {
+		return info, nil
+	}
+
+	services, err := s.getServiceInfo()
+	if err != nil {
+		return nil, err
+	}
+	info.Services = services
+	info.Status = getStackStatus(services)
+	return info, nil
+}
+
+// getServiceInfo matches the services in the stack's docker compose file with the containers
+// docker knows about. Services that have never been started have no container.
+func (s *StackManager) getServiceInfo() ([]*types.ServiceInfo, error) {
+	compose, err := s.readDockerCompose()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := docker.GetContainerStates(s.ctx, s.Stack.StackDir)
+	if err != nil {
+		return nil, err
+	}
+	return mergeServiceInfo(compose, containers), nil
+}
+
+// readDockerCompose reads the stack's docker compose file from disk
+func (s *StackManager) readDockerCompose() (*docker.DockerComposeConfig, error) {
+	composeBytes, err := os.ReadFile(filepath.Join(s.Stack.StackDir, "docker-compose.yml"))
+	if err != nil {
+		return nil, err
+	}
+	var compose *docker.DockerComposeConfig
+	if err := yaml.Unmarshal(composeBytes, &compose); err != nil {
+		return nil, err
+	}
+	return compose, nil
+}
+
+func mergeServiceInfo(compose *docker.DockerComposeConfig, containers []*docker.ContainerState) []*types.ServiceInfo {
+	containersByService := make(map[string]*docker.ContainerState, len(containers))
+	for _, container := range containers {
+		containersByService[container.Service] = container
+	}
+	services := make([]*types.ServiceInfo, 0, len(compose.Services))
+	for name, service := range compose.Services {
+		info := &types.ServiceInfo{
+			Name:      name,
+			Container: service.ContainerName,
+			Image:     service.Image,
+			State:     types.ServiceStateNotCreated,
+		}
+		if container, ok := containersByService[name]; ok {
+			info.Container = container.Container
+			info.State = container.State
+			info.Health = container.Health
+			info.Status = container.Status
+		}
+		services = append(services, info)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
+
+func getStackStatus(services []*types.ServiceInfo) string {
+	running := 0
+	for _, service := range services {
+		if service.State == "running" {
+			running++
+		}
+	}
+	switch {
+	case running == 0:
+		return types.StackStatusNotRunning
+	case running == len(services):
+		return types.StackStatusRunning
+	default:
+		return types.StackStatusPartiallyRunning
+	}
+}
@@ -0,0 +1,67 @@
+package stacks
+
+import (
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeServiceInfo(t *testing.T) {
+	compose := &docker.DockerComposeConfig{
+		Services: map[string]*docker.Service{
+			"postgres_0":     {ContainerName: "dev_postgres_0", Image: "postgres"},
+			"firefly_core_0": {ContainerName: "dev_firefly_core_0", Image: "ghcr.io/hyperledger/firefly:v1.3.0"},
+		},
+	}
+	containers := []*docker.ContainerState{
+		{Service: "firefly_core_0", Container: "dev_firefly_core_0", Image: "ghcr.io/hyperledger/firefly:v1.3.0", State: "running", Status: "Up 2 minutes"},
+	}
+	services := mergeServiceInfo(compose, containers)
+	assert.Equal(t, []*types.ServiceInfo{
+		{Name: "firefly_core_0", Container: "dev_firefly_core_0", Image: "ghcr.io/hyperledger/firefly:v1.3.0", State: "running", Status: "Up 2 minutes"},
+		{Name: "postgres_0", Container: "dev_postgres_0", Image: "postgres", State: types.ServiceStateNotCreated},
+	}, services)
+	assert.Equal(t, types.StackStatusPartiallyRunning, getStackStatus(services))
+}
+
+func TestGetStackStatus(t *testing.T) {
+	assert.Equal(t, types.StackStatusNotRunning, getStackStatus([]*types.ServiceInfo{}))
+	assert.Equal(t, types.StackStatusNotRunning, getStackStatus([]*types.ServiceInfo{{State: "exited"}}))
+	assert.Equal(t, types.StackStatusRunning, getStackStatus([]*types.ServiceInfo{{State: "running"}, {State: "running"}}))
+}
+
+func TestGetStackInfoWithoutServices(t *testing.T) {
+	index := 0
+	s := &StackManager{
+		Stack: &types.Stack{
+			Name:                   "dev",
+			BlockchainProvider:     types.BlockchainProviderEthereum,
+			BlockchainNodeProvider: types.BlockchainNodeProviderGeth,
+			BlockchainConnector:    types.BlockchainConnectorEvmconnect,
+			Database:               types.DatabaseSelectionPostgres,
+			ExposedBlockchainPort:  5100,
+			Members: []*types.Organization{
+				{ID: "0", Index: &index, OrgName: "org_0", NodeName: "node_0", ExposedFireflyPort: 5000, ExposedTokensPorts: []int{5108}},
+			},
+			State: &types.StackState{
+				DeployedContracts: []*types.DeployedContract{{Name: "FireFly", Location: map[string]string{"address": "0x1234"}}},
+			},
+		},
+	}
+	info, err := s.GetStackInfo(false)
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", info.Name)
+	assert.Equal(t, "ethereum", info.BlockchainProvider)
+	assert.Equal(t, "geth", info.BlockchainNodeProvider)
+	assert.Equal(t, "evmconnect", info.BlockchainConnector)
+	assert.Equal(t, []string{}, info.TokenProviders)
+	assert.Equal(t, 5100, info.BlockchainPort)
+	assert.Len(t, info.Members, 1)
+	assert.Equal(t, 5000, info.Members[0].Ports.FireFly)
+	assert.Equal(t, []int{5108}, info.Members[0].Ports.Tokens)
+	assert.Len(t, info.DeployedContracts, 1)
+	assert.Empty(t, info.Status)
+	assert.Nil(t, info.Services)
+}
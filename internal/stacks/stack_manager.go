@@ -22,10 +22,9 @@ import (
 	"fmt"
 	"net"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
-	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -64,6 +63,9 @@ type StackManager struct {
 	tokenProviders     []tokens.ITokensProvider
 	IsOldFileStructure bool
 	once               sync.Once
+	// initFailed is set when InitStack fails, as the stack it leaves behind is only removed,
+	// and was never complete enough for a pre-remove hook to run
+	initFailed bool
 }
 
 var unsupportedARM64Images map[string]bool = map[string]bool{
@@ -95,6 +97,9 @@ func NewStackManager(ctx context.Context) *StackManager {
 }
 
 func (s *StackManager) InitStack(options *types.InitOptions) (err error) {
+	if err := s.checkInitPorts(options); err != nil {
+		return err
+	}
 	environmentVarsMap := make(map[string]interface{})
 	for key, value := range options.EnvironmentVars {
 		environmentVarsMap[key] = value
@@ -121,14 +126,26 @@ func (s *StackManager) InitStack(options *types.InitOptions) (err error) {
 		SandboxEnabled:    options.SandboxEnabled,
 		MultipartyEnabled: options.MultipartyEnabled,
 		ChainIDPtr:        &options.ChainID,
+		BlockPeriod:       &options.BlockPeriod,
 		RemoteNodeURL:     options.RemoteNodeURL,
+		RemoteNodeAuth:    options.RemoteNodeAuth,
 		RequestTimeout:    options.RequestTimeout,
+		RetryPolicy:       options.RetryPolicy,
 		IPFSMode:          fftypes.FFEnum(options.IPFSMode),
+		ResourceProfile:   options.ResourceProfile,
 		ChannelName:       options.ChannelName,
 		ChaincodeName:     options.ChaincodeName,
 		CustomPinSupport:  options.CustomPinSupport,
 		RemoteNodeDeploy:  options.RemoteNodeDeploy,
+		Offline:           options.Offline,
 		EnvironmentVars:   environmentVarsMap,
+		Hooks:             resolveHooks(options.Hooks, ""),
+	}
+	defer func() {
+		s.initFailed = err != nil
+	}()
+	if err := s.runHook(types.HookPreInit); err != nil {
+		return err
 	}
 
 	tokenProviders, err := types.FFEnumArray(s.ctx, options.TokenProviders)
@@ -137,6 +154,13 @@ func (s *StackManager) InitStack(options *types.InitOptions) (err error) {
 	}
 	s.Stack.TokenProviders = tokenProviders
 
+	// The resources are stored rather than the profile alone, so that changes to the
+	// built in profiles do not change existing stacks
+	s.Stack.Resources, err = types.ResolveResources(options.ResourceProfile, options.Resources)
+	if err != nil {
+		return err
+	}
+
 	if s.Stack.IPFSMode.Equals(types.IPFSModePrivate) {
 		s.Stack.SwarmKey, err = GenerateSwarmKey()
 		if err != nil {
@@ -165,14 +189,18 @@ func (s *StackManager) InitStack(options *types.InitOptions) (err error) {
 			return err
 		}
 	} else {
-		// Otherwise, fetch the manifest file from GitHub for the specified version
+		// Otherwise, fetch the manifest file from GitHub for the specified version, unless it has been cached recently
+		kind, name := core.ManifestKindRelease, options.FireFlyVersion
 		if options.FireFlyVersion == "" || strings.ToLower(options.FireFlyVersion) == "latest" {
-			manifest, err = core.GetManifestForChannel(fftypes.FFEnum(options.ReleaseChannel))
+			kind, name = core.ManifestKindChannel, options.ReleaseChannel
+		}
+		if options.Offline {
+			manifest, err = core.ResolveCachedManifest(kind, name)
 			if err != nil {
-				return err
+				return fmt.Errorf("%s. Use --manifest to set a manifest when creating a stack offline", err)
 			}
 		} else {
-			manifest, err = core.GetManifestForRelease(options.FireFlyVersion)
+			manifest, err = core.ResolveManifest(s.ctx, kind, name, options.ManifestCacheTTL)
 			if err != nil {
 				return err
 			}
@@ -180,6 +208,7 @@ func (s *StackManager) InitStack(options *types.InitOptions) (err error) {
 	}
 
 	s.Stack.VersionManifest = manifest
+	s.ctx = core.WithRetryPolicy(s.ctx, s.Stack.RetryPolicy)
 	s.blockchainProvider = s.getBlockchainProvider()
 	s.tokenProviders = s.getITokenProviders()
 
@@ -206,7 +235,10 @@ func (s *StackManager) InitStack(options *types.InitOptions) (err error) {
 	if err := s.writeDockerComposeOverride(compose); err != nil {
 		return fmt.Errorf("failed to write docker-compose.override.yml: %s", err)
 	}
-	return s.writeConfig(options)
+	if err := s.writeConfig(options); err != nil {
+		return err
+	}
+	return s.runHook(types.HookPostInit)
 }
 
 func (s *StackManager) runDockerComposeCommand(command ...string) error {
@@ -225,6 +257,14 @@ func (s *StackManager) runDockerComposeCommand(command ...string) error {
 }
 
 func (s *StackManager) buildDockerCompose() *docker.DockerComposeConfig {
+	compose := s.buildStackServices()
+	s.applyDetachedServices(compose)
+	return compose
+}
+
+// buildStackServices builds the docker compose file for every service in the stack,
+// including any that have been detached
+func (s *StackManager) buildStackServices() *docker.DockerComposeConfig {
 	compose := docker.CreateDockerCompose(s.Stack)
 	extraServices := s.blockchainProvider.GetDockerServiceDefinitions()
 	for i, tp := range s.tokenProviders {
@@ -250,6 +290,7 @@ func (s *StackManager) buildDockerCompose() *docker.DockerComposeConfig {
 			}
 		}
 	}
+	s.applyResources(compose)
 	return compose
 }
 
@@ -284,6 +325,10 @@ func (s *StackManager) LoadStack(stackName string) error {
 	}
 	s.Stack = stack
 	s.Stack.StackDir = stackDir
+	if err := s.readRemoteNodeAuth(); err != nil {
+		return err
+	}
+	s.ctx = core.WithRetryPolicy(s.ctx, s.Stack.RetryPolicy)
 	s.blockchainProvider = s.getBlockchainProvider()
 	s.tokenProviders = s.getITokenProviders()
 
@@ -443,7 +488,38 @@ func (s *StackManager) writeStackConfig() error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath.Join(s.Stack.StackDir, "stack.json"), stackConfigBytes, 0755)
+	if err := os.WriteFile(filepath.Join(s.Stack.StackDir, "stack.json"), stackConfigBytes, 0755); err != nil {
+		return err
+	}
+	return s.writeRemoteNodeAuth()
+}
+
+// writeRemoteNodeAuth stores the remote node credentials in their own file, which unlike
+// stack.json is only readable by the current user
+func (s *StackManager) writeRemoteNodeAuth() error {
+	if s.Stack.RemoteNodeAuth.IsEmpty() {
+		return nil
+	}
+	authPath := filepath.Join(s.Stack.StackDir, types.RemoteNodeAuthFile)
+	authBytes, err := json.MarshalIndent(s.Stack.RemoteNodeAuth, "", " ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(authPath, authBytes, 0600); err != nil {
+		return err
+	}
+	// WriteFile does not change the mode of a file that already exists
+	return os.Chmod(authPath, 0600)
+}
+
+func (s *StackManager) readRemoteNodeAuth() error {
+	authBytes, err := os.ReadFile(filepath.Join(s.Stack.StackDir, types.RemoteNodeAuthFile))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(authBytes, &s.Stack.RemoteNodeAuth)
 }
 
 func (s *StackManager) writeConfig(options *types.InitOptions) error {
@@ -452,28 +528,7 @@ func (s *StackManager) writeConfig(options *types.InitOptions) error {
 	}
 
 	for _, member := range s.Stack.Members {
-		config := core.NewFireflyConfig(s.Stack, member)
-
-		// TODO: This code assumes that there is only one plugin instance per type. When we add support for
-		// multiple namespaces, this code will likely have to change a lot
-		blockchainConfig := s.blockchainProvider.GetBlockchainPluginConfig(s.Stack, member)
-		blockchainConfig.Name = "blockchain0"
-		config.Plugins.Blockchain = []*types.BlockchainConfig{
-			blockchainConfig,
-		}
-
-		if config.Plugins.Tokens == nil {
-			config.Plugins.Tokens = []*types.TokensConfig{}
-		}
-
-		for iTok, tp := range s.tokenProviders {
-			tokenConfig := tp.GetFireflyConfig(member, iTok)
-			tokenConfig.Name = tp.GetName()
-			config.Plugins.Tokens = append(config.Plugins.Tokens, tokenConfig)
-		}
-
-		coreConfigFilename := filepath.Join(s.Stack.InitDir, "config", fmt.Sprintf("firefly_core_%s.yml", member.ID))
-		if err := core.WriteFireflyConfig(config, coreConfigFilename, options.ExtraCoreConfigPath); err != nil {
+		if err := s.writeFireflyCoreConfig(filepath.Join(s.Stack.InitDir, "config"), member, options.ExtraCoreConfigPath); err != nil {
 			return err
 		}
 	}
@@ -490,78 +545,121 @@ func (s *StackManager) writeConfig(options *types.InitOptions) error {
 	}
 
 	if s.Stack.PrometheusEnabled {
-		promConfig := s.GeneratePrometheusConfig()
-		configBytes, err := yaml.Marshal(promConfig)
-		if err != nil {
-			return err
-		}
-		if err := os.WriteFile(path.Join(s.Stack.InitDir, "config", "prometheus.yml"), configBytes, 0755); err != nil {
+		if err := s.writePrometheusConfig(path.Join(s.Stack.InitDir, "config")); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return s.writeExtraConfig(options)
 }
 
-func (s *StackManager) writeDataExchangeCerts() error {
-	configDir := filepath.Join(s.Stack.InitDir, "config")
-	const dataexchange = "dataexchange"
-	for _, member := range s.Stack.Members {
+func (s *StackManager) writePrometheusConfig(configDir string) error {
+	promConfig := s.GeneratePrometheusConfig()
+	configBytes, err := yaml.Marshal(promConfig)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(configDir, "prometheus.yml"), configBytes, 0755)
+}
 
-		memberDXDir := path.Join(configDir, dataexchange+"_"+member.ID)
+func (s *StackManager) writeFireflyCoreConfig(configDir string, member *types.Organization, extraCoreConfigPath string) error {
+	config := core.NewFireflyConfig(s.Stack, member)
 
-		// TODO: remove dependency on openssl here
-		//nolint:gosec
-		opensslCmd := exec.Command("openssl", "req", "-new", "-x509", "-nodes", "-days", "365", "-subj", fmt.Sprintf("/CN=%s_%s/O=member_%s", dataexchange, member.ID, member.ID), "-keyout", "key.pem", "-out", "cert.pem")
-		opensslCmd.Dir = filepath.Join(configDir, dataexchange+"_"+member.ID)
-		if err := opensslCmd.Run(); err != nil {
-			return err
-		}
+	// TODO: This code assumes that there is only one plugin instance per type. When we add support for
+	// multiple namespaces, this code will likely have to change a lot
+	blockchainConfig := s.blockchainProvider.GetBlockchainPluginConfig(s.Stack, member)
+	blockchainConfig.Name = "blockchain0"
+	config.Plugins.Blockchain = []*types.BlockchainConfig{
+		blockchainConfig,
+	}
 
-		dataExchangeConfig := s.GenerateDataExchangeHTTPSConfig(member.ID)
-		configBytes, err := json.Marshal(dataExchangeConfig)
-		if err != nil {
-			return err
-		}
-		if err := os.WriteFile(path.Join(memberDXDir, "config.json"), configBytes, 0755); err != nil {
+	if config.Plugins.Tokens == nil {
+		config.Plugins.Tokens = []*types.TokensConfig{}
+	}
+
+	for iTok, tp := range s.tokenProviders {
+		tokenConfig := tp.GetFireflyConfig(member, iTok)
+		tokenConfig.Name = tp.GetName()
+		config.Plugins.Tokens = append(config.Plugins.Tokens, tokenConfig)
+	}
+
+	coreConfigFilename := filepath.Join(configDir, fmt.Sprintf("firefly_core_%s.yml", member.ID))
+	return core.WriteFireflyConfig(config, coreConfigFilename, extraCoreConfigPath)
+}
+
+func (s *StackManager) writeDataExchangeCerts() error {
+	configDir := filepath.Join(s.Stack.InitDir, "config")
+	for _, member := range s.Stack.Members {
+		if err := s.writeMemberDataExchangeCerts(configDir, member); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+func (s *StackManager) writeMemberDataExchangeCerts(configDir string, member *types.Organization) error {
+	const dataexchange = "dataexchange"
+	memberDXDir := path.Join(configDir, dataexchange+"_"+member.ID)
+
+	if err := s.writeMemberDataExchangeKeyPair(memberDXDir, member); err != nil {
+		return err
+	}
+
+	dataExchangeConfig := s.GenerateDataExchangeHTTPSConfig(member.ID)
+	configBytes, err := json.Marshal(dataExchangeConfig)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(memberDXDir, "config.json"), configBytes, 0755)
+}
+
 func (s *StackManager) copyDataExchangeConfigToVolumes() error {
 	configDir := filepath.Join(s.Stack.RuntimeDir, "config")
 	for _, member := range s.Stack.Members {
-		// Copy files into docker volumes
-		memberDXDir := path.Join(configDir, "dataexchange_"+member.ID)
-		volumeName := fmt.Sprintf("%s_dataexchange_%s", s.Stack.Name, member.ID)
-		if err := docker.MkdirInVolume(s.ctx, volumeName, "destinations"); err != nil {
-			return err
-		}
-		if err := docker.MkdirInVolume(s.ctx, volumeName, "peers"); err != nil {
-			return err
-		}
-		if err := docker.MkdirInVolume(s.ctx, volumeName, "peer-certs"); err != nil {
-			return err
-		}
-		if err := docker.MkdirInVolume(s.ctx, volumeName, "blobs"); err != nil {
-			return err
-		}
-		if err := docker.CopyFileToVolume(s.ctx, volumeName, path.Join(memberDXDir, "config.json"), "/config.json"); err != nil {
-			return err
-		}
-		if err := docker.CopyFileToVolume(s.ctx, volumeName, path.Join(memberDXDir, "cert.pem"), "/cert.pem"); err != nil {
-			return err
-		}
-		if err := docker.CopyFileToVolume(s.ctx, volumeName, path.Join(memberDXDir, "key.pem"), "/key.pem"); err != nil {
+		if err := s.copyMemberDataExchangeConfigToVolume(configDir, member); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+func (s *StackManager) copyMemberDataExchangeConfigToVolume(configDir string, member *types.Organization) error {
+	// Copy files into docker volumes
+	memberDXDir := path.Join(configDir, "dataexchange_"+member.ID)
+	volumeName := fmt.Sprintf("%s_dataexchange_%s", s.Stack.Name, member.ID)
+	if err := docker.MkdirInVolume(s.ctx, volumeName, "destinations"); err != nil {
+		return err
+	}
+	if err := docker.MkdirInVolume(s.ctx, volumeName, "peers"); err != nil {
+		return err
+	}
+	if err := docker.MkdirInVolume(s.ctx, volumeName, "peer-certs"); err != nil {
+		return err
+	}
+	if err := docker.MkdirInVolume(s.ctx, volumeName, "blobs"); err != nil {
+		return err
+	}
+	if err := docker.CopyFileToVolume(s.ctx, volumeName, path.Join(memberDXDir, "config.json"), "/config.json"); err != nil {
+		return err
+	}
+	if err := docker.CopyFileToVolume(s.ctx, volumeName, path.Join(memberDXDir, "cert.pem"), "/cert.pem"); err != nil {
+		return err
+	}
+	return docker.CopyFileToVolume(s.ctx, volumeName, path.Join(memberDXDir, "key.pem"), "/key.pem")
+}
+
 func (s *StackManager) createMember(id string, index int, options *types.InitOptions, external bool) (*types.Organization, error) {
+	member := newMember(id, index, options, external)
+	account, err := s.blockchainProvider.CreateAccount([]string{member.OrgName, member.OrgName, strconv.Itoa(index)})
+	if err != nil {
+		return nil, err
+	}
+	member.Account = account
+	return member, nil
+}
+
+// newMember allocates the ports for a member, without creating its blockchain account
+func newMember(id string, index int, options *types.InitOptions, external bool) *types.Organization {
 	serviceBase := options.ServicesBasePort + (index * 100)
 	ptmBase := options.PtmBasePort + (index * 10)
 	member := &types.Organization{
@@ -579,11 +677,11 @@ func (s *StackManager) createMember(id string, index int, options *types.InitOpt
 	}
 
 	nextPort := serviceBase + 5
-	member.ExposedDataexchangePort = serviceBase + nextPort
+	member.ExposedDataexchangePort = nextPort
 	nextPort++
-	member.ExposedIPFSApiPort = serviceBase + nextPort
+	member.ExposedIPFSApiPort = nextPort
 	nextPort++
-	member.ExposedIPFSGWPort = serviceBase + nextPort
+	member.ExposedIPFSGWPort = nextPort
 	nextPort++
 
 	if options.PrometheusEnabled {
@@ -597,16 +695,10 @@ func (s *StackManager) createMember(id string, index int, options *types.InitOpt
 		nextPort++
 	}
 
-	account, err := s.blockchainProvider.CreateAccount([]string{member.OrgName, member.OrgName, strconv.Itoa(index)})
-	if err != nil {
-		return nil, err
-	}
-	member.Account = account
-
 	if options.SandboxEnabled {
 		member.ExposedSandboxPort = nextPort
 	}
-	return member, nil
+	return member
 }
 
 func (s *StackManager) StartStack(options *types.StartOptions) (messages []string, err error) {
@@ -616,6 +708,9 @@ func (s *StackManager) StartStack(options *types.StartOptions) (messages []strin
 	if err != nil {
 		return messages, err
 	}
+	if err := s.runHook(types.HookPreStart); err != nil {
+		return messages, err
+	}
 	hasBeenRun, err := s.Stack.HasRunBefore()
 	if err != nil {
 		return messages, err
@@ -625,12 +720,16 @@ func (s *StackManager) StartStack(options *types.StartOptions) (messages []strin
 		messages = append(messages, setupMessages...)
 		if err != nil {
 			// Something bad happened during setup
+			if s.ctx.Err() != nil {
+				// Whatever was running when the user interrupted the setup failed as a result
+				err = fmt.Errorf("setup was interrupted")
+			}
 			if options.NoRollback {
 				return messages, err
 			} else {
 				// Rollback changes
 				s.Log.Error(fmt.Errorf("an error occurred - rolling back changes"))
-				resetErr := s.ResetStack()
+				resetErr := s.rollback(s.ResetStack)
 
 				var finalErr error
 
@@ -643,16 +742,26 @@ func (s *StackManager) StartStack(options *types.StartOptions) (messages []strin
 				return messages, finalErr
 			}
 		}
+		// The setup is complete at this point, so a failing hook is not rolled back, and the
+		// hook is not run again the next time the stack is started
+		if err := s.runHook(types.HookPostFirstTimeSetup); err != nil {
+			return messages, fmt.Errorf("%s - the stack has been set up, and the hook will not be run again", err)
+		}
 	} else {
 		err = s.runStartupSequence(false)
 		if err != nil {
 			return messages, err
 		}
 	}
-	return messages, s.ensureFireflyNodesUp(true)
+	if err := s.ensureFireflyNodesUp(true); err != nil {
+		return messages, err
+	}
+	return messages, s.runHook(types.HookPostStart)
 }
 
-func (s *StackManager) PullStack(options *types.PullOptions) error {
+// getStackImages returns every image the stack uses. Images that are built locally are
+// only included if includeLocal is set, as they cannot be pulled.
+func (s *StackManager) getStackImages(includeLocal bool) []string {
 	var images []string
 	manifestImages := make(map[string]bool)
 
@@ -662,7 +771,7 @@ func (s *StackManager) PullStack(options *types.PullOptions) error {
 			fullImage := entry.GetDockerImageString()
 			s.Log.Info(fmt.Sprintf("Manifest entry image='%s' local=%t", fullImage, entry.Local))
 			manifestImages[fullImage] = true
-			if entry.Local {
+			if entry.Local && !includeLocal {
 				continue
 			}
 			images = append(images, fullImage)
@@ -671,6 +780,9 @@ func (s *StackManager) PullStack(options *types.PullOptions) error {
 
 	images = append(images, constants.IPFSImageName)
 
+	// Used to copy files in and out of volumes
+	images = append(images, constants.AlpineImageName)
+
 	// Also pull postgres if we're using it
 	if s.Stack.Database.Equals(types.DatabaseSelectionPostgres) {
 		images = append(images, constants.PostgresImageName)
@@ -681,12 +793,17 @@ func (s *StackManager) PullStack(options *types.PullOptions) error {
 		images = append(images, constants.SandboxImageName)
 	}
 
+	if s.Stack.PrometheusEnabled {
+		images = append(images, constants.PrometheusImageName)
+	}
+
 	// Iterate over all images used by the blockchain provider
 	for _, service := range s.blockchainProvider.GetDockerServiceDefinitions() {
 		if !manifestImages[service.Service.Image] {
 			images = append(images, service.Service.Image)
 		}
 	}
+	images = append(images, s.blockchainProvider.GetSetupImages()...)
 
 	// Iterate over all images used by the tokens provider
 	for iTok, tp := range s.tokenProviders {
@@ -697,26 +814,29 @@ func (s *StackManager) PullStack(options *types.PullOptions) error {
 		}
 	}
 
-	// Use docker to pull every image - retry on failure
-	hasPulled := map[string]bool{}
+	uniqueImages := make([]string, 0, len(images))
+	seen := map[string]bool{}
 	for _, image := range images {
-		if _, ok := hasPulled[image]; !ok {
-			s.Log.Info(fmt.Sprintf("pulling '%s'", image))
-			args := []string{"pull", image}
-			if unsupportedARM64Images[image] && runtime.GOARCH == "arm64" {
-				args = append(args, "--platform=linux/amd64")
-			}
-			if err := docker.RunDockerCommandRetry(s.ctx, s.Stack.InitDir, options.Retries, args[0:]...); err != nil {
-				return err
-			} else {
-				hasPulled[image] = true
-			}
+		if !seen[image] {
+			seen[image] = true
+			uniqueImages = append(uniqueImages, image)
 		}
 	}
-	return nil
+	return uniqueImages
 }
 
-func (s *StackManager) removeVolumes() error {
+func (s *StackManager) PullStack(options *types.PullOptions) error {
+	images := s.getStackImages(false)
+	if s.Stack.Offline {
+		// Offline stacks never contact a registry, so the images must have been loaded already
+		return checkImagesPresent(s.ctx, images)
+	}
+	// Use docker to pull every image - retry on failure
+	return s.pullImages(images, options)
+}
+
+// getVolumeNames returns the names of every volume owned by the stack, without the stack name prefix
+func (s *StackManager) getVolumeNames() []string {
 	var volumes []string
 	for _, service := range s.blockchainProvider.GetDockerServiceDefinitions() {
 		volumes = append(volumes, service.VolumeNames...)
@@ -726,12 +846,32 @@ func (s *StackManager) removeVolumes() error {
 			volumes = append(volumes, service.VolumeNames...)
 		}
 	}
+	// First time setup copies each connector config into a volume, which not every connector
+	// declares as one of its own. fabconnect is the only connector that does not have one.
+	if !s.Stack.BlockchainConnector.Equals(types.BlockchainConnectorFabconnect) {
+		declared := make(map[string]bool, len(volumes))
+		for _, volumeName := range volumes {
+			declared[volumeName] = true
+		}
+		for _, member := range s.Stack.Members {
+			configVolumeName := fmt.Sprintf("%s_config_%s", s.Stack.BlockchainConnector, member.ID)
+			if !declared[configVolumeName] {
+				volumes = append(volumes, configVolumeName)
+			}
+		}
+	}
+	composeVolumes := make([]string, 0)
 	for volumeName := range docker.CreateDockerCompose(s.Stack).Volumes {
-		volumes = append(volumes, volumeName)
+		composeVolumes = append(composeVolumes, volumeName)
 	}
-	for _, volumeName := range volumes {
+	sort.Strings(composeVolumes)
+	return append(volumes, composeVolumes...)
+}
+
+func (s *StackManager) removeVolumes() error {
+	for _, volumeName := range s.getVolumeNames() {
 		if err := docker.RunDockerCommand(s.ctx, "", "volume", "remove", fmt.Sprintf("%s_%s", s.Stack.Name, volumeName)); err != nil {
-			if !strings.Contains(strings.ToLower(err.Error()), "no such volume") {
+			if !docker.IsNoSuchVolumeError(err) {
 				return err
 			}
 		}
@@ -757,6 +897,7 @@ func (s *StackManager) runStartupSequence(firstTimeSetup bool) error {
 }
 
 func (s *StackManager) StopStack() error {
+	s.runTeardownHook(types.HookPreStop)
 	return s.runDockerComposeCommand("stop")
 }
 
@@ -776,7 +917,30 @@ func (s *StackManager) ResetStack() error {
 	return nil
 }
 
+// rollback runs fn to undo a failed change, even if it failed because the command was
+// interrupted and the context of the stack manager has already been canceled
+func (s *StackManager) rollback(fn func() error) error {
+	ctx := s.ctx
+	s.ctx = context.WithoutCancel(ctx)
+	defer func() { s.ctx = ctx }()
+	return fn()
+}
+
 func (s *StackManager) RemoveStack() error {
+	if s.Stack == nil {
+		// InitStack failed before anything was created
+		return nil
+	}
+	// A stack whose init failed before its compose file was written has no containers or
+	// volumes, and not all of its members may have been created
+	_, baseErr := os.Stat(filepath.Join(s.Stack.StackDir, "docker-compose.yml"))
+	_, runtimeErr := os.Stat(filepath.Join(s.Stack.RuntimeDir, "docker-compose.yml"))
+	if os.IsNotExist(baseErr) && os.IsNotExist(runtimeErr) {
+		return os.RemoveAll(s.Stack.StackDir)
+	}
+	if !s.initFailed {
+		s.runTeardownHook(types.HookPreRemove)
+	}
 	if err := s.runDockerComposeCommand("down"); err != nil {
 		return err
 	}
@@ -900,7 +1064,7 @@ func (s *StackManager) runFirstTimeSetup(options *types.StartOptions) (messages
 	}
 
 	pullOptions := &types.PullOptions{
-		Retries: 2,
+		Retries: defaultPullRetries,
 	}
 	if err := s.PullStack(pullOptions); err != nil {
 		return messages, err
@@ -1046,13 +1210,24 @@ func (s *StackManager) runFirstTimeSetup(options *types.StartOptions) (messages
 	}
 
 	// Update the stack state with any new state that was created as a part of the setup process
-	return messages, s.writeStackStateJSON(s.Stack.RuntimeDir)
+	if err := s.writeStackStateJSON(s.Stack.RuntimeDir); err != nil {
+		return messages, err
+	}
+	return messages, nil
 }
 
 func (s *StackManager) ensureFireflyNodesUp(firstTimeSetup bool) error {
+	if err := s.ensureDetachedServicesUp(); err != nil {
+		return err
+	}
 	for _, member := range s.Stack.Members {
-		if member.External {
-			configFilename := filepath.Join(s.Stack.RuntimeDir, "config", fmt.Sprintf("firefly_core_%v.yml", member.ID))
+		serviceName := fmt.Sprintf("firefly_core_%v", member.ID)
+		detached := s.Stack.IsDetached(serviceName)
+		if member.External || detached {
+			configFilename := filepath.Join(s.Stack.RuntimeDir, "config", serviceName+".yml")
+			if detached {
+				configFilename = filepath.Join(s.devServiceDir(serviceName), serviceName+".yml")
+			}
 			var port int
 			if firstTimeSetup {
 				port = member.ExposedFireflyAdminSPIPort
@@ -1066,7 +1241,7 @@ func (s *StackManager) ensureFireflyNodesUp(firstTimeSetup bool) error {
 			}
 			if available {
 				s.Log.Info(fmt.Sprintf("please start your firefly core with the config file for this stack: firefly -f %s  ", configFilename))
-				if err := s.waitForFireflyStart(port); err != nil {
+				if err := s.waitForServiceStart("firefly", port); err != nil {
 					return err
 				}
 			}
@@ -1075,110 +1250,23 @@ func (s *StackManager) ensureFireflyNodesUp(firstTimeSetup bool) error {
 	return nil
 }
 
-func (s *StackManager) waitForFireflyStart(port int) error {
-	retries := 600
-	retryPeriod := 1000 // ms
-	retriesRemaining := retries
-	for retriesRemaining > 0 {
-		time.Sleep(time.Duration(retryPeriod) * time.Millisecond)
+// waitForServiceStart waits, for as long as the retry policy of the stack allows, until a
+// process that the user starts outside of docker is listening on port
+func (s *StackManager) waitForServiceStart(name string, port int) error {
+	err := core.Retry(s.ctx, func() error {
 		available, err := checkPortAvailable(port)
 		if err != nil {
 			return err
 		}
-		if !available {
-			return nil
+		if available {
+			return fmt.Errorf("%s has not started on port %d", name, port)
 		}
-		retriesRemaining--
-	}
-	return fmt.Errorf("waited for %v seconds for firefly to start on port %v but it was never available", retries*retryPeriod/1000, port)
-}
-
-func (s *StackManager) UpgradeStack(version string, forceUpgrade bool) error {
-	// stop the currently running stack
-	if err := s.StopStack(); err != nil {
-		return err
-	}
-	oldManifest := s.Stack.VersionManifest
-	oldVersion, err := docker.GetImageLabel(fmt.Sprintf("%s@sha256:%s", oldManifest.FireFly.Image, oldManifest.FireFly.SHA), "tag")
-	if err != nil {
-		return err
-	}
-
-	if !forceUpgrade {
-		if err := core.ValidateVersionUpgrade(oldVersion, version); err != nil {
-			return err
-		}
-	}
-
-	// get the version manifest for the new version
-	newManifest, err := core.GetManifestForRelease(version)
-	if err != nil {
-		return err
-	}
-
-	if err := replaceVersions(oldManifest, newManifest, filepath.Join(s.Stack.StackDir, "docker-compose.yml")); err != nil {
-		return err
-	}
-
-	s.Stack.VersionManifest = newManifest
-	if err := s.writeStackConfig(); err != nil {
-		return err
-	}
-
-	if err := s.PullStack(&types.PullOptions{}); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func replaceVersions(oldManifest, newManifest *types.VersionManifest, filename string) error {
-	b, err := os.ReadFile(filename)
-	if err != nil {
-		return err
-	}
-	s := string(b)
-
-	old := oldManifest.FireFly.GetDockerImageString()
-	new := newManifest.FireFly.GetDockerImageString()
-	s = strings.ReplaceAll(s, old, new)
-
-	old = oldManifest.Ethconnect.GetDockerImageString()
-	new = newManifest.Ethconnect.GetDockerImageString()
-	s = strings.ReplaceAll(s, old, new)
-
-	old = oldManifest.Evmconnect.GetDockerImageString()
-	new = newManifest.Evmconnect.GetDockerImageString()
-	s = strings.ReplaceAll(s, old, new)
-
-	// v1.2.x stacks may not have had a tezosconnect entry because it's new
-	if oldManifest.Tezosconnect != nil {
-		old = oldManifest.Tezosconnect.GetDockerImageString()
-		new = newManifest.Tezosconnect.GetDockerImageString()
-		s = strings.ReplaceAll(s, old, new)
+		return nil
+	})
+	if err != nil && s.ctx.Err() == nil {
+		return fmt.Errorf("%s - the retryPolicy of the stack sets how long to wait", err)
 	}
-
-	old = oldManifest.Fabconnect.GetDockerImageString()
-	new = newManifest.Fabconnect.GetDockerImageString()
-	s = strings.ReplaceAll(s, old, new)
-
-	old = oldManifest.DataExchange.GetDockerImageString()
-	new = newManifest.DataExchange.GetDockerImageString()
-	s = strings.ReplaceAll(s, old, new)
-
-	old = oldManifest.TokensERC1155.GetDockerImageString()
-	new = newManifest.TokensERC1155.GetDockerImageString()
-	s = strings.ReplaceAll(s, old, new)
-
-	old = oldManifest.TokensERC20ERC721.GetDockerImageString()
-	new = newManifest.TokensERC20ERC721.GetDockerImageString()
-	s = strings.ReplaceAll(s, old, new)
-
-	old = oldManifest.Signer.GetDockerImageString()
-	new = newManifest.Signer.GetDockerImageString()
-	s = strings.ReplaceAll(s, old, new)
-
-	return os.WriteFile(filename, []byte(s), 0755)
+	return err
 }
 
 func (s *StackManager) PrintStackInfo() error {
@@ -1194,9 +1282,9 @@ func (s *StackManager) PrintStackInfo() error {
 	return nil
 }
 
-// IsRunning prints to the stdout, the stack name and it status as "running" or "not_running".
+// IsRunning prints to the stdout, the stack name and its status as "running", "partially_running" or "not_running".
 func (s *StackManager) IsRunning() error {
-	output, err := docker.RunDockerComposeCommandReturnsStdout(s.Stack.StackDir, "ps")
+	services, err := s.getServiceInfo()
 	if err != nil {
 		return err
 	}
@@ -1211,11 +1299,7 @@ func (s *StackManager) IsRunning() error {
 		fmt.Fprintf(w, formatHeader, "STACK", "STATUS")
 	})
 
-	if strings.Contains(string(output), s.Stack.Name) { // if the output contains the stack name, it means the container is running.
-		fmt.Fprintf(w, formatBody, s.Stack.Name, "running")
-	} else {
-		fmt.Fprintf(w, formatBody, s.Stack.Name, "not_running")
-	}
+	fmt.Fprintf(w, formatBody, s.Stack.Name, getStackStatus(services))
 	fmt.Fprintln(w)
 	w.Flush()
 	return nil
@@ -0,0 +1,116 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/hyperledger/firefly-cli/internal/core"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+)
+
+type fireflyStatus struct {
+	Node struct {
+		Name       string `json:"name"`
+		Registered bool   `json:"registered"`
+	} `json:"node"`
+	Org struct {
+		Name       string `json:"name"`
+		Registered bool   `json:"registered"`
+	} `json:"org"`
+	Multiparty struct {
+		Enabled bool `json:"enabled"`
+	} `json:"multiparty"`
+}
+
+// CheckHealth reports the state and healthcheck result of every service in the stack, grouped by
+// the member each service belongs to, along with the registration status of each member as reported
+// by its FireFly API
+func (s *StackManager) CheckHealth() (*types.StackHealth, error) {
+	services, err := s.getServiceInfo()
+	if err != nil {
+		return nil, err
+	}
+	servicesByName := make(map[string]*types.ServiceInfo, len(services))
+	for _, service := range services {
+		servicesByName[service.Name] = service
+	}
+
+	health := &types.StackHealth{
+		Name:     s.Stack.Name,
+		Healthy:  true,
+		Members:  []*types.MemberHealth{},
+		Services: []*types.ServiceInfo{},
+	}
+	for _, member := range s.Stack.Members {
+		memberHealth := &types.MemberHealth{
+			ID:       member.ID,
+			OrgName:  member.OrgName,
+			NodeName: member.NodeName,
+			Healthy:  true,
+			Services: []*types.ServiceInfo{},
+		}
+		for _, serviceName := range s.getMemberServiceNames(member) {
+			if service, ok := servicesByName[serviceName]; ok {
+				memberHealth.Services = append(memberHealth.Services, service)
+				delete(servicesByName, serviceName)
+				if !isServiceHealthy(service) {
+					memberHealth.Healthy = false
+				}
+			}
+		}
+		s.checkFireflyStatus(member, memberHealth)
+		if !memberHealth.Healthy {
+			health.Healthy = false
+		}
+		health.Members = append(health.Members, memberHealth)
+	}
+
+	// Anything left over is shared by all members, such as the blockchain node or signer
+	for _, service := range servicesByName {
+		health.Services = append(health.Services, service)
+		if !isServiceHealthy(service) {
+			health.Healthy = false
+		}
+	}
+	sort.Slice(health.Services, func(i, j int) bool { return health.Services[i].Name < health.Services[j].Name })
+	return health, nil
+}
+
+func (s *StackManager) checkFireflyStatus(member *types.Organization, memberHealth *types.MemberHealth) {
+	var status *fireflyStatus
+	statusURL := fmt.Sprintf("http://localhost:%d/api/v1/status", member.ExposedFireflyPort)
+	if err := core.Request(s.ctx, http.MethodGet, statusURL, nil, &status); err != nil {
+		memberHealth.APIError = err.Error()
+		memberHealth.Healthy = false
+		return
+	}
+	if status.Multiparty.Enabled {
+		memberHealth.OrgRegistered = &status.Org.Registered
+		memberHealth.NodeRegistered = &status.Node.Registered
+		if !status.Org.Registered || !status.Node.Registered {
+			memberHealth.Healthy = false
+		}
+	}
+}
+
+func isServiceHealthy(service *types.ServiceInfo) bool {
+	return service.State == "running" && service.Health != docker.HealthUnhealthy
+}
@@ -0,0 +1,52 @@
+package stacks
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsServiceHealthy(t *testing.T) {
+	assert.True(t, isServiceHealthy(&types.ServiceInfo{State: "running"}))
+	assert.True(t, isServiceHealthy(&types.ServiceInfo{State: "running", Health: "healthy"}))
+	assert.True(t, isServiceHealthy(&types.ServiceInfo{State: "running", Health: "starting"}))
+	assert.False(t, isServiceHealthy(&types.ServiceInfo{State: "running", Health: "unhealthy"}))
+	assert.False(t, isServiceHealthy(&types.ServiceInfo{State: "exited"}))
+	assert.False(t, isServiceHealthy(&types.ServiceInfo{State: types.ServiceStateNotCreated}))
+}
+
+func TestCheckFireflyStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/status", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"node":{"name":"node_0","registered":false},"org":{"name":"org_0","registered":true},"multiparty":{"enabled":true}}`))
+	}))
+	defer server.Close()
+
+	s := &StackManager{ctx: context.Background()}
+	member := &types.Organization{ID: "0", ExposedFireflyPort: server.Listener.Addr().(*net.TCPAddr).Port}
+	memberHealth := &types.MemberHealth{Healthy: true}
+	s.checkFireflyStatus(member, memberHealth)
+	assert.False(t, memberHealth.Healthy)
+	assert.Empty(t, memberHealth.APIError)
+	assert.True(t, *memberHealth.OrgRegistered)
+	assert.False(t, *memberHealth.NodeRegistered)
+}
+
+func TestCheckFireflyStatusUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+	server.Close()
+
+	s := &StackManager{ctx: context.Background()}
+	memberHealth := &types.MemberHealth{Healthy: true}
+	s.checkFireflyStatus(&types.Organization{ID: "0", ExposedFireflyPort: port}, memberHealth)
+	assert.False(t, memberHealth.Healthy)
+	assert.NotEmpty(t, memberHealth.APIError)
+	assert.Nil(t, memberHealth.OrgRegistered)
+}
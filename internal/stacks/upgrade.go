@@ -0,0 +1,260 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stacks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/hyperledger/firefly-cli/internal/core"
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/otiai10/copy"
+	"gopkg.in/yaml.v3"
+)
+
+const upgradeBackupDir = "upgrade_backup"
+
+// upgradeBackupFiles are the files in the stack directory that an upgrade rewrites
+var upgradeBackupFiles = []string{"stack.json", "docker-compose.yml"}
+
+const (
+	ServiceAdded   = "added"
+	ServiceRemoved = "removed"
+	ServiceChanged = "changed"
+)
+
+// ServiceChange describes how a service in the docker compose file changes during an upgrade
+type ServiceChange struct {
+	Service  string   `json:"service"`
+	Change   string   `json:"change"`
+	OldImage string   `json:"oldImage,omitempty"`
+	NewImage string   `json:"newImage,omitempty"`
+	Fields   []string `json:"fields,omitempty"`
+}
+
+type UpgradePlan struct {
+	FromVersion string           `json:"fromVersion"`
+	ToVersion   string           `json:"toVersion"`
+	Changes     []*ServiceChange `json:"changes"`
+}
+
+// UpgradeStack moves the stack to the manifest of another FireFly release, and regenerates the
+// docker compose file from stack.json. If the new images cannot be pulled, or the stack does not
+// start when options.Start is set, the previous stack.json and docker compose file are restored.
+func (s *StackManager) UpgradeStack(options *types.UpgradeOptions) (plan *UpgradePlan, err error) {
+	oldManifest := s.Stack.VersionManifest
+	oldVersion, err := docker.GetImageLabel(s.ctx, fmt.Sprintf("%s@sha256:%s", oldManifest.FireFly.Image, oldManifest.FireFly.SHA), "tag")
+	if err != nil {
+		return nil, err
+	}
+
+	if !options.Force {
+		if err := core.ValidateVersionUpgrade(oldVersion, options.Version); err != nil {
+			return nil, err
+		}
+	}
+
+	// get the version manifest for the new version
+	newManifest, err := core.ResolveManifest(s.ctx, core.ManifestKindRelease, options.Version, options.ManifestCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	oldCompose, err := s.readDockerCompose()
+	if err != nil {
+		return nil, err
+	}
+	s.Stack.VersionManifest = newManifest
+	newCompose := s.buildDockerCompose()
+	changes, err := diffDockerCompose(oldCompose, newCompose)
+	if err != nil {
+		s.Stack.VersionManifest = oldManifest
+		return nil, err
+	}
+	plan = &UpgradePlan{
+		FromVersion: oldVersion,
+		ToVersion:   options.Version,
+		Changes:     changes,
+	}
+	if options.DryRun {
+		s.Stack.VersionManifest = oldManifest
+		return plan, nil
+	}
+
+	// stop the currently running stack
+	if err := s.StopStack(); err != nil {
+		s.Stack.VersionManifest = oldManifest
+		return nil, err
+	}
+	if err := s.backupStackFiles(); err != nil {
+		s.Stack.VersionManifest = oldManifest
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			s.Log.Error(fmt.Errorf("an error occurred - rolling back to %s", oldVersion))
+			s.Stack.VersionManifest = oldManifest
+			restoreErr := s.rollback(func() error {
+				if options.Start {
+					_ = s.StopStack()
+				}
+				return s.restoreStackFiles()
+			})
+			if restoreErr != nil {
+				// The backup is kept, as it is the only copy of the files of the old version
+				err = fmt.Errorf("%s - error rolling back upgrade: %s", err, restoreErr)
+				plan = nil
+				return
+			}
+			err = fmt.Errorf("%s - upgrade rolled back to %s", err, oldVersion)
+			plan = nil
+		}
+		s.removeStackFilesBackup()
+	}()
+
+	if err = s.writeDockerCompose(newCompose); err != nil {
+		return nil, err
+	}
+	if err = s.writeStackConfig(); err != nil {
+		return nil, err
+	}
+	if err = s.PullStack(&types.PullOptions{}); err != nil {
+		return nil, err
+	}
+	if options.Start {
+		// Rollback is handled here, as the default rollback of start would reset the stack
+		if _, err = s.StartStack(&types.StartOptions{NoRollback: true}); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+func (s *StackManager) backupStackFiles() error {
+	backupDir := filepath.Join(s.Stack.StackDir, upgradeBackupDir)
+	if err := os.RemoveAll(backupDir); err != nil {
+		return err
+	}
+	for _, filename := range upgradeBackupFiles {
+		if err := copy.Copy(filepath.Join(s.Stack.StackDir, filename), filepath.Join(backupDir, filename)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeStackFilesBackup deletes the backup once it is no longer needed. Failing to delete it
+// does not fail the upgrade, as it is replaced by the next upgrade anyway.
+func (s *StackManager) removeStackFilesBackup() {
+	if err := os.RemoveAll(filepath.Join(s.Stack.StackDir, upgradeBackupDir)); err != nil {
+		s.Log.Warn(fmt.Sprintf("unable to remove the backup of the stack files: %s", err))
+	}
+}
+
+func (s *StackManager) restoreStackFiles() error {
+	backupDir := filepath.Join(s.Stack.StackDir, upgradeBackupDir)
+	for _, filename := range upgradeBackupFiles {
+		if err := copy.Copy(filepath.Join(backupDir, filename), filepath.Join(s.Stack.StackDir, filename)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffDockerCompose compares every service in two docker compose files, returning the services
+// that were added, removed, or had their image or any other config changed
+func diffDockerCompose(oldCompose, newCompose *docker.DockerComposeConfig) ([]*ServiceChange, error) {
+	oldServices, err := composeServicesToMaps(oldCompose)
+	if err != nil {
+		return nil, err
+	}
+	newServices, err := composeServicesToMaps(newCompose)
+	if err != nil {
+		return nil, err
+	}
+	serviceNames := make([]string, 0, len(oldServices)+len(newServices))
+	for name := range oldServices {
+		serviceNames = append(serviceNames, name)
+	}
+	for name := range newServices {
+		if _, ok := oldServices[name]; !ok {
+			serviceNames = append(serviceNames, name)
+		}
+	}
+	sort.Strings(serviceNames)
+
+	changes := []*ServiceChange{}
+	for _, name := range serviceNames {
+		oldService, inOld := oldServices[name]
+		newService, inNew := newServices[name]
+		switch {
+		case !inOld:
+			changes = append(changes, &ServiceChange{Service: name, Change: ServiceAdded, NewImage: fmt.Sprint(newService["image"])})
+		case !inNew:
+			changes = append(changes, &ServiceChange{Service: name, Change: ServiceRemoved, OldImage: fmt.Sprint(oldService["image"])})
+		default:
+			change := &ServiceChange{Service: name, Change: ServiceChanged}
+			if !reflect.DeepEqual(oldService["image"], newService["image"]) {
+				change.OldImage = fmt.Sprint(oldService["image"])
+				change.NewImage = fmt.Sprint(newService["image"])
+			}
+			for field := range oldService {
+				if _, ok := newService[field]; !ok && field != "image" {
+					change.Fields = append(change.Fields, field)
+				}
+			}
+			for field, value := range newService {
+				if field != "image" && !reflect.DeepEqual(oldService[field], value) {
+					change.Fields = append(change.Fields, field)
+				}
+			}
+			sort.Strings(change.Fields)
+			if change.NewImage != "" || len(change.Fields) > 0 {
+				changes = append(changes, change)
+			}
+		}
+	}
+	return changes, nil
+}
+
+// composeServicesToMaps converts every service to the generic form it has in the YAML file, so
+// that services read from a file can be compared with services that have just been built
+func composeServicesToMaps(compose *docker.DockerComposeConfig) (map[string]map[string]interface{}, error) {
+	services := make(map[string]map[string]interface{})
+	if compose == nil {
+		return services, nil
+	}
+	for name, service := range compose.Services {
+		b, err := yaml.Marshal(service)
+		if err != nil {
+			return nil, err
+		}
+		var serviceMap map[string]interface{}
+		if err := yaml.Unmarshal(b, &serviceMap); err != nil {
+			return nil, err
+		}
+		if serviceMap == nil {
+			serviceMap = map[string]interface{}{}
+		}
+		services[name] = serviceMap
+	}
+	return services, nil
+}
@@ -0,0 +1,69 @@
+package stacks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly-cli/internal/docker"
+	"github.com/hyperledger/firefly-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffDockerCompose(t *testing.T) {
+	oldCompose := &docker.DockerComposeConfig{
+		Services: map[string]*docker.Service{
+			"firefly_core_0": {Image: "ghcr.io/hyperledger/firefly@sha256:aaaa", Ports: []string{"5000:5000"}},
+			"ethconnect_0":   {Image: "ghcr.io/hyperledger/firefly-ethconnect@sha256:bbbb"},
+			"postgres_0":     {Image: "postgres", Environment: map[string]interface{}{"POSTGRES_PASSWORD": "f1refly"}},
+		},
+	}
+	newCompose := &docker.DockerComposeConfig{
+		Services: map[string]*docker.Service{
+			"firefly_core_0": {Image: "ghcr.io/hyperledger/firefly@sha256:cccc", Ports: []string{"5000:5000"}},
+			"evmconnect_0":   {Image: "ghcr.io/hyperledger/firefly-evmconnect@sha256:dddd"},
+			"postgres_0":     {Image: "postgres", Environment: map[string]interface{}{"POSTGRES_PASSWORD": "f1refly", "PGDATA": "/var/lib/postgresql/data/pgdata"}, Command: "postgres"},
+		},
+	}
+	changes, err := diffDockerCompose(oldCompose, newCompose)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ServiceChange{
+		{Service: "ethconnect_0", Change: ServiceRemoved, OldImage: "ghcr.io/hyperledger/firefly-ethconnect@sha256:bbbb"},
+		{Service: "evmconnect_0", Change: ServiceAdded, NewImage: "ghcr.io/hyperledger/firefly-evmconnect@sha256:dddd"},
+		{Service: "firefly_core_0", Change: ServiceChanged, OldImage: "ghcr.io/hyperledger/firefly@sha256:aaaa", NewImage: "ghcr.io/hyperledger/firefly@sha256:cccc"},
+		{Service: "postgres_0", Change: ServiceChanged, Fields: []string{"command", "environment"}},
+	}, changes)
+}
+
+func TestDiffDockerComposeNoChanges(t *testing.T) {
+	compose := &docker.DockerComposeConfig{
+		Services: map[string]*docker.Service{
+			"ipfs_0": {Image: "ipfs/go-ipfs:v0.10.0", Volumes: []string{"ipfs_staging_0:/export"}},
+		},
+	}
+	changes, err := diffDockerCompose(compose, compose)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestBackupAndRestoreStackFiles(t *testing.T) {
+	stackDir := t.TempDir()
+	s := &StackManager{Stack: &types.Stack{StackDir: stackDir}}
+	assert.NoError(t, os.WriteFile(filepath.Join(stackDir, "stack.json"), []byte(`{"name":"old"}`), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(stackDir, "docker-compose.yml"), []byte("old"), 0755))
+
+	assert.NoError(t, s.backupStackFiles())
+	assert.NoError(t, os.WriteFile(filepath.Join(stackDir, "stack.json"), []byte(`{"name":"new"}`), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(stackDir, "docker-compose.yml"), []byte("new"), 0755))
+	assert.NoError(t, s.restoreStackFiles())
+
+	b, err := os.ReadFile(filepath.Join(stackDir, "stack.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"old"}`, string(b))
+	b, err = os.ReadFile(filepath.Join(stackDir, "docker-compose.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "old", string(b))
+
+	s.removeStackFilesBackup()
+	assert.NoDirExists(t, filepath.Join(stackDir, upgradeBackupDir))
+}
@@ -82,7 +82,7 @@ func (p *ERC1155Provider) FirstTimeSetup(tokenIdx int) error {
 
 func (p *ERC1155Provider) GetDockerServiceDefinitions(tokenIdx int) []*docker.ServiceDefinition {
 	serviceDefinitions := make([]*docker.ServiceDefinition, 0, len(p.stack.Members))
-	for i, member := range p.stack.Members {
+	for _, member := range p.stack.Members {
 		connectorName := fmt.Sprintf("tokens_%v_%v", member.ID, tokenIdx)
 
 		var contractAddress types.HexAddress
@@ -106,7 +106,7 @@ func (p *ERC1155Provider) GetDockerServiceDefinitions(tokenIdx int) []*docker.Se
 			ServiceName: connectorName,
 			Service: &docker.Service{
 				Image:         p.stack.VersionManifest.TokensERC1155.GetDockerImageString(),
-				ContainerName: fmt.Sprintf("%s_tokens_%v_%v", p.stack.Name, i, tokenIdx),
+				ContainerName: fmt.Sprintf("%s_tokens_%v_%v", p.stack.Name, member.ID, tokenIdx),
 				Ports:         []string{fmt.Sprintf("%d:3000", member.ExposedTokensPorts[tokenIdx])},
 				Environment:   env,
 				DependsOn: map[string]map[string]string{
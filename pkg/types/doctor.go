@@ -0,0 +1,31 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+const (
+	DoctorResultPass = "pass"
+	DoctorResultWarn = "warn"
+	DoctorResultFail = "fail"
+)
+
+// DoctorCheck is the result of a single check made by the doctor command
+type DoctorCheck struct {
+	Name        string `json:"name"`
+	Result      string `json:"result"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation,omitempty"`
+}
@@ -0,0 +1,68 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The points in the life of a stack at which a hook can be run
+const (
+	HookPreInit            = "pre-init"
+	HookPostInit           = "post-init"
+	HookPreStart           = "pre-start"
+	HookPostFirstTimeSetup = "post-first-time-setup"
+	HookPostStart          = "post-start"
+	HookPreStop            = "pre-stop"
+	HookPreRemove          = "pre-remove"
+)
+
+var HookNames = []string{
+	HookPreInit,
+	HookPostInit,
+	HookPreStart,
+	HookPostFirstTimeSetup,
+	HookPostStart,
+	HookPreStop,
+	HookPreRemove,
+}
+
+func ValidateHookName(name string) error {
+	for _, hook := range HookNames {
+		if name == hook {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown hook '%s'. Options are: %s", name, strings.Join(HookNames, ", "))
+}
+
+// ParseHooks parses hooks in the form name=command
+func ParseHooks(hooks []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(hooks))
+	for _, hook := range hooks {
+		name, command, ok := strings.Cut(hook, "=")
+		if !ok || strings.TrimSpace(command) == "" {
+			return nil, fmt.Errorf("invalid hook '%s' - hooks must be in the form name=command", hook)
+		}
+		if err := ValidateHookName(name); err != nil {
+			return nil, err
+		}
+		parsed[name] = command
+	}
+	return parsed, nil
+}
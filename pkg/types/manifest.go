@@ -16,7 +16,11 @@
 
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type GitHubRelease struct {
 	TagName string `json:"tag_name,omitempty"`
@@ -51,6 +55,41 @@ func (m *VersionManifest) Entries() []*ManifestEntry {
 	}
 }
 
+// CachedManifest is a manifest that has been fetched for a release or release channel, along
+// with when it was fetched
+type CachedManifest struct {
+	Kind     string           `json:"kind"`
+	Name     string           `json:"name"`
+	Fetched  time.Time        `json:"fetched"`
+	Manifest *VersionManifest `json:"manifest"`
+}
+
+func (c *CachedManifest) Expired(ttl time.Duration) bool {
+	return time.Since(c.Fetched) > ttl
+}
+
+// ManifestCacheInfo summarizes a cached manifest, without the manifest itself
+type ManifestCacheInfo struct {
+	Kind           string    `json:"kind"`
+	Name           string    `json:"name"`
+	FireFlyVersion string    `json:"fireflyVersion,omitempty"`
+	Fetched        time.Time `json:"fetched"`
+	Expired        bool      `json:"expired"`
+}
+
+func NewManifestCacheInfo(c *CachedManifest, ttl time.Duration) *ManifestCacheInfo {
+	info := &ManifestCacheInfo{
+		Kind:    c.Kind,
+		Name:    c.Name,
+		Fetched: c.Fetched,
+		Expired: c.Expired(ttl),
+	}
+	if c.Manifest != nil && c.Manifest.FireFly != nil {
+		info.FireFlyVersion = c.Manifest.FireFly.Tag
+	}
+	return info
+}
+
 type ManifestEntry struct {
 	Image string `json:"image,omitempty"`
 	Local bool   `json:"local,omitempty"`
@@ -66,3 +105,25 @@ func (m *ManifestEntry) GetDockerImageString() string {
 	}
 	return m.Image
 }
+
+// ParseManifestEntry splits an image reference of the form image, image:tag or
+// image@sha256:digest into a manifest entry
+func ParseManifestEntry(ref string) (*ManifestEntry, error) {
+	entry := &ManifestEntry{Image: ref}
+	if i := strings.Index(ref, "@"); i >= 0 {
+		digest := ref[i+1:]
+		if !strings.HasPrefix(digest, "sha256:") || len(digest) == len("sha256:") {
+			return nil, fmt.Errorf("invalid image '%s': only sha256 digests are supported", ref)
+		}
+		entry.Image = ref[:i]
+		entry.SHA = strings.TrimPrefix(digest, "sha256:")
+	} else if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		// A colon before the last slash is the port of a registry, not a tag
+		entry.Image = ref[:i]
+		entry.Tag = ref[i+1:]
+	}
+	if entry.Image == "" {
+		return nil, fmt.Errorf("invalid image '%s'", ref)
+	}
+	return entry, nil
+}
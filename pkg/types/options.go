@@ -18,24 +18,35 @@ package types
 
 import (
 	"context"
+	"time"
 
 	"github.com/hyperledger/firefly-common/pkg/fftypes"
 )
 
 type PullOptions struct {
-	Retries int
+	Retries     int
+	Concurrency int
 }
 
 type StartOptions struct {
 	NoRollback bool
 }
 
+type UpgradeOptions struct {
+	Version          string
+	Force            bool
+	DryRun           bool
+	Start            bool
+	ManifestCacheTTL time.Duration
+}
+
 type InitOptions struct {
 	StackName                 string
 	MemberCount               int
 	FireFlyBasePort           int
 	ServicesBasePort          int
 	PtmBasePort               int
+	AutoPorts                 bool
 	DatabaseProvider          string
 	ExternalProcesses         int
 	OrgNames                  []string
@@ -48,6 +59,8 @@ type InitOptions struct {
 	TokenProviders            []string
 	FireFlyVersion            string
 	ManifestPath              string
+	Offline                   bool
+	ManifestCacheTTL          time.Duration
 	PrometheusEnabled         bool
 	PrometheusPort            int
 	SandboxEnabled            bool
@@ -56,12 +69,16 @@ type InitOptions struct {
 	BlockPeriod               int
 	ContractAddress           string
 	RemoteNodeURL             string
+	RemoteNodeAuth            *RemoteNodeAuth
 	ChainID                   int64
 	DisableTokenFactories     bool
 	RequestTimeout            int
+	RetryPolicy               *RetryPolicy
 	ReleaseChannel            string
 	MultipartyEnabled         bool
 	IPFSMode                  string
+	ResourceProfile           string
+	Resources                 map[string]*ServiceResources
 	CCPYAMLPaths              []string
 	MSPPaths                  []string
 	ChannelName               string
@@ -69,6 +86,7 @@ type InitOptions struct {
 	CustomPinSupport          bool
 	RemoteNodeDeploy          bool
 	EnvironmentVars           map[string]string
+	Hooks                     map[string]string
 }
 
 const IPFSMode = "ipfs_mode"
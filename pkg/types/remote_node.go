@@ -0,0 +1,117 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// RemoteNodeAuthFile is the file in the stack directory that holds the remote node
+// credentials. They are kept out of stack.json, and the file is only readable by its owner.
+const RemoteNodeAuthFile = "remote_node.json"
+
+// RemoteNodeAuth holds the credentials and TLS settings used to connect to a blockchain
+// node at --remote-node-url, for example one that sits behind an authenticating gateway
+type RemoteNodeAuth struct {
+	Username    string            `json:"username,omitempty"`
+	Password    string            `json:"password,omitempty"`
+	BearerToken string            `json:"bearerToken,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	// CACert, ClientCert and ClientKey hold PEM encoded data rather than file paths, so
+	// that the stack does not depend on files outside of its own directory
+	CACert     string `json:"caCert,omitempty"`
+	ClientCert string `json:"clientCert,omitempty"`
+	ClientKey  string `json:"clientKey,omitempty"`
+}
+
+func (a *RemoteNodeAuth) IsEmpty() bool {
+	return a == nil || (a.Username == "" && a.Password == "" && a.BearerToken == "" && len(a.Headers) == 0 && !a.HasTLS())
+}
+
+// HasTLS returns true if a custom CA or a client certificate has been set
+func (a *RemoteNodeAuth) HasTLS() bool {
+	return a != nil && (a.CACert != "" || a.ClientCert != "" || a.ClientKey != "")
+}
+
+// HasHeaders returns true if any header, including a bearer token, has to be sent to the node
+func (a *RemoteNodeAuth) HasHeaders() bool {
+	return a != nil && (a.BearerToken != "" || len(a.Headers) > 0)
+}
+
+// AllHeaders returns the custom headers, along with the Authorization header for the bearer token
+func (a *RemoteNodeAuth) AllHeaders() map[string]string {
+	if !a.HasHeaders() {
+		return nil
+	}
+	headers := make(map[string]string, len(a.Headers)+1)
+	for name, value := range a.Headers {
+		headers[name] = value
+	}
+	if a.BearerToken != "" {
+		headers["Authorization"] = "Bearer " + a.BearerToken
+	}
+	return headers
+}
+
+// Validate checks the settings make sense together, and for the given remote node URL
+func (a *RemoteNodeAuth) Validate(remoteNodeURL string) error {
+	if a.IsEmpty() {
+		return nil
+	}
+	if remoteNodeURL == "" {
+		return fmt.Errorf("remote node credentials and TLS settings can only be used with a remote node URL")
+	}
+	u, err := url.Parse(remoteNodeURL)
+	if err != nil {
+		return fmt.Errorf("invalid remote node URL '%s': %s", remoteNodeURL, err)
+	}
+	if a.Password != "" && a.Username == "" {
+		return fmt.Errorf("a remote node password requires a username")
+	}
+	if u.User != nil && a.Username != "" {
+		return fmt.Errorf("the remote node username cannot be set when the URL already contains credentials")
+	}
+	if a.Username != "" && a.BearerToken != "" {
+		return fmt.Errorf("only one of a remote node username or bearer token can be set")
+	}
+	for name := range a.Headers {
+		if name == "" {
+			return fmt.Errorf("remote node header names cannot be empty")
+		}
+		if http.CanonicalHeaderKey(name) == "Authorization" && (a.Username != "" || a.BearerToken != "") {
+			return fmt.Errorf("the Authorization header cannot be set along with a remote node username or bearer token")
+		}
+	}
+	if (a.ClientCert == "") != (a.ClientKey == "") {
+		return fmt.Errorf("a remote node client certificate and key must be set together")
+	}
+	if a.HasTLS() && u.Scheme != "https" {
+		return fmt.Errorf("remote node TLS settings require an https URL")
+	}
+	for name, data := range map[string]string{"CA certificate": a.CACert, "client certificate": a.ClientCert, "client key": a.ClientKey} {
+		if data == "" {
+			continue
+		}
+		if block, _ := pem.Decode([]byte(data)); block == nil {
+			return fmt.Errorf("the remote node %s is not PEM encoded", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+)
+
+const ResourceProfile = "resource_profile"
+
+var (
+	ResourceProfileSmall   = fftypes.FFEnumValue(ResourceProfile, "small")
+	ResourceProfileDefault = fftypes.FFEnumValue(ResourceProfile, "default")
+	ResourceProfileLarge   = fftypes.FFEnumValue(ResourceProfile, "large")
+)
+
+// The groups of services that resources are set for. Every service in a group gets the same
+// resources, so a 5 member stack has 5 times the core resources, but only one geth node.
+const (
+	ResourceGroupCore         = "core"
+	ResourceGroupConnector    = "connector"
+	ResourceGroupNode         = "node"
+	ResourceGroupIPFS         = "ipfs"
+	ResourceGroupPostgres     = "postgres"
+	ResourceGroupTokens       = "tokens"
+	ResourceGroupDataExchange = "dataexchange"
+)
+
+var ResourceGroups = []string{
+	ResourceGroupCore,
+	ResourceGroupConnector,
+	ResourceGroupNode,
+	ResourceGroupIPFS,
+	ResourceGroupPostgres,
+	ResourceGroupTokens,
+	ResourceGroupDataExchange,
+}
+
+// ResourceLimits uses the docker compose formats, such as "0.5" for CPUs and "512M" for memory
+type ResourceLimits struct {
+	CPUs   string `json:"cpus,omitempty" yaml:"cpus,omitempty"`
+	Memory string `json:"memory,omitempty" yaml:"memory,omitempty"`
+}
+
+// ServiceResources is rendered as the resources of the deploy section of a docker compose service
+type ServiceResources struct {
+	Limits       *ResourceLimits `json:"limits,omitempty" yaml:"limits,omitempty"`
+	Reservations *ResourceLimits `json:"reservations,omitempty" yaml:"reservations,omitempty"`
+}
+
+func resources(limitCPUs, limitMemory, reservedCPUs, reservedMemory string) *ServiceResources {
+	return &ServiceResources{
+		Limits:       &ResourceLimits{CPUs: limitCPUs, Memory: limitMemory},
+		Reservations: &ResourceLimits{CPUs: reservedCPUs, Memory: reservedMemory},
+	}
+}
+
+// resourceProfiles holds the resources of each group for the built in profiles. The default
+// profile leaves everything unlimited, as stacks always were before profiles existed.
+var resourceProfiles = map[string]map[string]*ServiceResources{
+	ResourceProfileSmall.String(): {
+		ResourceGroupCore:         resources("0.5", "512M", "0.1", "128M"),
+		ResourceGroupConnector:    resources("0.25", "256M", "0.05", "64M"),
+		ResourceGroupNode:         resources("1", "1G", "0.25", "256M"),
+		ResourceGroupIPFS:         resources("0.25", "256M", "0.05", "64M"),
+		ResourceGroupPostgres:     resources("0.25", "256M", "0.05", "64M"),
+		ResourceGroupTokens:       resources("0.25", "256M", "0.05", "64M"),
+		ResourceGroupDataExchange: resources("0.25", "256M", "0.05", "64M"),
+	},
+	ResourceProfileDefault.String(): {},
+	ResourceProfileLarge.String(): {
+		ResourceGroupCore:         resources("2", "2G", "0.5", "512M"),
+		ResourceGroupConnector:    resources("1", "1G", "0.25", "256M"),
+		ResourceGroupNode:         resources("4", "4G", "1", "1G"),
+		ResourceGroupIPFS:         resources("1", "1G", "0.25", "256M"),
+		ResourceGroupPostgres:     resources("1", "1G", "0.25", "256M"),
+		ResourceGroupTokens:       resources("1", "512M", "0.1", "128M"),
+		ResourceGroupDataExchange: resources("1", "512M", "0.1", "128M"),
+	},
+}
+
+var memoryRegexp = regexp.MustCompile(`^[0-9]+([kKmMgG][bB]?|[bB])?$`)
+
+// ResolveResources returns the resources of each group for a profile, with the overrides
+// applied on top. An empty profile is the same as the default one.
+func ResolveResources(profile string, overrides map[string]*ServiceResources) (map[string]*ServiceResources, error) {
+	if profile == "" {
+		profile = ResourceProfileDefault.String()
+	}
+	if _, err := fftypes.FFEnumParseString(context.Background(), ResourceProfile, profile); err != nil {
+		return nil, err
+	}
+
+	resolved := map[string]*ServiceResources{}
+	for group, r := range resourceProfiles[profile] {
+		resolved[group] = r.merge(nil)
+	}
+	groups := make([]string, 0, len(overrides))
+	for group := range overrides {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		if !isResourceGroup(group) {
+			return nil, fmt.Errorf("unknown resource group '%s'. Options are: %v", group, ResourceGroups)
+		}
+		r := resolved[group].merge(overrides[group])
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid resources for '%s': %s", group, err)
+		}
+		resolved[group] = r
+	}
+	if len(resolved) == 0 {
+		return nil, nil
+	}
+	return resolved, nil
+}
+
+// merge returns a copy of the resources, with any value that is set in the overrides replaced
+func (r *ServiceResources) merge(overrides *ServiceResources) *ServiceResources {
+	merged := &ServiceResources{}
+	if r != nil {
+		merged.Limits = r.Limits.merge(nil)
+		merged.Reservations = r.Reservations.merge(nil)
+	}
+	if overrides != nil {
+		merged.Limits = merged.Limits.merge(overrides.Limits)
+		merged.Reservations = merged.Reservations.merge(overrides.Reservations)
+	}
+	return merged
+}
+
+func (l *ResourceLimits) merge(overrides *ResourceLimits) *ResourceLimits {
+	if l == nil && overrides == nil {
+		return nil
+	}
+	merged := &ResourceLimits{}
+	if l != nil {
+		*merged = *l
+	}
+	if overrides != nil {
+		if overrides.CPUs != "" {
+			merged.CPUs = overrides.CPUs
+		}
+		if overrides.Memory != "" {
+			merged.Memory = overrides.Memory
+		}
+	}
+	return merged
+}
+
+func (r *ServiceResources) Validate() error {
+	for _, l := range []*ResourceLimits{r.Limits, r.Reservations} {
+		if l == nil {
+			continue
+		}
+		if l.CPUs != "" {
+			if cpus, err := strconv.ParseFloat(l.CPUs, 64); err != nil || cpus <= 0 {
+				return fmt.Errorf("cpus must be a positive number, such as 0.5, not '%s'", l.CPUs)
+			}
+		}
+		if l.Memory != "" && !memoryRegexp.MatchString(l.Memory) {
+			return fmt.Errorf("memory must be a size, such as 512M or 2G, not '%s'", l.Memory)
+		}
+	}
+	if r.Limits == nil || r.Reservations == nil {
+		return nil
+	}
+	// The values have been checked above, so they all parse
+	if r.Limits.CPUs != "" && r.Reservations.CPUs != "" {
+		limit, _ := strconv.ParseFloat(r.Limits.CPUs, 64)
+		reserved, _ := strconv.ParseFloat(r.Reservations.CPUs, 64)
+		if reserved > limit {
+			return fmt.Errorf("the reserved cpus (%s) cannot be more than the limit (%s)", r.Reservations.CPUs, r.Limits.CPUs)
+		}
+	}
+	if r.Limits.Memory != "" && r.Reservations.Memory != "" {
+		if memoryBytes(r.Reservations.Memory) > memoryBytes(r.Limits.Memory) {
+			return fmt.Errorf("the reserved memory (%s) cannot be more than the limit (%s)", r.Reservations.Memory, r.Limits.Memory)
+		}
+	}
+	return nil
+}
+
+// memoryBytes returns the number of bytes of a size that matches memoryRegexp
+func memoryBytes(memory string) uint64 {
+	digits := strings.TrimRight(memory, "kKmMgGbB")
+	value, _ := strconv.ParseUint(digits, 10, 64)
+	switch strings.ToLower(memory[len(digits):]) {
+	case "k", "kb":
+		return value << 10
+	case "m", "mb":
+		return value << 20
+	case "g", "gb":
+		return value << 30
+	default:
+		return value
+	}
+}
+
+func isResourceGroup(group string) bool {
+	for _, g := range ResourceGroups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// RetryPolicy controls how the CLI retries requests to the services of a stack, which
+// fail until the services have finished starting
+type RetryPolicy struct {
+	// Retries is how many times a failed request is retried before giving up
+	Retries int `json:"retries" yaml:"retries"`
+	// InitialDelay is how long to wait before the first retry, such as "1s"
+	InitialDelay string `json:"initialDelay,omitempty" yaml:"initialDelay,omitempty"`
+	// MaxDelay is how long the delay can grow to, as it doubles after each retry. If it is
+	// not set the delay stays the same.
+	MaxDelay string `json:"maxDelay,omitempty" yaml:"maxDelay,omitempty"`
+}
+
+func (p *RetryPolicy) Validate() error {
+	if p.Retries < 0 {
+		return fmt.Errorf("the number of retries cannot be negative")
+	}
+	for _, d := range []string{p.InitialDelay, p.MaxDelay} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d); err != nil {
+			return fmt.Errorf("invalid retry delay '%s': %s", d, err)
+		}
+	}
+	return nil
+}
+
+// DelayBefore returns how long to wait before the given retry, counting from zero
+func (p *RetryPolicy) DelayBefore(retry int) time.Duration {
+	// Delays are checked by Validate, so anything that fails to parse is treated as no delay
+	delay, _ := time.ParseDuration(p.InitialDelay)
+	maxDelay, _ := time.ParseDuration(p.MaxDelay)
+	for i := 0; i < retry && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
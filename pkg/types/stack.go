@@ -25,39 +25,47 @@ import (
 )
 
 type Stack struct {
-	Name                      string                 `json:"name,omitempty"`
-	Members                   []*Organization        `json:"members,omitempty"`
-	SwarmKey                  string                 `json:"swarmKey,omitempty"`
-	ExposedBlockchainPort     int                    `json:"exposedBlockchainPort,omitempty"`
-	ExposedPtmPort            int                    `json:"exposedPtmPort,omitempty"`
-	Database                  fftypes.FFEnum         `json:"database"`
-	BlockchainProvider        fftypes.FFEnum         `json:"blockchainProvider"`
-	BlockchainConnector       fftypes.FFEnum         `json:"blockchainConnector"`
-	BlockchainNodeProvider    fftypes.FFEnum         `json:"blockchainNodeProvider"`
-	PrivateTransactionManager fftypes.FFEnum         `json:"privateTransactionManager"`
-	Consensus                 fftypes.FFEnum         `json:"consensus"`
-	TokenProviders            []fftypes.FFEnum       `json:"tokenProviders"`
-	VersionManifest           *VersionManifest       `json:"versionManifest,omitempty"`
-	PrometheusEnabled         bool                   `json:"prometheusEnabled,omitempty"`
-	SandboxEnabled            bool                   `json:"sandboxEnabled,omitempty"`
-	MultipartyEnabled         bool                   `json:"multiparty"`
-	ExposedPrometheusPort     int                    `json:"exposedPrometheusPort,omitempty"`
-	ContractAddress           string                 `json:"contractAddress,omitempty"`
-	ChainIDPtr                *int64                 `json:"chainID,omitempty"`
-	RemoteNodeURL             string                 `json:"remoteNodeURL,omitempty"`
-	DisableTokenFactories     bool                   `json:"disableTokenFactories,omitempty"`
-	RequestTimeout            int                    `json:"requestTimeout,omitempty"`
-	IPFSMode                  fftypes.FFEnum         `json:"ipfsMode"`
-	RemoteFabricNetwork       bool                   `json:"remoteFabricNetwork,omitempty"`
-	ChannelName               string                 `json:"channelName,omitempty"`
-	ChaincodeName             string                 `json:"chaincodeName,omitempty"`
-	CustomPinSupport          bool                   `json:"customPinSupport,omitempty"`
-	RemoteNodeDeploy          bool                   `json:"remoteNodeDeploy,omitempty"`
-	EnvironmentVars           map[string]interface{} `json:"environmentVars"`
-	InitDir                   string                 `json:"-"`
-	RuntimeDir                string                 `json:"-"`
-	StackDir                  string                 `json:"-"`
-	State                     *StackState            `json:"-"`
+	Name                      string                       `json:"name,omitempty"`
+	Members                   []*Organization              `json:"members,omitempty"`
+	SwarmKey                  string                       `json:"swarmKey,omitempty"`
+	ExposedBlockchainPort     int                          `json:"exposedBlockchainPort,omitempty"`
+	ExposedPtmPort            int                          `json:"exposedPtmPort,omitempty"`
+	Database                  fftypes.FFEnum               `json:"database"`
+	BlockchainProvider        fftypes.FFEnum               `json:"blockchainProvider"`
+	BlockchainConnector       fftypes.FFEnum               `json:"blockchainConnector"`
+	BlockchainNodeProvider    fftypes.FFEnum               `json:"blockchainNodeProvider"`
+	PrivateTransactionManager fftypes.FFEnum               `json:"privateTransactionManager"`
+	Consensus                 fftypes.FFEnum               `json:"consensus"`
+	TokenProviders            []fftypes.FFEnum             `json:"tokenProviders"`
+	VersionManifest           *VersionManifest             `json:"versionManifest,omitempty"`
+	PrometheusEnabled         bool                         `json:"prometheusEnabled,omitempty"`
+	SandboxEnabled            bool                         `json:"sandboxEnabled,omitempty"`
+	MultipartyEnabled         bool                         `json:"multiparty"`
+	ExposedPrometheusPort     int                          `json:"exposedPrometheusPort,omitempty"`
+	ContractAddress           string                       `json:"contractAddress,omitempty"`
+	ChainIDPtr                *int64                       `json:"chainID,omitempty"`
+	BlockPeriod               *int                         `json:"blockPeriod,omitempty"`
+	RemoteNodeURL             string                       `json:"remoteNodeURL,omitempty"`
+	RemoteNodeAuth            *RemoteNodeAuth              `json:"-"`
+	DisableTokenFactories     bool                         `json:"disableTokenFactories,omitempty"`
+	RequestTimeout            int                          `json:"requestTimeout,omitempty"`
+	RetryPolicy               *RetryPolicy                 `json:"retryPolicy,omitempty"`
+	IPFSMode                  fftypes.FFEnum               `json:"ipfsMode"`
+	ResourceProfile           string                       `json:"resourceProfile,omitempty"`
+	Resources                 map[string]*ServiceResources `json:"resources,omitempty"`
+	RemoteFabricNetwork       bool                         `json:"remoteFabricNetwork,omitempty"`
+	ChannelName               string                       `json:"channelName,omitempty"`
+	ChaincodeName             string                       `json:"chaincodeName,omitempty"`
+	CustomPinSupport          bool                         `json:"customPinSupport,omitempty"`
+	RemoteNodeDeploy          bool                         `json:"remoteNodeDeploy,omitempty"`
+	Offline                   bool                         `json:"offline,omitempty"`
+	DetachedServices          []string                     `json:"detachedServices,omitempty"`
+	EnvironmentVars           map[string]interface{}       `json:"environmentVars"`
+	Hooks                     map[string]string            `json:"hooks,omitempty"`
+	InitDir                   string                       `json:"-"`
+	RuntimeDir                string                       `json:"-"`
+	StackDir                  string                       `json:"-"`
+	State                     *StackState                  `json:"-"`
 }
 
 func (s *Stack) ChainID() int64 {
@@ -111,6 +119,17 @@ func (s *Stack) IsOldFileStructure() (bool, error) {
 	}
 }
 
+// IsDetached returns true if the given docker compose service has been taken out of
+// the stack, so that a local build can be run in its place
+func (s *Stack) IsDetached(serviceName string) bool {
+	for _, detached := range s.DetachedServices {
+		if detached == serviceName {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Stack) ConcatenateWithProvidedEnvironmentVars(input map[string]interface{}) map[string]interface{} {
 	result := make(map[string]interface{})
 	for k, v := range input {
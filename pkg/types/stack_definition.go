@@ -0,0 +1,365 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// StackDefinitionVersion is the newest version of the stack definition file format
+// that this version of the CLI understands
+const StackDefinitionVersion = 1
+
+// StackDefinition is a declarative description of a stack, which can be checked in
+// and passed to `ff init --from` instead of a long list of command line flags.
+// Any field that is left out of the file keeps its normal default value.
+type StackDefinition struct {
+	Version                  int                          `json:"version" yaml:"version"`
+	Name                     string                       `json:"name,omitempty" yaml:"name,omitempty"`
+	Members                  []*MemberDefinition          `json:"members,omitempty" yaml:"members,omitempty"`
+	ExternalProcesses        int                          `json:"externalProcesses,omitempty" yaml:"externalProcesses,omitempty"`
+	Database                 string                       `json:"database,omitempty" yaml:"database,omitempty"`
+	Blockchain               *BlockchainDefinition        `json:"blockchain,omitempty" yaml:"blockchain,omitempty"`
+	TokenProviders           []string                     `json:"tokenProviders,omitempty" yaml:"tokenProviders,omitempty"`
+	Release                  *ReleaseDefinition           `json:"release,omitempty" yaml:"release,omitempty"`
+	Ports                    *PortsDefinition             `json:"ports,omitempty" yaml:"ports,omitempty"`
+	IPFSMode                 string                       `json:"ipfsMode,omitempty" yaml:"ipfsMode,omitempty"`
+	ResourceProfile          string                       `json:"resourceProfile,omitempty" yaml:"resourceProfile,omitempty"`
+	Resources                map[string]*ServiceResources `json:"resources,omitempty" yaml:"resources,omitempty"`
+	PrometheusEnabled        *bool                        `json:"prometheusEnabled,omitempty" yaml:"prometheusEnabled,omitempty"`
+	SandboxEnabled           *bool                        `json:"sandboxEnabled,omitempty" yaml:"sandboxEnabled,omitempty"`
+	MultipartyEnabled        *bool                        `json:"multiparty,omitempty" yaml:"multiparty,omitempty"`
+	DisableTokenFactories    *bool                        `json:"disableTokenFactories,omitempty" yaml:"disableTokenFactories,omitempty"`
+	RequestTimeout           int                          `json:"requestTimeout,omitempty" yaml:"requestTimeout,omitempty"`
+	RetryPolicy              *RetryPolicy                 `json:"retryPolicy,omitempty" yaml:"retryPolicy,omitempty"`
+	ExtraCoreConfigPath      string                       `json:"coreConfig,omitempty" yaml:"coreConfig,omitempty"`
+	ExtraConnectorConfigPath string                       `json:"connectorConfig,omitempty" yaml:"connectorConfig,omitempty"`
+	EnvironmentVars          map[string]string            `json:"environmentVars,omitempty" yaml:"environmentVars,omitempty"`
+	Hooks                    map[string]string            `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+}
+
+type MemberDefinition struct {
+	OrgName  string `json:"orgName,omitempty" yaml:"orgName,omitempty"`
+	NodeName string `json:"nodeName,omitempty" yaml:"nodeName,omitempty"`
+}
+
+type BlockchainDefinition struct {
+	Provider                  string   `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Node                      string   `json:"node,omitempty" yaml:"node,omitempty"`
+	Connector                 string   `json:"connector,omitempty" yaml:"connector,omitempty"`
+	Consensus                 string   `json:"consensus,omitempty" yaml:"consensus,omitempty"`
+	PrivateTransactionManager string   `json:"privateTransactionManager,omitempty" yaml:"privateTransactionManager,omitempty"`
+	ChainID                   int64    `json:"chainID,omitempty" yaml:"chainID,omitempty"`
+	BlockPeriod               *int     `json:"blockPeriod,omitempty" yaml:"blockPeriod,omitempty"`
+	ContractAddress           string   `json:"contractAddress,omitempty" yaml:"contractAddress,omitempty"`
+	RemoteNodeURL             string   `json:"remoteNodeURL,omitempty" yaml:"remoteNodeURL,omitempty"`
+	RemoteNodeDeploy          *bool    `json:"remoteNodeDeploy,omitempty" yaml:"remoteNodeDeploy,omitempty"`
+	ChannelName               string   `json:"channel,omitempty" yaml:"channel,omitempty"`
+	ChaincodeName             string   `json:"chaincode,omitempty" yaml:"chaincode,omitempty"`
+	CustomPinSupport          *bool    `json:"customPinSupport,omitempty" yaml:"customPinSupport,omitempty"`
+	CCPYAMLPaths              []string `json:"ccp,omitempty" yaml:"ccp,omitempty"`
+	MSPPaths                  []string `json:"msp,omitempty" yaml:"msp,omitempty"`
+}
+
+type ReleaseDefinition struct {
+	Version      string `json:"version,omitempty" yaml:"version,omitempty"`
+	Channel      string `json:"channel,omitempty" yaml:"channel,omitempty"`
+	ManifestPath string `json:"manifest,omitempty" yaml:"manifest,omitempty"`
+}
+
+type PortsDefinition struct {
+	FireFlyBase  int   `json:"fireflyBase,omitempty" yaml:"fireflyBase,omitempty"`
+	ServicesBase int   `json:"servicesBase,omitempty" yaml:"servicesBase,omitempty"`
+	PtmBase      int   `json:"ptmBase,omitempty" yaml:"ptmBase,omitempty"`
+	Prometheus   int   `json:"prometheus,omitempty" yaml:"prometheus,omitempty"`
+	Auto         *bool `json:"auto,omitempty" yaml:"auto,omitempty"`
+}
+
+// The names ApplyToInitOptions gives for the flags of the release and Fabric channels
+const (
+	ReleaseChannelFlag = "release-channel"
+	FabricChannelFlag  = "fabric-channel"
+)
+
+// ApplyToInitOptions copies every value that is set in the definition onto the options,
+// leaving anything that the definition does not mention untouched. isExplicit is given the
+// name of the command line flag for each value, and values for flags that the user set
+// explicitly are not overridden. It can be nil if no flags were set. The release channel and
+// the Fabric channel are both set with --channel, on different commands, so they are given as
+// ReleaseChannelFlag and FabricChannelFlag instead.
+func (d *StackDefinition) ApplyToInitOptions(options *InitOptions, isExplicit func(flag string) bool) error {
+	if d.Version == 0 {
+		return fmt.Errorf("stack definition is missing a version")
+	}
+	if d.Version > StackDefinitionVersion {
+		return fmt.Errorf("stack definition version %d is not supported by this version of the CLI", d.Version)
+	}
+	a := &definitionApplier{isExplicit: isExplicit}
+
+	setString(&options.StackName, d.Name)
+	if len(d.Members) > 0 {
+		options.MemberCount = len(d.Members)
+		orgNames := make([]string, len(d.Members))
+		nodeNames := make([]string, len(d.Members))
+		for i, member := range d.Members {
+			if member == nil {
+				continue
+			}
+			orgNames[i] = member.OrgName
+			nodeNames[i] = member.NodeName
+		}
+		a.setStrings("org-name", &options.OrgNames, orgNames)
+		a.setStrings("node-name", &options.NodeNames, nodeNames)
+	}
+	a.setInt("external", &options.ExternalProcesses, d.ExternalProcesses)
+	a.setString("database", &options.DatabaseProvider, d.Database)
+	a.setStrings("token-providers", &options.TokenProviders, d.TokenProviders)
+	a.setString("ipfs-mode", &options.IPFSMode, d.IPFSMode)
+	a.setString("resources", &options.ResourceProfile, d.ResourceProfile)
+	if d.Resources != nil {
+		options.Resources = d.Resources
+	}
+	if _, err := ResolveResources(options.ResourceProfile, options.Resources); err != nil {
+		return err
+	}
+	a.setBool("prometheus-enabled", &options.PrometheusEnabled, d.PrometheusEnabled)
+	a.setBool("sandbox-enabled", &options.SandboxEnabled, d.SandboxEnabled)
+	a.setBool("multiparty", &options.MultipartyEnabled, d.MultipartyEnabled)
+	setBool(&options.DisableTokenFactories, d.DisableTokenFactories)
+	a.setInt("request-timeout", &options.RequestTimeout, d.RequestTimeout)
+	if p := d.RetryPolicy; p != nil {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+		if options.RetryPolicy == nil {
+			options.RetryPolicy = &RetryPolicy{}
+		}
+		if !a.explicit("request-retries") {
+			options.RetryPolicy.Retries = p.Retries
+		}
+		a.setString("request-retry-delay", &options.RetryPolicy.InitialDelay, p.InitialDelay)
+		a.setString("request-retry-max-delay", &options.RetryPolicy.MaxDelay, p.MaxDelay)
+	}
+	a.setString("core-config", &options.ExtraCoreConfigPath, d.ExtraCoreConfigPath)
+	a.setString("connector-config", &options.ExtraConnectorConfigPath, d.ExtraConnectorConfigPath)
+	if d.EnvironmentVars != nil && !a.explicit("environment-vars") {
+		options.EnvironmentVars = d.EnvironmentVars
+	}
+	if d.Hooks != nil {
+		for name := range d.Hooks {
+			if err := ValidateHookName(name); err != nil {
+				return err
+			}
+		}
+		// Hooks set with --hook are added on top of these
+		options.Hooks = d.Hooks
+	}
+
+	if b := d.Blockchain; b != nil {
+		a.setString("blockchain-provider", &options.BlockchainProvider, b.Provider)
+		a.setString("blockchain-node", &options.BlockchainNodeProvider, b.Node)
+		a.setString("blockchain-connector", &options.BlockchainConnector, b.Connector)
+		a.setString("consensus", &options.Consensus, b.Consensus)
+		a.setString("private-transaction-manager", &options.PrivateTransactionManager, b.PrivateTransactionManager)
+		if b.ChainID != 0 && !a.explicit("chain-id") {
+			options.ChainID = b.ChainID
+		}
+		if b.BlockPeriod != nil && !a.explicit("block-period") {
+			options.BlockPeriod = *b.BlockPeriod
+		}
+		a.setString("contract-address", &options.ContractAddress, b.ContractAddress)
+		a.setString("remote-node-url", &options.RemoteNodeURL, b.RemoteNodeURL)
+		a.setBool("remote-node-deploy", &options.RemoteNodeDeploy, b.RemoteNodeDeploy)
+		a.setString(FabricChannelFlag, &options.ChannelName, b.ChannelName)
+		a.setString("chaincode", &options.ChaincodeName, b.ChaincodeName)
+		a.setBool("custom-pin-support", &options.CustomPinSupport, b.CustomPinSupport)
+		a.setStrings("ccp", &options.CCPYAMLPaths, b.CCPYAMLPaths)
+		a.setStrings("msp", &options.MSPPaths, b.MSPPaths)
+	}
+
+	if r := d.Release; r != nil {
+		a.setString("release", &options.FireFlyVersion, r.Version)
+		a.setString(ReleaseChannelFlag, &options.ReleaseChannel, r.Channel)
+		a.setString("manifest", &options.ManifestPath, r.ManifestPath)
+	}
+
+	if p := d.Ports; p != nil {
+		a.setInt("firefly-base-port", &options.FireFlyBasePort, p.FireFlyBase)
+		a.setInt("services-base-port", &options.ServicesBasePort, p.ServicesBase)
+		a.setInt("ptm-base-port", &options.PtmBasePort, p.PtmBase)
+		a.setInt("prometheus-port", &options.PrometheusPort, p.Prometheus)
+		a.setBool("auto-ports", &options.AutoPorts, p.Auto)
+	}
+	return nil
+}
+
+// ResolvePaths makes the relative paths in the definition relative to dir, which is
+// the directory the definition file is in
+func (d *StackDefinition) ResolvePaths(dir string) {
+	resolve := func(p *string) {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	resolve(&d.ExtraCoreConfigPath)
+	resolve(&d.ExtraConnectorConfigPath)
+	if d.Release != nil {
+		resolve(&d.Release.ManifestPath)
+	}
+	if d.Blockchain != nil {
+		for i := range d.Blockchain.CCPYAMLPaths {
+			resolve(&d.Blockchain.CCPYAMLPaths[i])
+		}
+		for i := range d.Blockchain.MSPPaths {
+			resolve(&d.Blockchain.MSPPaths[i])
+		}
+	}
+}
+
+// definitionApplier only sets a value if its command line flag was not set explicitly
+type definitionApplier struct {
+	isExplicit func(flag string) bool
+}
+
+func (a *definitionApplier) explicit(flag string) bool {
+	return a.isExplicit != nil && a.isExplicit(flag)
+}
+
+func (a *definitionApplier) setString(flag string, dest *string, value string) {
+	if !a.explicit(flag) {
+		setString(dest, value)
+	}
+}
+
+func (a *definitionApplier) setStrings(flag string, dest *[]string, value []string) {
+	if value != nil && !a.explicit(flag) {
+		*dest = value
+	}
+}
+
+func (a *definitionApplier) setInt(flag string, dest *int, value int) {
+	if !a.explicit(flag) {
+		setInt(dest, value)
+	}
+}
+
+func (a *definitionApplier) setBool(flag string, dest *bool, value *bool) {
+	if !a.explicit(flag) {
+		setBool(dest, value)
+	}
+}
+
+// NewStackDefinition rebuilds a definition from an existing stack. Settings that are
+// not recorded in stack.json, such as the paths of extra config files, are left out.
+func NewStackDefinition(stack *Stack) *StackDefinition {
+	d := &StackDefinition{
+		Version:           StackDefinitionVersion,
+		Name:              stack.Name,
+		Members:           make([]*MemberDefinition, len(stack.Members)),
+		Database:          stack.Database.String(),
+		TokenProviders:    FFEnumArrayToStrings(stack.TokenProviders),
+		IPFSMode:          stack.IPFSMode.String(),
+		ResourceProfile:   stack.ResourceProfile,
+		Resources:         stack.Resources,
+		PrometheusEnabled: &stack.PrometheusEnabled,
+		SandboxEnabled:    &stack.SandboxEnabled,
+		MultipartyEnabled: &stack.MultipartyEnabled,
+		RequestTimeout:    stack.RequestTimeout,
+		RetryPolicy:       stack.RetryPolicy,
+		Blockchain: &BlockchainDefinition{
+			Provider:                  stack.BlockchainProvider.String(),
+			Node:                      stack.BlockchainNodeProvider.String(),
+			Connector:                 stack.BlockchainConnector.String(),
+			Consensus:                 stack.Consensus.String(),
+			PrivateTransactionManager: stack.PrivateTransactionManager.String(),
+			ContractAddress:           stack.ContractAddress,
+			RemoteNodeURL:             stack.RemoteNodeURL,
+		},
+		Ports: &PortsDefinition{
+			ServicesBase: stack.ExposedBlockchainPort,
+			PtmBase:      stack.ExposedPtmPort,
+			Prometheus:   stack.ExposedPrometheusPort,
+		},
+	}
+	if stack.BlockchainProvider.Equals(BlockchainProviderEthereum) {
+		d.Blockchain.ChainID = stack.ChainID()
+	}
+	if stack.RemoteNodeDeploy {
+		d.Blockchain.RemoteNodeDeploy = &stack.RemoteNodeDeploy
+	}
+	if stack.BlockchainProvider.Equals(BlockchainProviderFabric) && stack.RemoteFabricNetwork {
+		d.Blockchain.ChannelName = stack.ChannelName
+		d.Blockchain.ChaincodeName = stack.ChaincodeName
+		d.Blockchain.CustomPinSupport = &stack.CustomPinSupport
+	}
+	if stack.DisableTokenFactories {
+		d.DisableTokenFactories = &stack.DisableTokenFactories
+	}
+	if len(d.TokenProviders) == 0 {
+		d.TokenProviders = []string{TokenProviderNone.String()}
+	}
+
+	for i, member := range stack.Members {
+		d.Members[i] = &MemberDefinition{
+			OrgName:  member.OrgName,
+			NodeName: member.NodeName,
+		}
+		if member.External {
+			d.ExternalProcesses++
+		}
+		if i == 0 {
+			d.Ports.FireFlyBase = member.ExposedFireflyPort
+		}
+	}
+
+	if len(stack.EnvironmentVars) > 0 {
+		d.EnvironmentVars = make(map[string]string, len(stack.EnvironmentVars))
+		for key, value := range stack.EnvironmentVars {
+			d.EnvironmentVars[key] = fmt.Sprint(value)
+		}
+	}
+
+	if len(stack.Hooks) > 0 {
+		d.Hooks = stack.Hooks
+	}
+
+	if stack.VersionManifest != nil && stack.VersionManifest.FireFly != nil && !stack.VersionManifest.FireFly.Local && stack.VersionManifest.FireFly.Tag != "" {
+		d.Release = &ReleaseDefinition{
+			Version: stack.VersionManifest.FireFly.Tag,
+		}
+	}
+	return d
+}
+
+func setString(dest *string, value string) {
+	if value != "" {
+		*dest = value
+	}
+}
+
+func setInt(dest *int, value int) {
+	if value != 0 {
+		*dest = value
+	}
+}
+
+func setBool(dest *bool, value *bool) {
+	if value != nil {
+		*dest = *value
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+const (
+	StackStatusRunning          = "running"
+	StackStatusPartiallyRunning = "partially_running"
+	StackStatusNotRunning       = "not_running"
+
+	ServiceStateNotCreated = "not_created"
+)
+
+// StackInfo is the machine readable description of a stack that is printed by the
+// ls, ps and info commands. Fields are only ever added to it, so that scripts which
+// consume it keep working.
+type StackInfo struct {
+	Name                   string              `json:"name"`
+	Status                 string              `json:"status,omitempty"`
+	BlockchainProvider     string              `json:"blockchainProvider"`
+	BlockchainNodeProvider string              `json:"blockchainNodeProvider,omitempty"`
+	BlockchainConnector    string              `json:"blockchainConnector,omitempty"`
+	Database               string              `json:"database,omitempty"`
+	TokenProviders         []string            `json:"tokenProviders"`
+	BlockchainPort         int                 `json:"blockchainPort,omitempty"`
+	PrometheusPort         int                 `json:"prometheusPort,omitempty"`
+	Members                []*MemberInfo       `json:"members"`
+	Services               []*ServiceInfo      `json:"services,omitempty"`
+	DeployedContracts      []*DeployedContract `json:"deployedContracts,omitempty"`
+}
+
+type MemberInfo struct {
+	ID       string       `json:"id"`
+	OrgName  string       `json:"orgName"`
+	NodeName string       `json:"nodeName"`
+	External bool         `json:"external"`
+	Ports    *MemberPorts `json:"ports"`
+}
+
+type MemberPorts struct {
+	FireFly                   int   `json:"firefly,omitempty"`
+	FireFlyAdmin              int   `json:"fireflyAdmin,omitempty"`
+	FireFlyMetrics            int   `json:"fireflyMetrics,omitempty"`
+	UI                        int   `json:"ui,omitempty"`
+	Connector                 int   `json:"connector,omitempty"`
+	ConnectorMetrics          int   `json:"connectorMetrics,omitempty"`
+	Database                  int   `json:"database,omitempty"`
+	DataExchange              int   `json:"dataexchange,omitempty"`
+	IPFSApi                   int   `json:"ipfsApi,omitempty"`
+	IPFSGateway               int   `json:"ipfsGateway,omitempty"`
+	Sandbox                   int   `json:"sandbox,omitempty"`
+	PrivateTransactionManager int   `json:"privateTransactionManager,omitempty"`
+	Tokens                    []int `json:"tokens,omitempty"`
+}
+
+type ServiceInfo struct {
+	Name      string `json:"name"`
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	State     string `json:"state"`
+	Health    string `json:"health,omitempty"`
+	Status    string `json:"status,omitempty"`
+}
+
+func NewMemberInfo(member *Organization) *MemberInfo {
+	return &MemberInfo{
+		ID:       member.ID,
+		OrgName:  member.OrgName,
+		NodeName: member.NodeName,
+		External: member.External,
+		Ports: &MemberPorts{
+			FireFly:                   member.ExposedFireflyPort,
+			FireFlyAdmin:              member.ExposedFireflyAdminSPIPort,
+			FireFlyMetrics:            member.ExposedFireflyMetricsPort,
+			UI:                        member.ExposedUIPort,
+			Connector:                 member.ExposedConnectorPort,
+			ConnectorMetrics:          member.ExposedConnectorMetricsPort,
+			Database:                  member.ExposedDatabasePort,
+			DataExchange:              member.ExposedDataexchangePort,
+			IPFSApi:                   member.ExposedIPFSApiPort,
+			IPFSGateway:               member.ExposedIPFSGWPort,
+			Sandbox:                   member.ExposedSandboxPort,
+			PrivateTransactionManager: member.ExposePtmTpPort,
+			Tokens:                    member.ExposedTokensPorts,
+		},
+	}
+}
+
+// StackHealth is the result of checking every service of a stack, as printed by the status command
+type StackHealth struct {
+	Name     string          `json:"name"`
+	Healthy  bool            `json:"healthy"`
+	Members  []*MemberHealth `json:"members"`
+	Services []*ServiceInfo  `json:"services"`
+}
+
+// MemberHealth is the health of the services that belong to a single member, and whether the
+// member's FireFly API reports its org and node as registered
+type MemberHealth struct {
+	ID             string         `json:"id"`
+	OrgName        string         `json:"orgName"`
+	NodeName       string         `json:"nodeName"`
+	Healthy        bool           `json:"healthy"`
+	APIError       string         `json:"apiError,omitempty"`
+	OrgRegistered  *bool          `json:"orgRegistered,omitempty"`
+	NodeRegistered *bool          `json:"nodeRegistered,omitempty"`
+	Services       []*ServiceInfo `json:"services"`
+}